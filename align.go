@@ -0,0 +1,44 @@
+package alog
+
+import (
+	"bytes"
+	"regexp"
+)
+
+// alignRegexp matches the template that right-aligns what follows it.
+var alignRegexp = regexp.MustCompile(`{(rpad|align:right)}`)
+
+// alignRight pads a completed line at its first {rpad} (or {align:right})
+// template, so that whatever follows ends at the right edge of the line
+// width, e.g. "built %s{rpad}%s" to push a duration to the right margin.
+// The template works in the prefix and suffix as well as in format strings,
+// provided color templates are enabled; in partial lines, it is shown as a
+// space. Must be called with the writer lock held.
+func (l *Logger) alignRight(buf []byte) []byte {
+	if bytes.IndexByte(buf, '{') == -1 {
+		return buf
+	}
+	loc := alignRegexp.FindIndex(buf)
+	if loc == nil {
+		return buf
+	}
+	left := buf[:loc[0]]
+	right := alignRegexp.ReplaceAll(buf[loc[1]:], nil)
+	padding := l.getLineWidth() - stringLen(left) - stringLen(right)
+	if padding < 1 {
+		padding = 1
+	}
+	out := make([]byte, 0, len(left)+padding+len(right))
+	out = append(out, left...)
+	out = append(out, bytes.Repeat(bytesSpace, padding)...)
+	return append(out, right...)
+}
+
+// removeAlignTemplates replaces alignment templates in a partial line with a
+// space.
+func removeAlignTemplates(buf []byte) []byte {
+	if bytes.IndexByte(buf, '{') == -1 {
+		return buf
+	}
+	return alignRegexp.ReplaceAll(buf, bytesSpace)
+}
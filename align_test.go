@@ -0,0 +1,23 @@
+package alog
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAlignRight(t *testing.T) {
+	assert := assert.New(t)
+	var buf bytes.Buffer
+	var writer = New(&buf, "", 0)
+	defer writer.Close()
+	writer.SetTerminalWidth(20)
+	writer.EnableColorTemplate()
+	writer.EnableColor()
+	writer.Printf("built @(green:%s){rpad}%s\n", "app", "1.2s")
+	assert.Equal("built \033[32mapp\033[39m       1.2s\n", buf.String())
+	buf.Reset()
+	writer.Printf("{align:right}%s\n", "right")
+	assert.Equal("               right\n", buf.String())
+}
@@ -0,0 +1,45 @@
+// Package alogcobra wires alog's command-line switches into a cobra command:
+//
+//	root := &cobra.Command{Use: "mytool"}
+//	alogcobra.Bind(root)
+//
+// gives root and all its subcommands --log-level, --no-color, --log-file,
+// --log-json and -v/-vv (see alog.RegisterFlags and
+// alog.RegisterVerbosityFlags), which configure alog.DefaultLogger.
+package alogcobra
+
+import (
+	"flag"
+
+	alog "github.com/duppercloud/ansi-log"
+	"github.com/spf13/cobra"
+)
+
+// Bind adds alog's flags to cmd's persistent flags, and sends cobra's own
+// error and usage messages through alog so that they don't garble partial
+// lines. It also wraps cmd's persistent post-run hook, keeping any already
+// set, to complete DefaultLogger's partial line when the command finishes. As
+// usual with cobra, a subcommand that sets its own persistent hooks replaces
+// this unless cobra.EnableTraverseRunHooks is set.
+func Bind(cmd *cobra.Command) {
+	fs := flag.NewFlagSet(cmd.Name(), flag.ContinueOnError)
+	alog.RegisterFlags(fs)
+	alog.RegisterVerbosityFlags(fs)
+	cmd.PersistentFlags().AddGoFlagSet(fs)
+	// Set here rather than in a hook, since flag errors are reported before
+	// any hooks run.
+	cmd.SetErr(alog.Stderr.RawWriter())
+
+	postRun, postRunE := cmd.PersistentPostRun, cmd.PersistentPostRunE
+	cmd.PersistentPostRun = nil
+	cmd.PersistentPostRunE = func(c *cobra.Command, args []string) error {
+		defer alog.DefaultLogger.Flush()
+		if postRunE != nil {
+			return postRunE(c, args)
+		}
+		if postRun != nil {
+			postRun(c, args)
+		}
+		return nil
+	}
+}
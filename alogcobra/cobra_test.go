@@ -0,0 +1,34 @@
+package alogcobra
+
+import (
+	"bytes"
+	"testing"
+
+	alog "github.com/duppercloud/ansi-log"
+	"github.com/spf13/cobra"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBind(t *testing.T) {
+	assert := assert.New(t)
+	var buf bytes.Buffer
+	defer alog.SetOutput(alog.DefaultLogger.Writer())
+	defer alog.SetVerbosity(alog.Verbosity())
+	defer alog.SetMinLevel(alog.LevelInfo)
+	alog.SetOutput(&buf)
+	verbosity := alog.Verbosity()
+	ran := false
+	root := &cobra.Command{Use: "tool", Run: func(cmd *cobra.Command, args []string) {
+		ran = true
+		alog.Printf("info\n")
+		alog.Warnf("warning\n")
+	}}
+	Bind(root)
+	assert.Equal(alog.Stderr.RawWriter(), root.ErrOrStderr(), "flag errors go through alog too")
+	root.SetArgs([]string{"--log-level=warn", "-v"})
+	assert.NoError(root.Execute())
+	assert.True(ran)
+	assert.Equal(verbosity+1, alog.Verbosity())
+	assert.NotContains(buf.String(), "info")
+	assert.Contains(buf.String(), "warning")
+}
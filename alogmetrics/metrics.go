@@ -0,0 +1,106 @@
+// Package alogmetrics exports alog Logger counters to monitoring systems. It
+// publishes them through expvar and serves them in the Prometheus text
+// exposition format, without depending on any Prometheus client library.
+package alogmetrics
+
+import (
+	"expvar"
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+
+	alog "github.com/duppercloud/ansi-log"
+)
+
+var (
+	mutex   sync.Mutex
+	loggers = make(map[string]*alog.Logger)
+)
+
+// Register makes the counters of l available under the given name. Registering
+// a second Logger under the same name replaces the first.
+func Register(name string, l *alog.Logger) {
+	mutex.Lock()
+	defer mutex.Unlock()
+	loggers[name] = l
+}
+
+// Unregister removes the Logger registered under name, if any.
+func Unregister(name string) {
+	mutex.Lock()
+	defer mutex.Unlock()
+	delete(loggers, name)
+}
+
+func snapshot() map[string]alog.Stats {
+	mutex.Lock()
+	registered := make(map[string]*alog.Logger, len(loggers))
+	for name, l := range loggers {
+		registered[name] = l
+	}
+	mutex.Unlock()
+	// Read the stats outside of our own lock, as each Logger takes its writer's lock.
+	stats := make(map[string]alog.Stats, len(registered))
+	for name, l := range registered {
+		stats[name] = l.Stats()
+	}
+	return stats
+}
+
+// PublishExpvar publishes the counters of all registered Loggers as a single
+// expvar variable with the given name (e.g. "alog"). Like expvar.Publish, it
+// panics if the name is already in use.
+func PublishExpvar(name string) {
+	expvar.Publish(name, expvar.Func(func() interface{} {
+		return snapshot()
+	}))
+}
+
+var metrics = []struct {
+	name  string
+	help  string
+	value func(alog.Stats) uint64
+}{
+	{"alog_lines_total", "Completed lines written.", func(s alog.Stats) uint64 { return s.Lines }},
+	{"alog_bytes_total", "Bytes of completed lines written.", func(s alog.Stats) uint64 { return s.Bytes }},
+	{"alog_warnings_total", "Lines written at warn level.", func(s alog.Stats) uint64 { return s.Warns }},
+	{"alog_errors_total", "Lines written at error level.", func(s alog.Stats) uint64 { return s.Errors }},
+}
+
+// labelValueEscaper escapes label values as the text exposition format
+// requires: only backslashes, double quotes and newlines.
+var labelValueEscaper = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`)
+
+// WritePrometheus writes the counters of all registered Loggers to w in the
+// Prometheus text exposition format, labelled by logger name.
+func WritePrometheus(w io.Writer) error {
+	stats := snapshot()
+	names := make([]string, 0, len(stats))
+	for name := range stats {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, metric := range metrics {
+		if _, err := fmt.Fprintf(w, "# HELP %s %s\n# TYPE %s counter\n", metric.name, metric.help, metric.name); err != nil {
+			return err
+		}
+		for _, name := range names {
+			if _, err := fmt.Fprintf(w, "%s{logger=\"%s\"} %d\n", metric.name, labelValueEscaper.Replace(name), metric.value(stats[name])); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// Handler returns an http.Handler serving WritePrometheus, suitable for
+// mounting at /metrics or merging into an existing scrape endpoint.
+func Handler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+		WritePrometheus(w)
+	})
+}
@@ -0,0 +1,88 @@
+package alogmetrics
+
+import (
+	"bytes"
+	"encoding/json"
+	"expvar"
+	"net/http/httptest"
+	"testing"
+
+	alog "github.com/duppercloud/ansi-log"
+	"github.com/stretchr/testify/assert"
+)
+
+func newTestLogger(t *testing.T) *alog.Logger {
+	var buf bytes.Buffer
+	l := alog.New(&buf, "", 0)
+	t.Cleanup(func() { l.Close() })
+	l.DisableColor()
+	l.Println("one")
+	l.Warnf("two\n")
+	l.Errorf("three\n")
+	return l
+}
+
+// oddName has characters that the exposition format escapes in label values,
+// and a tab and non-ASCII letters, which it leaves as they are.
+const oddName = "ünïcode\t\"quoted\" \\ new\nline"
+
+func TestWritePrometheus(t *testing.T) {
+	assert := assert.New(t)
+	Register("app", newTestLogger(t))
+	defer Unregister("app")
+	Register(oddName, newTestLogger(t))
+	defer Unregister(oddName)
+	var buf bytes.Buffer
+	assert.NoError(WritePrometheus(&buf))
+	assert.Equal(`# HELP alog_lines_total Completed lines written.
+# TYPE alog_lines_total counter
+alog_lines_total{logger="app"} 3
+alog_lines_total{logger="ünïcode	\"quoted\" \\ new\nline"} 3
+# HELP alog_bytes_total Bytes of completed lines written.
+# TYPE alog_bytes_total counter
+alog_bytes_total{logger="app"} 14
+alog_bytes_total{logger="ünïcode	\"quoted\" \\ new\nline"} 14
+# HELP alog_warnings_total Lines written at warn level.
+# TYPE alog_warnings_total counter
+alog_warnings_total{logger="app"} 1
+alog_warnings_total{logger="ünïcode	\"quoted\" \\ new\nline"} 1
+# HELP alog_errors_total Lines written at error level.
+# TYPE alog_errors_total counter
+alog_errors_total{logger="app"} 1
+alog_errors_total{logger="ünïcode	\"quoted\" \\ new\nline"} 1
+`, buf.String())
+
+	Unregister(oddName)
+	buf.Reset()
+	assert.NoError(WritePrometheus(&buf))
+	assert.NotContains(buf.String(), "ünïcode")
+}
+
+func TestPublishExpvar(t *testing.T) {
+	assert := assert.New(t)
+	Register("app", newTestLogger(t))
+	defer Unregister("app")
+	PublishExpvar("alog_test")
+	variable := expvar.Get("alog_test")
+	if !assert.NotNil(variable) {
+		return
+	}
+	var stats map[string]alog.Stats
+	assert.NoError(json.Unmarshal([]byte(variable.String()), &stats))
+	assert.Equal(map[string]alog.Stats{"app": {Lines: 3, Bytes: 14, Warns: 1, Errors: 1}}, stats)
+	assert.Panics(func() { PublishExpvar("alog_test") }, "names can only be published once")
+}
+
+func TestHandler(t *testing.T) {
+	assert := assert.New(t)
+	Register("app", newTestLogger(t))
+	defer Unregister("app")
+	recorder := httptest.NewRecorder()
+	Handler().ServeHTTP(recorder, httptest.NewRequest("GET", "/metrics", nil))
+	assert.Equal(200, recorder.Code)
+	assert.Equal("text/plain; version=0.0.4; charset=utf-8", recorder.Header().Get("Content-Type"))
+	var expected bytes.Buffer
+	assert.NoError(WritePrometheus(&expected))
+	assert.Equal(expected.String(), recorder.Body.String())
+	assert.Contains(recorder.Body.String(), "alog_lines_total{logger=\"app\"} 3\n")
+}
@@ -0,0 +1,162 @@
+// Package alognotify alerts the user when something noteworthy happens in a
+// long-running program, such as an error being logged or a group of tasks
+// finishing: through an OSC 777 desktop notification (supported by several
+// terminals), the terminal bell, or the operating system's notification
+// service.
+package alognotify
+
+import (
+	"fmt"
+	"io"
+	"os/exec"
+	"runtime"
+	"strings"
+	"sync"
+	"sync/atomic"
+
+	alog "github.com/duppercloud/ansi-log"
+)
+
+// A Method is a way of delivering notifications. Methods may be combined.
+type Method int
+
+const (
+	// OSC777 asks the terminal to show a desktop notification.
+	OSC777 Method = 1 << iota
+	// Bell rings the terminal bell, which most terminals turn into a visual
+	// or taskbar alert when they're in the background. Like OSC777, it does
+	// nothing unless Out is a terminal, so that redirected output stays clean.
+	Bell
+	// Desktop uses notify-send on Linux and osascript on macOS.
+	Desktop
+)
+
+// A Notifier sends notifications. It is an alog.EntrySink: added to a
+// Logger, it notifies of every Error-level line.
+type Notifier struct {
+	// Methods selects how to deliver notifications.
+	Methods Method
+	// Out is where escape sequences are written; if nil, alog.Stderr's
+	// RawWriter, so that they don't disturb its partial lines.
+	Out io.Writer
+	// Title is used for notifications of logged errors; "Error" if empty.
+	Title string
+	// Focused, if set, is consulted before each notification, which is
+	// skipped if it returns true. Applications that track terminal focus
+	// (e.g. with focus reporting) can use it to only notify when the user
+	// isn't looking.
+	Focused func() bool
+
+	mutex      sync.Mutex
+	delivering atomic.Bool // whether an error is being notified of
+}
+
+// isTerminal reports whether escape sequences written to out reach a
+// terminal; tests replace it.
+var isTerminal = alog.WriterIsTerminal
+
+// New returns a Notifier using the given methods.
+func New(methods Method) *Notifier {
+	return &Notifier{Methods: methods}
+}
+
+// Attach returns a Notifier using the given methods, added as a sink to l.
+func Attach(l *alog.Logger, methods Method) *Notifier {
+	n := New(methods)
+	l.AddSink(n)
+	return n
+}
+
+// WriteEntry notifies of Error-level lines. It implements alog.EntrySink.
+// Notifications are delivered in the background, one at a time; errors
+// logged while one is being delivered are not notified of separately, so a
+// burst of errors doesn't turn into a burst of notifications.
+func (n *Notifier) WriteEntry(e *alog.Entry) error {
+	if e.Level < alog.LevelError {
+		return nil
+	}
+	if !n.delivering.CompareAndSwap(false, true) {
+		return nil
+	}
+	title := n.Title
+	if title == "" {
+		title = "Error"
+	}
+	body := strings.TrimSpace(e.PlainMessage())
+	// Sinks are called with the writer locked, so writing escapes through
+	// its RawWriter has to wait until we've returned.
+	go func() {
+		defer n.delivering.Store(false)
+		n.Notify(title, body)
+	}()
+	return nil
+}
+
+// Done notifies that the named group of tasks has finished, successfully if
+// err is nil.
+func (n *Notifier) Done(name string, err error) error {
+	if err != nil {
+		return n.Notify(name+" failed", err.Error())
+	}
+	return n.Notify(name+" finished", "")
+}
+
+// Notify sends a notification with the given title and body. It returns once
+// the notification has been handed to the terminal or the notification
+// service.
+func (n *Notifier) Notify(title, body string) error {
+	if n.Focused != nil && n.Focused() {
+		return nil
+	}
+	n.mutex.Lock()
+	defer n.mutex.Unlock()
+	out := n.Out
+	if out == nil {
+		out = alog.Stderr.RawWriter()
+	}
+	var seq string
+	if n.Methods&OSC777 != 0 {
+		seq += fmt.Sprintf("\033]777;notify;%s;%s\a", oscSafe(title), oscSafe(body))
+	}
+	if n.Methods&Bell != 0 {
+		seq += "\a"
+	}
+	if seq != "" && isTerminal(out) {
+		if _, err := io.WriteString(out, seq); err != nil {
+			return err
+		}
+	}
+	if n.Methods&Desktop != 0 {
+		if cmd := desktopCommand(runtime.GOOS, title, body); cmd != nil {
+			return cmd.Run()
+		}
+	}
+	return nil
+}
+
+// oscSafe removes characters that would end or corrupt an OSC sequence.
+func oscSafe(s string) string {
+	return strings.Map(func(r rune) rune {
+		if r < ' ' || r == 0x7f || r == ';' {
+			return ' '
+		}
+		return r
+	}, s)
+}
+
+func desktopCommand(goos, title, body string) *exec.Cmd {
+	switch goos {
+	case "linux", "freebsd", "openbsd", "netbsd":
+		return exec.Command("notify-send", title, body)
+	case "darwin":
+		script := fmt.Sprintf("display notification %s with title %s", appleScriptString(body), appleScriptString(title))
+		return exec.Command("osascript", "-e", script)
+	}
+	return nil
+}
+
+// appleScriptString quotes s as an AppleScript string literal, which only
+// knows backslash escapes for backslashes and double quotes.
+func appleScriptString(s string) string {
+	return `"` + strings.NewReplacer(`\`, `\\`, `"`, `\"`).Replace(s) + `"`
+}
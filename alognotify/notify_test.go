@@ -0,0 +1,91 @@
+package alognotify
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"sync"
+	"testing"
+	"time"
+
+	alog "github.com/duppercloud/ansi-log"
+	"github.com/stretchr/testify/assert"
+)
+
+// gatedBuffer collects writes, each of which waits for the gate to open.
+type gatedBuffer struct {
+	mutex sync.Mutex
+	buf   bytes.Buffer
+	gate  chan struct{}
+}
+
+func (b *gatedBuffer) Write(p []byte) (int, error) {
+	<-b.gate
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+	return b.buf.Write(p)
+}
+
+func (b *gatedBuffer) String() string {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+	return b.buf.String()
+}
+
+// pretendTerminal makes every writer count as a terminal until the test ends.
+func pretendTerminal(t *testing.T) {
+	prev := isTerminal
+	isTerminal = func(io.Writer) bool { return true }
+	t.Cleanup(func() { isTerminal = prev })
+}
+
+func TestNotify(t *testing.T) {
+	assert := assert.New(t)
+	var buf bytes.Buffer
+	n := New(OSC777 | Bell)
+	n.Out = &buf
+	assert.NoError(n.Notify("build; done", "all\ngood"))
+	assert.Equal("", buf.String(), "not a terminal")
+	pretendTerminal(t)
+	assert.NoError(n.Notify("build; done", "all\ngood"))
+	assert.Equal("\033]777;notify;build  done;all good\a\a", buf.String())
+	buf.Reset()
+	assert.NoError(n.Done("deploy", errors.New("timeout")))
+	assert.Equal("\033]777;notify;deploy failed;timeout\a\a", buf.String())
+	buf.Reset()
+	n.Focused = func() bool { return true }
+	assert.NoError(n.Notify("ignored", ""))
+	assert.Equal("", buf.String())
+}
+
+func TestAttach(t *testing.T) {
+	assert := assert.New(t)
+	pretendTerminal(t)
+	out := &gatedBuffer{gate: make(chan struct{})}
+	var logBuf bytes.Buffer
+	l := alog.New(&logBuf, "", 0)
+	defer l.Close()
+	n := Attach(l, Bell)
+	n.Out = out
+	l.Printf("fine\n")
+	l.Errorf("first\n")
+	l.Errorf("second\n")
+	close(out.gate)
+	assert.Eventually(func() bool { return !n.delivering.Load() }, 5*time.Second, time.Millisecond)
+	assert.Equal("\a", out.String(), "errors logged during delivery are dropped")
+	l.Errorf("third\n")
+	assert.Eventually(func() bool { return out.String() == "\a\a" }, 5*time.Second, time.Millisecond)
+}
+
+func TestDesktopCommand(t *testing.T) {
+	assert := assert.New(t)
+	cmd := desktopCommand("darwin", `say "hi"`, `C:\temp`)
+	if assert.NotNil(cmd) {
+		assert.Equal([]string{"osascript", "-e", `display notification "C:\\temp" with title "say \"hi\""`}, cmd.Args)
+	}
+	cmd = desktopCommand("linux", "title", "body")
+	if assert.NotNil(cmd) {
+		assert.Equal([]string{"notify-send", "title", "body"}, cmd.Args)
+	}
+	assert.Nil(desktopCommand("plan9", "title", "body"))
+}
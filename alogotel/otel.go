@@ -0,0 +1,80 @@
+// Package alogotel correlates alog output with OpenTelemetry traces.
+//
+// Install registers a context annotator so that lines printed with
+// alog.PrintContext and friends carry the trace_id and span_id of the span in
+// their context. Those fields show up in EntrySinks such as alog.NetWriter's
+// JSON output. Sink forwards lines to the OpenTelemetry logs API, so they are
+// exported as OTLP log records by whatever LoggerProvider is installed.
+package alogotel
+
+import (
+	"context"
+
+	alog "github.com/duppercloud/ansi-log"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/log"
+	"go.opentelemetry.io/otel/log/global"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// Install registers the trace annotator with alog. Call it once at startup.
+// Calling the returned function unregisters it.
+func Install() (uninstall func()) {
+	return alog.AddContextAnnotator(Annotate)
+}
+
+// Annotate returns trace_id and span_id fields for the span carried by ctx,
+// if it is valid.
+func Annotate(ctx context.Context) []alog.Field {
+	spanContext := trace.SpanContextFromContext(ctx)
+	if !spanContext.IsValid() {
+		return nil
+	}
+	return []alog.Field{
+		{Key: "trace_id", Value: spanContext.TraceID().String()},
+		{Key: "span_id", Value: spanContext.SpanID().String()},
+	}
+}
+
+// A Sink emits every line it receives as an OpenTelemetry log record. Add it to
+// Loggers with AddSink.
+type Sink struct {
+	logger log.Logger
+}
+
+// NewSink returns a Sink that emits records through the global LoggerProvider
+// under the given instrumentation scope name.
+func NewSink(name string) *Sink {
+	return NewSinkWithProvider(global.GetLoggerProvider(), name)
+}
+
+// NewSinkWithProvider is like NewSink but uses the given LoggerProvider.
+func NewSinkWithProvider(provider log.LoggerProvider, name string) *Sink {
+	return &Sink{logger: provider.Logger(name)}
+}
+
+var severities = map[alog.Level]log.Severity{
+	alog.LevelInfo:  log.SeverityInfo,
+	alog.LevelWarn:  log.SeverityWarn,
+	alog.LevelError: log.SeverityError,
+}
+
+// WriteEntry implements alog.EntrySink. The trace context is taken from the
+// entry's Context, so records are correlated with the active span.
+func (s *Sink) WriteEntry(e *alog.Entry) error {
+	var record log.Record
+	record.SetTimestamp(e.Time)
+	record.SetObservedTimestamp(e.Time)
+	record.SetSeverity(severities[e.Level])
+	record.SetSeverityText(e.Level.String())
+	record.SetBody(attribute.StringValue(e.PlainMessage()))
+	for _, field := range e.Fields {
+		record.AddAttributes(attribute.String(field.Key, field.Value))
+	}
+	ctx := e.Context
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	s.logger.Emit(ctx, record)
+	return nil
+}
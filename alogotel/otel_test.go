@@ -0,0 +1,117 @@
+package alogotel
+
+import (
+	"bytes"
+	"context"
+	"testing"
+
+	alog "github.com/duppercloud/ansi-log"
+	"github.com/stretchr/testify/assert"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/log"
+	"go.opentelemetry.io/otel/log/embedded"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// recordingProvider is an in-memory LoggerProvider that keeps every record
+// emitted through it.
+type recordingProvider struct {
+	embedded.LoggerProvider
+	name    string
+	records []log.Record
+	ctxs    []context.Context
+}
+
+func (p *recordingProvider) Logger(name string, options ...log.LoggerOption) log.Logger {
+	p.name = name
+	return &recordingLogger{provider: p}
+}
+
+type recordingLogger struct {
+	embedded.Logger
+	provider *recordingProvider
+}
+
+func (l *recordingLogger) Emit(ctx context.Context, record log.Record) {
+	l.provider.records = append(l.provider.records, record.Clone())
+	l.provider.ctxs = append(l.provider.ctxs, ctx)
+}
+
+func (l *recordingLogger) Enabled(ctx context.Context, param log.EnabledParameters) bool {
+	return true
+}
+
+var spanContext = trace.NewSpanContext(trace.SpanContextConfig{
+	TraceID:    trace.TraceID{0x01, 0x02, 0x03, 0x04, 0x05, 0x06, 0x07, 0x08, 0x09, 0x0a, 0x0b, 0x0c, 0x0d, 0x0e, 0x0f, 0x10},
+	SpanID:     trace.SpanID{0x01, 0x02, 0x03, 0x04, 0x05, 0x06, 0x07, 0x08},
+	TraceFlags: trace.FlagsSampled,
+})
+
+func TestAnnotate(t *testing.T) {
+	assert := assert.New(t)
+	assert.Nil(Annotate(context.Background()))
+	ctx := trace.ContextWithSpanContext(context.Background(), spanContext)
+	assert.Equal([]alog.Field{
+		{Key: "trace_id", Value: "0102030405060708090a0b0c0d0e0f10"},
+		{Key: "span_id", Value: "0102030405060708"},
+	}, Annotate(ctx))
+}
+
+func TestInstall(t *testing.T) {
+	assert := assert.New(t)
+	var buf bytes.Buffer
+	var provider recordingProvider
+	writer := alog.New(&buf, "", 0)
+	defer writer.Close()
+	writer.AddSink(NewSinkWithProvider(&provider, "test"))
+	ctx := trace.ContextWithSpanContext(context.Background(), spanContext)
+
+	uninstall := Install()
+	writer.PrintContext(ctx, "annotated\n")
+	uninstall()
+	writer.PrintContext(ctx, "plain\n")
+	if assert.Len(provider.records, 2) {
+		assert.Equal(2, provider.records[0].AttributesLen())
+		assert.Equal(0, provider.records[1].AttributesLen(), "uninstalled annotators aren't consulted")
+	}
+}
+
+func TestSinkWriteEntry(t *testing.T) {
+	assert := assert.New(t)
+	var buf bytes.Buffer
+	var provider recordingProvider
+	writer := alog.New(&buf, "", 0)
+	defer writer.Close()
+	writer.AddSink(NewSinkWithProvider(&provider, "worker"))
+	assert.Equal("worker", provider.name)
+
+	ctx := trace.ContextWithSpanContext(context.Background(), spanContext)
+	remove := alog.AddContextAnnotator(func(ctx context.Context) []alog.Field {
+		return []alog.Field{{Key: "request_id", Value: "42"}}
+	})
+	defer remove()
+	writer.ErrorfContext(ctx, "failed: %s\n", "boom")
+	writer.Println("done")
+
+	if !assert.Len(provider.records, 2) {
+		return
+	}
+	record := provider.records[0]
+	assert.Equal("failed: boom", record.Body().AsString())
+	assert.Equal(log.SeverityError, record.Severity())
+	assert.Equal(alog.LevelError.String(), record.SeverityText())
+	assert.False(record.Timestamp().IsZero())
+	var attributes []attribute.KeyValue
+	record.WalkAttributes(func(kv attribute.KeyValue) bool {
+		attributes = append(attributes, kv)
+		return true
+	})
+	assert.Equal([]attribute.KeyValue{attribute.String("request_id", "42")}, attributes)
+	assert.Equal(spanContext, trace.SpanContextFromContext(provider.ctxs[0]))
+
+	record = provider.records[1]
+	assert.Equal("done", record.Body().AsString())
+	assert.Equal(log.SeverityInfo, record.Severity())
+	assert.Equal(0, record.AttributesLen())
+	assert.NotNil(provider.ctxs[1], "a background context is used without one")
+}
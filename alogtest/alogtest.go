@@ -0,0 +1,127 @@
+// Package alogtest provides helpers for testing output rendered by alog,
+// including the partial-line redraw engine, against golden files.
+package alogtest
+
+import (
+	"bytes"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"sync"
+	"testing"
+)
+
+var update = flag.Bool("alogtest.update", false, "rewrite alogtest golden files with the actual output")
+
+// A Recorder is an io.Writer that records every write it receives as a
+// separate frame. alog hands each burst of rendering (one completed line, one
+// redraw of the partial lines, ...) to its writer in a single Write call, so
+// each frame is one step of what a terminal would have displayed.
+type Recorder struct {
+	mutex  sync.Mutex
+	frames [][]byte
+}
+
+// NewRecorder returns an empty Recorder.
+func NewRecorder() *Recorder {
+	return &Recorder{}
+}
+
+func (r *Recorder) Write(p []byte) (int, error) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	r.frames = append(r.frames, append([]byte{}, p...))
+	return len(p), nil
+}
+
+// Frames returns the frames recorded so far.
+func (r *Recorder) Frames() [][]byte {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	return append([][]byte{}, r.frames...)
+}
+
+// Reset discards all recorded frames.
+func (r *Recorder) Reset() {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	r.frames = nil
+}
+
+var normalizers = []struct {
+	pattern     *regexp.Regexp
+	replacement string
+}{
+	{regexp.MustCompile(`\d{4}[-/]\d\d[-/]\d\d([T ]\d\d:\d\d:\d\d(\.\d+)?)?`), "<date>"},
+	{regexp.MustCompile(`\b\d\d:\d\d:\d\d(\.\d+)?`), "<time>"},
+	// The fixed-width output of alog.FormatDuration.
+	{regexp.MustCompile(`[ \d.]{3,4}(ms|s|m|h)\b`), "<dur>"},
+}
+
+// Normalize replaces dates, times and durations in frame with placeholders
+// and makes control characters visible, so that frames can be compared
+// across runs and read in a golden file.
+func Normalize(frame []byte) string {
+	s := string(frame)
+	for _, n := range normalizers {
+		s = n.pattern.ReplaceAllString(s, n.replacement)
+	}
+	var buf strings.Builder
+	for _, r := range s {
+		switch {
+		case r == '\033':
+			buf.WriteString(`\e`)
+		case r == '\r':
+			buf.WriteString(`\r`)
+		case r == '\n':
+			buf.WriteString("\\n\n")
+		case r < ' ' || r == 0x7f:
+			fmt.Fprintf(&buf, `\x%02x`, r)
+		default:
+			buf.WriteRune(r)
+		}
+	}
+	return buf.String()
+}
+
+func render(frames [][]byte) []byte {
+	var buf bytes.Buffer
+	for i, frame := range frames {
+		fmt.Fprintf(&buf, "--- frame %d ---\n", i)
+		buf.WriteString(Normalize(frame))
+		if buf.Len() > 0 && buf.Bytes()[buf.Len()-1] != '\n' {
+			buf.WriteByte('\n')
+		}
+	}
+	return buf.Bytes()
+}
+
+// AssertFrames compares the normalized frames recorded by recorder with the
+// contents of goldenPath, failing t if they differ. Run the tests with
+// -alogtest.update to (re)write the golden files instead.
+func AssertFrames(t testing.TB, recorder *Recorder, goldenPath string) bool {
+	t.Helper()
+	actual := render(recorder.Frames())
+	if *update {
+		if err := os.MkdirAll(filepath.Dir(goldenPath), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(goldenPath, actual, 0644); err != nil {
+			t.Fatal(err)
+		}
+		return true
+	}
+	expected, err := os.ReadFile(goldenPath)
+	if err != nil {
+		t.Errorf("reading golden file (run with -alogtest.update to create it): %v", err)
+		return false
+	}
+	if !bytes.Equal(expected, actual) {
+		t.Errorf("rendered frames differ from %s (run with -alogtest.update to accept them)\n--- expected:\n%s--- actual:\n%s", goldenPath, expected, actual)
+		return false
+	}
+	return true
+}
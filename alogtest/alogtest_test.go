@@ -0,0 +1,21 @@
+package alogtest
+
+import (
+	"testing"
+
+	alog "github.com/duppercloud/ansi-log"
+)
+
+func TestAssertFrames(t *testing.T) {
+	recorder := NewRecorder()
+	writer1 := alog.New(recorder, "{isodate} ", 0)
+	writer2 := alog.New(recorder, "", alog.Lelapsed)
+	writer1.SetTerminalWidth(80)
+	writer1.Print("building...")
+	writer2.Print("testing...")
+	writer1.Print(" done\n")
+	writer2.Print(" ok\n")
+	writer1.Close()
+	writer2.Close()
+	AssertFrames(t, recorder, "testdata/frames.golden")
+}
@@ -0,0 +1,59 @@
+// Package alogurfave wires alog's command-line switches into a urfave/cli
+// app:
+//
+//	app := &cli.App{
+//		Flags:  append(myFlags, alogurfave.Flags()...),
+//		Before: alogurfave.Before,
+//		After:  alogurfave.After,
+//	}
+//
+// gives the app --log-level, --no-color, --log-file, --log-json and -v
+// (which may be repeated), configuring alog.DefaultLogger as alog's own
+// RegisterFlags and RegisterVerbosityFlags would.
+package alogurfave
+
+import (
+	"flag"
+	"fmt"
+
+	alog "github.com/duppercloud/ansi-log"
+	"github.com/urfave/cli/v2"
+)
+
+// Flags returns the cli flags understood by Before.
+func Flags() []cli.Flag {
+	return []cli.Flag{
+		&cli.StringFlag{Name: "log-level", Usage: "only log at this level (info, warn or error) and above"},
+		&cli.BoolFlag{Name: "no-color", Usage: "don't colorize the log"},
+		&cli.StringFlag{Name: "log-file", Usage: "append the log to this file"},
+		&cli.BoolFlag{Name: "log-json", Usage: "log as JSON, one object per line"},
+		&cli.BoolFlag{Name: "verbose", Aliases: []string{"v"}, Usage: "log more verbosely; repeat for more"},
+	}
+}
+
+// Before applies the flags returned by Flags to alog.DefaultLogger. Use it as
+// the app's Before function, or call it from one.
+func Before(c *cli.Context) error {
+	fs := flag.NewFlagSet(c.App.Name, flag.ContinueOnError)
+	alog.RegisterFlags(fs)
+	for _, name := range []string{"log-level", "no-color", "log-file", "log-json"} {
+		if !c.IsSet(name) {
+			continue
+		}
+		if err := fs.Set(name, fmt.Sprint(c.Value(name))); err != nil {
+			return fmt.Errorf("--%s: %w", name, err)
+		}
+	}
+	if n := c.Count("verbose"); n > 0 {
+		alog.SetVerbosity(alog.Verbosity() + n)
+	}
+	c.App.ErrWriter = alog.Stderr.RawWriter()
+	return nil
+}
+
+// After completes alog.DefaultLogger's partial line. Use it as the app's
+// After function, or call it from one.
+func After(c *cli.Context) error {
+	alog.DefaultLogger.Flush()
+	return nil
+}
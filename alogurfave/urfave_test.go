@@ -0,0 +1,38 @@
+package alogurfave
+
+import (
+	"bytes"
+	"testing"
+
+	alog "github.com/duppercloud/ansi-log"
+	"github.com/stretchr/testify/assert"
+	"github.com/urfave/cli/v2"
+)
+
+func TestBefore(t *testing.T) {
+	assert := assert.New(t)
+	var buf bytes.Buffer
+	defer alog.SetOutput(alog.DefaultLogger.Writer())
+	defer alog.SetVerbosity(alog.Verbosity())
+	defer alog.SetMinLevel(alog.LevelInfo)
+	alog.SetOutput(&buf)
+	verbosity := alog.Verbosity()
+	ran := false
+	app := &cli.App{
+		Name:   "tool",
+		Flags:  Flags(),
+		Before: Before,
+		After:  After,
+		Action: func(c *cli.Context) error {
+			ran = true
+			alog.Printf("info\n")
+			alog.Warnf("warning\n")
+			return nil
+		},
+	}
+	assert.NoError(app.Run([]string{"tool", "--log-level=warn", "-v"}))
+	assert.True(ran)
+	assert.Equal(verbosity+1, alog.Verbosity())
+	assert.NotContains(buf.String(), "info")
+	assert.Contains(buf.String(), "warning")
+}
@@ -0,0 +1,28 @@
+package alog
+
+import "fmt"
+
+// An AssertionError is what Assert panics with when an assertion fails.
+type AssertionError struct {
+	Message string
+}
+
+func (e *AssertionError) Error() string { return "assertion failed: " + e.Message }
+
+// Assert bails, as with Bail, if cond is false: it prints the stack and the
+// message formatted from format and v in the error style, and panics with an
+// *AssertionError. Building with the alog_noassert tag turns Assert into a
+// no-op, though its arguments are still evaluated.
+func (l *Logger) Assert(cond bool, format string, v ...interface{}) {
+	if assertionsEnabled && !cond {
+		err := &AssertionError{fmt.Sprintf(format, v...)}
+		l.bail(err, l.styleForLevel(LevelError, []byte("Assertion failed: "+err.Message+"\n")))
+	}
+}
+
+func Assert(cond bool, format string, v ...interface{}) {
+	if assertionsEnabled && !cond {
+		err := &AssertionError{fmt.Sprintf(format, v...)}
+		DefaultLogger.bail(err, DefaultLogger.styleForLevel(LevelError, []byte("Assertion failed: "+err.Message+"\n")))
+	}
+}
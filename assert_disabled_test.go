@@ -0,0 +1,19 @@
+//go:build alog_noassert
+
+package alog
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAssertCompiledOut(t *testing.T) {
+	assert := assert.New(t)
+	var buf bytes.Buffer
+	var writer = New(&buf, "", 0)
+	defer writer.Close()
+	assert.NotPanics(func() { writer.Assert(false, "x is %d", 3) })
+	assert.Equal("", buf.String())
+}
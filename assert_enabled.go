@@ -0,0 +1,5 @@
+//go:build !alog_noassert
+
+package alog
+
+const assertionsEnabled = true
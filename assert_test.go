@@ -0,0 +1,36 @@
+package alog
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAssert(t *testing.T) {
+	if !assertionsEnabled {
+		t.Skip("assertions are compiled out by the alog_noassert tag")
+	}
+	assert := assert.New(t)
+	var buf bytes.Buffer
+	var writer = New(&buf, "", 0)
+	defer writer.Close()
+	writer.DisableColor()
+	writer.Assert(true, "not printed")
+	assert.Equal("", buf.String())
+	func() {
+		defer func() {
+			err, ok := recover().(*AssertionError)
+			if assert.True(ok) {
+				assert.Equal("assertion failed: x is 3", err.Error())
+			}
+		}()
+		writer.Assert(false, "x is %d", 3)
+	}()
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	assert.True(strings.HasPrefix(lines[0], "goroutine "))
+	assert.Contains(lines[1], "TestAssert")
+	assert.Equal("Assertion failed: x is 3", lines[len(lines)-1])
+	assert.NotContains(buf.String(), "(*Logger).Assert")
+}
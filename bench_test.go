@@ -0,0 +1,90 @@
+package alog
+
+import (
+	"bytes"
+	"io"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMsgFastPath(t *testing.T) {
+	assert := assert.New(t)
+	var buf bytes.Buffer
+	var writer = New(&buf, "$$ ", Ldate|Ltime)
+	defer writer.Close()
+	writer.Msg("hello")
+	assert.Regexp(`^\$\$ \d{4}/\d\d/\d\d \d\d:\d\d:\d\d hello\n$`, buf.String())
+	buf.Reset()
+	writer.Print("partial ")
+	writer.Msg("line")
+	assert.Equal("partial line\n", buf.String()[len("$$ 2009/01/23 01:23:23 "):], "Msg completes a pending partial line")
+}
+
+func TestMsgHonorsSettings(t *testing.T) {
+	assert := assert.New(t)
+	var buf bytes.Buffer
+	var writer = New(&buf, "", 0)
+	defer writer.Close()
+	writer.SetJSONOutput(true)
+	writer.Msg("hello")
+	assert.Regexp(`^\{"time":"[^"]+","level":"info","message":"hello"\}\n$`, buf.String())
+	buf.Reset()
+	writer.SetJSONOutput(false)
+	writer.SetHistorySize(10)
+	writer.Msg("remembered")
+	assert.Equal("remembered\n", buf.String())
+	if entries := writer.History().Entries(); assert.Len(entries, 1) {
+		assert.Equal("remembered", entries[0].Message)
+	}
+}
+
+func TestMsgZeroAllocs(t *testing.T) {
+	writer := New(io.Discard, "$$ ", Lisodate|Lmicroseconds)
+	writer.Msg("warm up")
+	allocs := testing.AllocsPerRun(100, func() {
+		writer.Msg("a literal message")
+	})
+	assert.Equal(t, 0.0, allocs, "the Msg fast path should not allocate")
+}
+
+func BenchmarkMsg(b *testing.B) {
+	writer := New(io.Discard, "$$ ", LstdFlags)
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		writer.Msg("a literal message")
+	}
+}
+
+func BenchmarkPrint(b *testing.B) {
+	writer := New(io.Discard, "$$ ", LstdFlags)
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		writer.Print("a literal message\n")
+	}
+}
+
+func BenchmarkPrintf(b *testing.B) {
+	writer := New(io.Discard, "$$ ", LstdFlags)
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		writer.Printf("a %s message %d\n", "formatted", i)
+	}
+}
+
+func BenchmarkPrintfColorTemplate(b *testing.B) {
+	writer := New(io.Discard, "@(dim:{isodate}) ", 0)
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		writer.Printf("@(green:%s) message %d\n", "templated", i)
+	}
+}
+
+func BenchmarkPartialLines(b *testing.B) {
+	writer := New(io.Discard, "$$ ", 0)
+	defer writer.Close()
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		writer.Replacef("progress: %d", i)
+	}
+}
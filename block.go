@@ -0,0 +1,40 @@
+package alog
+
+import "bytes"
+
+// SetBlockMode sets whether multi-line messages are written as a block: the
+// first line with the usual prefix, and each further line written by the
+// same call behind a dim "│ " gutter ("| " without Unicode) indented to line up with the message,
+// rather than with a prefix of its own. This reads better for stack traces
+// and diffs.
+func (l *Logger) SetBlockMode(flag bool) {
+	ws := getWriterState(l.out)
+	ws.lock()
+	defer ws.unlock()
+	l.customized = true
+	l.blockMode = flag
+}
+
+func (l *Logger) EnableBlockMode()  { l.SetBlockMode(true) }
+func (l *Logger) DisableBlockMode() { l.SetBlockMode(false) }
+
+func SetBlockMode(flag bool) { DefaultLogger.SetBlockMode(flag) }
+func EnableBlockMode()       { DefaultLogger.SetBlockMode(true) }
+func DisableBlockMode()      { DefaultLogger.SetBlockMode(false) }
+
+// getBlockLine formats a line following the first of a multi-line message in
+// block mode. Must be called with the writer lock held.
+func (l *Logger) getBlockLine(line []byte) []byte {
+	l.tmp = l.tmp[:0]
+	l.formatHeader(&l.tmp, line)
+	indent := stringLen(l.tmp)
+	l.tmp = append(l.tmp[:0], bytes.Repeat(bytesSpace, indent)...)
+	l.tmp = append(l.tmp, styleEscapes("dim")...)
+	l.tmp = append(l.tmp, l.glyphs().gutter...)
+	l.tmp = append(l.tmp, ansiBytesResetAll...)
+	l.tmp = append(l.tmp, line...)
+	if !l.isColorEnabled() {
+		l.tmp = uncolorize(l.tmp)
+	}
+	return l.tmp
+}
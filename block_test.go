@@ -0,0 +1,33 @@
+package alog
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBlockMode(t *testing.T) {
+	assert := assert.New(t)
+	var buf bytes.Buffer
+	var writer = New(&buf, "P: ", 0)
+	defer writer.Close()
+	writer.EnableUnicode()
+	writer.DisableColor()
+	writer.EnableBlockMode()
+	writer.Print("panic: oops\n  main.go:12\n  main.go:34\n")
+	writer.Print("next\n")
+	assert.Equal("P: panic: oops\n   │   main.go:12\n   │   main.go:34\nP: next\n", buf.String())
+}
+
+func TestBlockModeASCII(t *testing.T) {
+	assert := assert.New(t)
+	var buf bytes.Buffer
+	var writer = New(&buf, "P: ", 0)
+	defer writer.Close()
+	writer.DisableUnicode()
+	writer.DisableColor()
+	writer.EnableBlockMode()
+	writer.Print("panic: oops\n  main.go:12\n")
+	assert.Equal("P: panic: oops\n   |   main.go:12\n", buf.String())
+}
@@ -0,0 +1,62 @@
+package alog
+
+import (
+	"fmt"
+	"path"
+	"runtime/debug"
+)
+
+// PrintBuildInfo prints what the binary knows about its own build: the main
+// module's name and version, the VCS revision and time (flagging a dirty
+// working tree) and the Go version, as a small styled block suitable for a
+// startup banner. It prints nothing if the binary carries no build info.
+func (l *Logger) PrintBuildInfo() {
+	if !l.IsEnabled() {
+		return
+	}
+	info, ok := debug.ReadBuildInfo()
+	if !ok {
+		return
+	}
+	ws := getWriterState(l.out)
+	ws.lock()
+	defer ws.unlock()
+	for _, line := range formatBuildInfo(info) {
+		l.intOutput(2, []byte(line+"\n"), true)
+	}
+}
+
+func PrintBuildInfo() { DefaultLogger.PrintBuildInfo() }
+
+func formatBuildInfo(info *debug.BuildInfo) []string {
+	name := info.Main.Path
+	if info.Path != "" {
+		name = path.Base(info.Path)
+	}
+	header := styled("bright", name)
+	if version := info.Main.Version; version != "" {
+		header += " " + version
+	}
+	lines := []string{header}
+	field := func(label, value string) {
+		lines = append(lines, fmt.Sprintf("  %s %s", styled("dim", fmt.Sprintf("%-9s", label)), value))
+	}
+	settings := map[string]string{}
+	for _, setting := range info.Settings {
+		settings[setting.Key] = setting.Value
+	}
+	if revision := settings["vcs.revision"]; revision != "" {
+		if len(revision) > 12 {
+			revision = revision[:12]
+		}
+		if settings["vcs.modified"] == "true" {
+			revision += " " + styled("warn", "(dirty)")
+		}
+		field("revision", revision)
+	}
+	if built := settings["vcs.time"]; built != "" {
+		field("committed", built)
+	}
+	field("go", info.GoVersion)
+	return lines
+}
@@ -0,0 +1,32 @@
+package alog
+
+import (
+	"runtime/debug"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFormatBuildInfo(t *testing.T) {
+	assert := assert.New(t)
+	info := &debug.BuildInfo{
+		GoVersion: "go1.22.1",
+		Path:      "example.com/tool/cmd/tool",
+		Main:      debug.Module{Path: "example.com/tool", Version: "v1.2.3"},
+		Settings: []debug.BuildSetting{
+			{Key: "vcs.revision", Value: "0123456789abcdef0123"},
+			{Key: "vcs.time", Value: "2024-05-06T07:08:09Z"},
+			{Key: "vcs.modified", Value: "true"},
+		},
+	}
+	var lines []string
+	for _, line := range formatBuildInfo(info) {
+		lines = append(lines, string(uncolorize([]byte(line))))
+	}
+	assert.Equal([]string{
+		"tool v1.2.3",
+		"  revision  0123456789ab (dirty)",
+		"  committed 2024-05-06T07:08:09Z",
+		"  go        go1.22.1",
+	}, lines)
+}
@@ -0,0 +1,56 @@
+package alog
+
+import "fmt"
+
+// FormatBytes formats a number of bytes in 5 characters, like FormatDuration
+// does durations, using binary (IEC) multiples: 1K is 1024 bytes, 1M is 1024K
+// and so on, as with ls -h. E.g. " 512B", "1.50K", "15.3M", " 153G". Negative
+// numbers may take 6 characters, e.g. "-1.50K".
+func FormatBytes(n int64) string {
+	return formatBytes(n, 1024, "BKMGTPE")
+}
+
+// FormatBytesSI is like FormatBytes, but uses decimal (SI) multiples: 1k is
+// 1000 bytes, 1M is 1000k and so on.
+func FormatBytesSI(n int64) string {
+	return formatBytes(n, 1000, "BkMGTPE")
+}
+
+func formatBytes(n int64, base float64, units string) string {
+	sign := ""
+	magnitude := uint64(n)
+	if n < 0 {
+		sign = "-"
+		// Negating in uint64 also works for math.MinInt64.
+		magnitude = -magnitude
+	}
+	if float64(magnitude) < base {
+		return fmt.Sprintf("%4s", fmt.Sprintf("%s%d", sign, magnitude)) + "B"
+	}
+	value := float64(magnitude)
+	unit := 0
+	// Round to the precision shown before deciding on a unit, so that e.g.
+	// 1023.9K becomes 1.00M rather than 1024K.
+	for unit < len(units)-1 && value >= base-0.5 {
+		value /= base
+		unit++
+	}
+	var number string
+	switch {
+	case value >= 99.95:
+		number = fmt.Sprintf("%.0f", value)
+	case value >= 9.995:
+		number = fmt.Sprintf("%.1f", value)
+	default:
+		number = fmt.Sprintf("%.2f", value)
+	}
+	return fmt.Sprintf("%4s", sign+number) + units[unit:unit+1]
+}
+
+// ByteCount is a number of bytes that formats itself with FormatBytes, e.g.
+// for use with Printf.
+type ByteCount int64
+
+func (n ByteCount) String() string {
+	return FormatBytes(int64(n))
+}
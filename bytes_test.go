@@ -0,0 +1,28 @@
+package alog
+
+import (
+	"fmt"
+	"math"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFormatBytes(t *testing.T) {
+	assert := assert.New(t)
+	assert.Equal("   0B", FormatBytes(0))
+	assert.Equal(" 512B", FormatBytes(512))
+	assert.Equal("1023B", FormatBytes(1023))
+	assert.Equal("1.00K", FormatBytes(1024))
+	assert.Equal("1.50K", FormatBytes(1536))
+	assert.Equal("15.3M", FormatBytes(16000000))
+	assert.Equal(" 153G", FormatBytes(153<<30))
+	assert.Equal("1.00M", FormatBytes(1024*1024-1))
+	assert.Equal("1.50k", FormatBytesSI(1500))
+	assert.Equal("1.00M", FormatBytesSI(1000*1000))
+	assert.Equal(" -12B", FormatBytes(-12))
+	assert.Equal("-1.50K", FormatBytes(-1536))
+	assert.Equal("-8.00E", FormatBytes(math.MinInt64))
+	assert.Equal("8.00E", FormatBytes(math.MaxInt64))
+	assert.Equal("size 1.00K", fmt.Sprintf("size %v", ByteCount(1024)))
+}
@@ -0,0 +1,83 @@
+package alog
+
+import (
+	"path"
+	"runtime"
+	"runtime/debug"
+	"strings"
+	"sync"
+)
+
+// SetTrimCallerPaths sets whether the file names printed by Llongfile are
+// shortened from absolute paths on the build machine to the package's import
+// path followed by the file name, e.g. "github.com/me/app/cmd/main.go", as
+// they are when building with -trimpath (in which case there is nothing left
+// to do). Prefixes set with SetCallerPathPrefixes take precedence.
+func (l *Logger) SetTrimCallerPaths(flag bool) {
+	ws := getWriterState(l.out)
+	ws.lock()
+	defer ws.unlock()
+	l.customized = true
+	l.trimCallerPaths = flag
+}
+
+func (l *Logger) EnableTrimCallerPaths()  { l.SetTrimCallerPaths(true) }
+func (l *Logger) DisableTrimCallerPaths() { l.SetTrimCallerPaths(false) }
+
+// SetCallerPathPrefixes sets prefixes, such as the checkout directory, to trim
+// from the file names printed by Llongfile. The first prefix that matches is
+// trimmed. No prefixes, the default, leaves file names alone.
+func (l *Logger) SetCallerPathPrefixes(prefixes ...string) {
+	ws := getWriterState(l.out)
+	ws.lock()
+	defer ws.unlock()
+	l.customized = true
+	l.callerPathPrefixes = prefixes
+}
+
+func SetTrimCallerPaths(flag bool)             { DefaultLogger.SetTrimCallerPaths(flag) }
+func EnableTrimCallerPaths()                   { DefaultLogger.SetTrimCallerPaths(true) }
+func DisableTrimCallerPaths()                  { DefaultLogger.SetTrimCallerPaths(false) }
+func SetCallerPathPrefixes(prefixes ...string) { DefaultLogger.SetCallerPathPrefixes(prefixes...) }
+
+// trimCallerPath shortens file, the file of the function at pc, according to
+// the Logger's settings. Must be called with the writer lock held.
+func (l *Logger) trimCallerPath(pc uintptr, file string) string {
+	for _, prefix := range l.callerPathPrefixes {
+		if prefix != "" && strings.HasPrefix(file, prefix) {
+			return strings.TrimPrefix(file[len(prefix):], "/")
+		}
+	}
+	if !l.trimCallerPaths || !strings.HasPrefix(file, "/") && !(len(file) > 1 && file[1] == ':') {
+		// Relative paths are already trimmed.
+		return file
+	}
+	fn := runtime.FuncForPC(pc)
+	if fn == nil {
+		return file
+	}
+	pkg, _ := splitFuncName(fn.Name())
+	pkg = strings.Replace(pkg, "%2e", ".", -1)
+	if pkg == "main" {
+		pkg = mainPackagePath()
+	}
+	if pkg == "" {
+		return file
+	}
+	return pkg + "/" + path.Base(file)
+}
+
+var mainPackage struct {
+	once sync.Once
+	path string
+}
+
+// mainPackagePath returns the import path of the main package, if known.
+func mainPackagePath() string {
+	mainPackage.once.Do(func() {
+		if info, ok := debug.ReadBuildInfo(); ok {
+			mainPackage.path = info.Path
+		}
+	})
+	return mainPackage.path
+}
@@ -0,0 +1,26 @@
+package alog
+
+import (
+	"bytes"
+	"path"
+	"runtime"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTrimCallerPaths(t *testing.T) {
+	assert := assert.New(t)
+	var buf bytes.Buffer
+	var writer = New(&buf, "", Llongfile)
+	defer writer.Close()
+	writer.EnableTrimCallerPaths()
+	writer.Println("hello")
+	assert.Regexp(`^`+packagePath+`/callerpath_test.go:\d+: hello\n$`, buf.String())
+
+	buf.Reset()
+	_, file, _, _ := runtime.Caller(0)
+	writer.SetCallerPathPrefixes("/nonexistent/", path.Dir(file))
+	writer.Println("hello")
+	assert.Regexp(`^callerpath_test.go:\d+: hello\n$`, buf.String())
+}
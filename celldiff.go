@@ -0,0 +1,115 @@
+package alog
+
+import (
+	"bytes"
+	"strconv"
+)
+
+// A cellDiff describes what has to be written to turn a line on screen into
+// another: new[start:end], starting at column col. The rest of the line is
+// already on screen.
+type cellDiff struct {
+	start, end int
+	col        int
+}
+
+// diffCells compares a line on screen with the one replacing it, skipping
+// their common prefix and, if both are the same width, their common suffix.
+// Lines are compared a character or escape at a time, so the parts skipped
+// always cover whole cells.
+func diffCells(old, new []byte) cellDiff {
+	oldTokens, newTokens := splitTokens(old), splitTokens(new)
+	d := cellDiff{end: len(new)}
+	n := 0
+	for n < len(oldTokens) && n < len(newTokens) && bytes.Equal(oldTokens[n], newTokens[n]) {
+		d.start += len(newTokens[n])
+		n++
+	}
+	d.col = stringLen(new[:d.start])
+	if stringLen(old) != stringLen(new) {
+		return d
+	}
+	oldEnd := len(old)
+	for i, j := len(oldTokens)-1, len(newTokens)-1; i >= n && j >= n && bytes.Equal(oldTokens[i], newTokens[j]); i, j = i-1, j-1 {
+		oldEnd -= len(oldTokens[i])
+		d.end -= len(newTokens[j])
+	}
+	// The suffix on screen is only right if it was drawn in the colors the
+	// new line would draw it in.
+	if *getActiveAnsiCodes(old[:oldEnd]) != *getActiveAnsiCodes(new[:d.end]) {
+		d.end = len(new)
+	}
+	return d
+}
+
+// cellsEnd returns the length of buf up to the end of its last character,
+// leaving out any escapes after it; 0 if it has no characters.
+func cellsEnd(buf []byte) int {
+	end := 0
+	for i := 0; i < len(buf); {
+		n, escape := nextToken(buf[i:])
+		i += n
+		if !escape {
+			end = i
+		}
+	}
+	return end
+}
+
+func splitTokens(buf []byte) [][]byte {
+	var tokens [][]byte
+	for len(buf) > 0 {
+		n, _ := nextToken(buf)
+		tokens = append(tokens, buf[:n])
+		buf = buf[n:]
+	}
+	return tokens
+}
+
+// writeCellDiff redraws line, which was last drawn as lastBuf, by writing only
+// the cells that changed, if that's shorter than rewriting the line. It
+// reports whether it did. Must be called with the writer lock held.
+func (w *WriterState) writeCellDiff(line int, lastBuf, buf []byte) bool {
+	if !cursorMovementSupported {
+		return false
+	}
+	d := diffCells(lastBuf, buf)
+	var move []byte
+	if d.col > 0 {
+		move = []byte("\033[" + strconv.Itoa(d.col) + "C")
+	}
+	if len(move) >= d.start && d.end == len(buf) {
+		return false
+	}
+	w.write(getActiveAnsiCodes(lastBuf).getResetBytes())
+	if !moveCursorToLine(w.out, line) && !w.cursorIsAtBegin {
+		w.write(bytesCarriageReturn)
+	}
+	w.write(move)
+	changed := buf[d.start:d.end]
+	if d.end < len(buf) {
+		// Escapes after the last changed cell would only color cells that
+		// are already on screen.
+		changed = changed[:cellsEnd(changed)]
+	}
+	// Colors are only restored, and reset again, for cells that are drawn.
+	if cellsEnd(changed) > 0 {
+		w.write(getActiveAnsiCodes(buf[:d.start]).getRestoreBytes())
+		w.write(changed)
+		if d.end < len(buf) {
+			w.write(getActiveAnsiCodes(buf[:d.start+len(changed)]).getResetBytes())
+		}
+	}
+	if d.end < len(buf) {
+		// The cursor is left mid-line, with the colors reset.
+		w.cursorIsInline = false
+		return true
+	}
+	currStringLen := stringLen(buf)
+	lastStringLen := stringLen(lastBuf)
+	for i := currStringLen; i < lastStringLen; i++ {
+		w.write(bytesSpace)
+	}
+	w.cursorIsInline = currStringLen >= lastStringLen
+	return true
+}
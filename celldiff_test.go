@@ -0,0 +1,51 @@
+package alog
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDiffCells(t *testing.T) {
+	assert := assert.New(t)
+	old := []byte("copying 41% of files")
+	d := diffCells(old, []byte("copying 42% of files"))
+	assert.Equal(cellDiff{start: 9, end: 10, col: 9}, d)
+	d = diffCells(old, []byte("copying 100% of files"))
+	assert.Equal(cellDiff{start: 8, end: 21, col: 8}, d, "no suffix when the width changes")
+	old = []byte("\033[31m1\033[39m/5 ☃ done")
+	d = diffCells(old, []byte("\033[31m2\033[39m/5 ☃ done"))
+	assert.Equal(cellDiff{start: 5, end: 6, col: 0}, d)
+	d = diffCells(old, []byte("\033[32m1\033[39m/5 ☃ done"))
+	assert.Equal(cellDiff{start: 0, end: 22, col: 0}, d, "recolored text is redrawn")
+	d = diffCells([]byte("\033[31mab"), []byte("\033[32mab"))
+	assert.Equal(cellDiff{start: 0, end: 7, col: 0}, d, "the suffix would be in the wrong color")
+}
+
+func TestCellsEnd(t *testing.T) {
+	assert := assert.New(t)
+	assert.Equal(0, cellsEnd(nil))
+	assert.Equal(0, cellsEnd([]byte("\033[31m\033[39m")))
+	assert.Equal(7, cellsEnd([]byte("\033[31mab\033[39m\033[32m")))
+	assert.Equal(3, cellsEnd([]byte("☃\033[0m")))
+}
+
+func TestCellDiffRedraw(t *testing.T) {
+	assert := assert.New(t)
+	var buf bytes.Buffer
+	var writer = New(&buf, "", 0)
+	writer.SetTerminalWidth(80)
+	writer.ShowPartialLines()
+	defer writer.Close()
+	writer.Print("downloading: 10% of 3 files")
+	buf.Reset()
+	writer.Replace("downloading: 20% of 3 files")
+	assert.Equal("\r\033[13C2", buf.String())
+	buf.Reset()
+	writer.Replace("done")
+	assert.Equal("\rdone                       ", buf.String())
+	buf.Reset()
+	writer.Replace("done: 3 files\n")
+	assert.Equal("\rdone: 3 files\n", buf.String(), "completed lines are written in full")
+}
@@ -0,0 +1,139 @@
+package alog
+
+import (
+	"fmt"
+	"os"
+	"regexp"
+	"strings"
+	"sync/atomic"
+)
+
+// A CIProvider identifies a continuous integration service whose log viewer
+// alog can adapt its output to.
+type CIProvider int
+
+const (
+	// NoCI means output is for a terminal (or a plain file).
+	NoCI CIProvider = iota
+	GitHubActions
+	GitLabCI
+	Buildkite
+)
+
+// DetectCI returns the CI service the process is running under, based on
+// the environment variables each one sets, or NoCI.
+func DetectCI() CIProvider {
+	switch {
+	case os.Getenv("GITHUB_ACTIONS") == "true":
+		return GitHubActions
+	case os.Getenv("GITLAB_CI") == "true":
+		return GitLabCI
+	case os.Getenv("BUILDKITE") == "true":
+		return Buildkite
+	}
+	return NoCI
+}
+
+// SetCIMode adapts output to the given CI service's log viewer: partial
+// lines, multiline mode and the status bar are disabled, as these viewers
+// can't move the cursor, while colors are kept, as they render them. On
+// GitHub Actions, warnings and errors become annotations. Use Group to mark
+// collapsible sections. NoCI turns CI mode off again. This applies to all
+// Loggers sharing the writer. A typical call is SetCIMode(DetectCI()).
+func (l *Logger) SetCIMode(provider CIProvider) {
+	ws := getWriterState(l.out)
+	ws.lock()
+	defer ws.unlock()
+	if provider != NoCI {
+		ws.flushAll()
+		ws.disableStatusBar()
+		ws.clearTempLines()
+		ws.multiline = false
+		ws.lastTemp.reset()
+		ws.cursorLineIndex = 0
+		ws.cursorIsAtBegin = true
+		ws.cursorIsInline = false
+	}
+	ws.ci = provider
+}
+
+func SetCIMode(provider CIProvider) { DefaultLogger.SetCIMode(provider) }
+
+// writeCILine writes a completed line in CI mode. Must be called with the
+// writer lock held.
+func (l *Logger) writeCILine(buf []byte) {
+	if annotation := l.ciAnnotation(buf); annotation != nil {
+		writeLine(l.out, annotation)
+		return
+	}
+	l.writeCompletedLine(buf)
+	if marker := l.ciMarker(); marker != nil {
+		writeLine(l.out, marker)
+	}
+}
+
+// ciAnnotation returns the annotation that replaces a completed line at the
+// current level in CI mode, or nil if the line is written as it is.
+func (l *Logger) ciAnnotation(buf []byte) []byte {
+	if getWriterState(l.out).ci != GitHubActions || l.lineLevel < LevelWarn {
+		return nil
+	}
+	command := "warning"
+	if l.lineLevel >= LevelError {
+		command = "error"
+	}
+	return []byte("::" + command + "::" + escapeGitHubData(string(uncolorize(buf))))
+}
+
+// ciMarker returns the line to write after a completed line at the current
+// level in CI mode, or nil.
+func (l *Logger) ciMarker() []byte {
+	if getWriterState(l.out).ci != Buildkite || l.lineLevel < LevelError {
+		return nil
+	}
+	// Expand the enclosing section, so that the error is visible.
+	return []byte("^^^ +++")
+}
+
+func escapeGitHubData(s string) string {
+	s = strings.ReplaceAll(s, "%", "%25")
+	s = strings.ReplaceAll(s, "\r", "%0D")
+	return strings.ReplaceAll(s, "\n", "%0A")
+}
+
+var sectionCounter int64
+var sectionNameRegexp = regexp.MustCompile("[^a-z0-9_]+")
+
+// Group starts a collapsible section titled name in the CI service's log
+// viewer, and returns a function that ends it. Sections don't nest on all
+// services, so end one before starting the next. Outside of CI mode, Group
+// writes nothing.
+func (l *Logger) Group(name string) (end func()) {
+	ws := getWriterState(l.out)
+	ws.lock()
+	defer ws.unlock()
+	l.flushInt()
+	switch ws.ci {
+	case GitHubActions:
+		writeLine(l.out, []byte("::group::"+escapeGitHubData(name)))
+		return func() { l.endGroup("::endgroup::") }
+	case GitLabCI:
+		id := fmt.Sprintf("%s_%d", sectionNameRegexp.ReplaceAllString(strings.ToLower(name), "_"), atomic.AddInt64(&sectionCounter, 1))
+		writeLine(l.out, []byte(fmt.Sprintf("\033[0Ksection_start:%d:%s[collapsed=true]\r\033[0K%s", l.clockNow().Unix(), id, name)))
+		return func() { l.endGroup(fmt.Sprintf("\033[0Ksection_end:%d:%s\r\033[0K", l.currentTime().Unix(), id)) }
+	case Buildkite:
+		// Sections end where the next one starts.
+		writeLine(l.out, []byte("--- "+name))
+	}
+	return func() {}
+}
+
+func Group(name string) (end func()) { return DefaultLogger.Group(name) }
+
+func (l *Logger) endGroup(marker string) {
+	ws := getWriterState(l.out)
+	ws.lock()
+	defer ws.unlock()
+	l.flushInt()
+	writeLine(l.out, []byte(marker))
+}
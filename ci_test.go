@@ -0,0 +1,68 @@
+package alog
+
+import (
+	"bytes"
+	"regexp"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDetectCI(t *testing.T) {
+	assert := assert.New(t)
+	t.Setenv("GITHUB_ACTIONS", "")
+	t.Setenv("GITLAB_CI", "")
+	t.Setenv("BUILDKITE", "")
+	assert.Equal(NoCI, DetectCI())
+	t.Setenv("GITLAB_CI", "true")
+	assert.Equal(GitLabCI, DetectCI())
+}
+
+func TestCIModeGitHubActions(t *testing.T) {
+	assert := assert.New(t)
+	var buf bytes.Buffer
+	var writer = New(&buf, "", 0)
+	defer writer.Close()
+	writer.ShowPartialLines()
+	writer.SetCIMode(GitHubActions)
+	end := writer.Group("build")
+	writer.Print("compiling")
+	assert.Equal("::group::build\n", buf.String())
+	writer.Print(" 100%\n")
+	writer.Errorf("failed: 50%%\n")
+	end()
+	assert.Equal("::group::build\ncompiling 100%\n::error::failed: 50%25\n::endgroup::\n", buf.String())
+}
+
+func TestCIModeGitLab(t *testing.T) {
+	assert := assert.New(t)
+	var buf bytes.Buffer
+	var writer = New(&buf, "", 0)
+	defer writer.Close()
+	writer.SetCIMode(GitLabCI)
+	end := writer.Group("Run Tests")
+	writer.Print("ok\n")
+	end()
+	assert.Regexp(regexp.MustCompile("^\033\\[0Ksection_start:\\d+:run_tests_\\d+\\[collapsed=true\\]\r\033\\[0KRun Tests\nok\n\033\\[0Ksection_end:\\d+:run_tests_\\d+\r\033\\[0K\n$"), buf.String())
+}
+
+func TestCIModeRoutedLines(t *testing.T) {
+	assert := assert.New(t)
+	var stdout, stderr bytes.Buffer
+	var writer = New(&stdout, "", 0)
+	defer writer.Close()
+	writer.DisableColor()
+	writer.SetCIMode(GitHubActions)
+	writer.Route(LevelError, &stderr)
+	writer.Println("info")
+	writer.Errorf("failed: 50%%\n")
+	assert.Equal("info\n", stdout.String())
+	assert.Equal("::error::failed: 50%25\n", stderr.String())
+
+	stdout.Reset()
+	stderr.Reset()
+	writer.SetCIMode(Buildkite)
+	writer.Errorf("failed\n")
+	assert.Equal("", stdout.String())
+	assert.Equal("failed\n^^^ +++\n", stderr.String())
+}
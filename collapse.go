@@ -0,0 +1,101 @@
+package alog
+
+import "time"
+
+type finishedLine struct {
+	l      *Logger
+	buf    []byte
+	demote bool
+	timer  *time.Timer
+}
+
+// SetCollapseFinished makes lines that complete while displayed as partial
+// lines stay where they are, among the partial lines, for the given duration
+// rather than being written out for good. After that they are removed, or, if
+// demote is true, replaced by a dim one-line version in the scrollback. This
+// keeps the output of long sessions with many tasks compact. Lines that were
+// never displayed as partial lines are written as usual, and lines still
+// waiting to collapse are written out when the Logger is closed or the
+// program exits through Fatal and the like. A duration of zero turns this off.
+func (l *Logger) SetCollapseFinished(after time.Duration, demote bool) {
+	ws := getWriterState(l.out)
+	ws.lock()
+	defer ws.unlock()
+	l.customized = true
+	l.collapseAfter = after
+	l.collapseDemote = demote
+}
+
+func SetCollapseFinished(after time.Duration, demote bool) {
+	DefaultLogger.SetCollapseFinished(after, demote)
+}
+
+// addFinishedLine shows buf, a line completed by l, along with the partial
+// lines until l's collapse duration has elapsed. Must be called with the
+// writer lock held.
+func (w *WriterState) addFinishedLine(l *Logger, buf []byte) {
+	after := l.collapseAfter
+	finished := &finishedLine{l: l, buf: append([]byte{}, buf...), demote: l.collapseDemote}
+	w.finished = append(w.finished, finished)
+	finished.timer = time.AfterFunc(after, func() {
+		w.lock()
+		defer w.unlock()
+		w.collapseFinishedLine(finished)
+	})
+}
+
+// removeFinishedLine takes finished off the list, reporting whether it was
+// still there.
+func (w *WriterState) removeFinishedLine(finished *finishedLine) bool {
+	for i, f := range w.finished {
+		if f == finished {
+			w.finished = append(w.finished[:i], w.finished[i+1:]...)
+			return true
+		}
+	}
+	return false
+}
+
+func (w *WriterState) collapseFinishedLine(finished *finishedLine) {
+	if !w.removeFinishedLine(finished) {
+		// Already written out by flushFinishedLines.
+		return
+	}
+	if finished.demote {
+		w.writeDemotedLine(finished)
+	}
+	updateTempOutput(w.out)
+}
+
+func (w *WriterState) writeDemotedLine(finished *finishedLine) {
+	l := finished.l
+	line := trimStringEllipsis(uncolorize(finished.buf), getTermWidth(w.out)-1)
+	if l.isColorEnabled() {
+		line = append(append(styleEscapes("dim"), line...), ansiBytesResetAll...)
+	}
+	l.writeCompletedLine(line)
+}
+
+// flushFinishedLines writes out the finished lines of l, or of every Logger
+// if l is nil, without waiting for them to collapse: demoted if they would
+// have been, and in full otherwise, so that nothing is lost when closing or
+// exiting. Must be called with the writer lock held.
+func (w *WriterState) flushFinishedLines(l *Logger) {
+	flushed := false
+	for _, finished := range append([]*finishedLine(nil), w.finished...) {
+		if l != nil && finished.l != l {
+			continue
+		}
+		finished.timer.Stop()
+		w.removeFinishedLine(finished)
+		if finished.demote {
+			w.writeDemotedLine(finished)
+		} else {
+			finished.l.writeCompletedLine(finished.buf)
+		}
+		flushed = true
+	}
+	if flushed {
+		updateTempOutput(w.out)
+	}
+}
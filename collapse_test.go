@@ -0,0 +1,89 @@
+package alog
+
+import (
+	"bytes"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSetCollapseFinished(t *testing.T) {
+	assert := assert.New(t)
+	var buf bytes.Buffer
+	writer1 := New(&buf, "", 0)
+	writer2 := New(&buf, "", 0)
+	writer1.SetTerminalWidth(80)
+	writer1.ShowPartialLines()
+	writer1.SetCollapseFinished(time.Hour, false)
+	writer1.Print("task1...")
+	writer2.Print("task2...")
+	buf.Reset()
+	writer1.Print(" done\n")
+	assert.Equal("\r\033[9Cdone | task2...", buf.String(), "the finished line stays with the partial lines")
+
+	ws := getWriterState(&buf)
+	ws.lock()
+	finished := ws.finished[0]
+	ws.unlock()
+	buf.Reset()
+	ws.lock()
+	ws.collapseFinishedLine(finished)
+	ws.unlock()
+	assert.Equal("\rtask2...                ", buf.String())
+	writer1.Close()
+	writer2.Close()
+}
+
+func TestCollapseFinishedDemote(t *testing.T) {
+	assert := assert.New(t)
+	var buf bytes.Buffer
+	writer1 := New(&buf, "", 0)
+	writer2 := New(&buf, "", 0)
+	writer1.SetTerminalWidth(80)
+	writer1.ShowPartialLines()
+	writer1.DisableColor()
+	writer1.SetCollapseFinished(time.Hour, true)
+	writer1.Print("task1...")
+	writer2.Print("task2...")
+	writer1.Print(" done\n")
+
+	ws := getWriterState(&buf)
+	ws.lock()
+	finished := ws.finished[0]
+	ws.unlock()
+	buf.Reset()
+	ws.lock()
+	ws.collapseFinishedLine(finished)
+	ws.unlock()
+	assert.Equal("\rtask1... done           \ntask2...", buf.String(), "no styling without color")
+	writer1.Close()
+	writer2.Close()
+}
+
+func TestCollapseFinishedClose(t *testing.T) {
+	assert := assert.New(t)
+	var buf bytes.Buffer
+	writer1 := New(&buf, "", 0)
+	writer2 := New(&buf, "", 0)
+	writer1.SetTerminalWidth(80)
+	writer1.ShowPartialLines()
+	writer1.SetCollapseFinished(time.Hour, false)
+	writer1.Print("task1...")
+	writer2.Print("task2...")
+	writer1.Print(" done\n")
+
+	ws := getWriterState(&buf)
+	ws.lock()
+	finished := ws.finished[0]
+	ws.unlock()
+	buf.Reset()
+	writer1.Close()
+	assert.Equal("\rtask1... done           \ntask2...", buf.String(), "closing writes the finished line out")
+	buf.Reset()
+	ws.lock()
+	ws.collapseFinishedLine(finished)
+	ws.unlock()
+	assert.Equal("", buf.String(), "nothing is left to collapse")
+	writer2.Close()
+}
@@ -0,0 +1,141 @@
+package alog
+
+import (
+	"bytes"
+	"context"
+	"os/exec"
+	"strings"
+	"sync"
+	"time"
+)
+
+// maxFailureOutputLines limits how much of a failed command's output
+// RunCommand repeats.
+const maxFailureOutputLines = 20
+
+// spinnerFrame returns the frame of a spinner to show after elapsed.
+func (l *Logger) spinnerFrame(elapsed time.Duration) string {
+	frames := l.glyphs().spinner
+	return frames[int(elapsed/(100*time.Millisecond))%len(frames)]
+}
+
+// animate makes sure the Logger's partial lines are redrawn often enough for
+// a spinner, returning a function that undoes that. Partial lines keep being
+// redrawn as long as anyone still animates them.
+func (l *Logger) animate() (stop func()) {
+	const interval = 100 * time.Millisecond
+	ws := getWriterState(l.out)
+	ws.lock()
+	ws.addRefreshUser(interval)
+	ws.unlock()
+	var once sync.Once
+	return func() {
+		once.Do(func() {
+			ws.lock()
+			ws.removeRefreshUser(interval)
+			ws.unlock()
+		})
+	}
+}
+
+// A CommandResult describes a command run by RunCommand.
+type CommandResult struct {
+	Name     string
+	Args     []string
+	ExitCode int // -1 if the command didn't exit normally
+	Duration time.Duration
+	Output   []byte // stdout and stderr, interleaved
+}
+
+// commandOutput captures a command's output, keeping track of the last
+// line written for display.
+type commandOutput struct {
+	mutex       sync.Mutex
+	buf         bytes.Buffer
+	lastLine    string
+	atLineStart bool // whether the output so far ends with a line break
+	ws          *WriterState
+}
+
+func (o *commandOutput) Write(p []byte) (int, error) {
+	o.mutex.Lock()
+	o.buf.Write(p)
+	// Only p needs looking at: the last line is either in it, or continued
+	// or left unchanged by it.
+	if text := bytes.TrimRight(p, "\r\n"); len(text) > 0 {
+		if i := bytes.LastIndexAny(text, "\r\n"); i >= 0 {
+			o.lastLine = string(text[i+1:])
+		} else if o.atLineStart {
+			o.lastLine = string(text)
+		} else {
+			o.lastLine += string(text)
+		}
+	}
+	if len(p) > 0 {
+		o.atLineStart = p[len(p)-1] == '\n' || p[len(p)-1] == '\r'
+	}
+	o.mutex.Unlock()
+	o.ws.refresh()
+	return len(p), nil
+}
+
+func (o *commandOutput) getLastLine() string {
+	o.mutex.Lock()
+	defer o.mutex.Unlock()
+	return o.lastLine
+}
+
+// RunCommand runs the named command to completion, showing a partial line
+// with a spinner, the time elapsed and the latest line of output while it
+// runs. The output isn't logged, except for the end of it if the command
+// fails; it is returned in the result, along with the exit code. The error
+// is that of exec.Cmd's Run.
+func (l *Logger) RunCommand(ctx context.Context, name string, args ...string) (*CommandResult, error) {
+	result := &CommandResult{Name: name, Args: args, ExitCode: -1}
+	ws := getWriterState(l.out)
+	output := &commandOutput{ws: ws}
+	task := New(l.out, l.Prefix(), l.Flags())
+	task.colorEnabled.copyFrom(&l.colorEnabled)
+	task.clock.set(l.clock.get())
+	task.SetTempRenderer(func(state LineState, width int) []byte {
+		elapsed := state.Now.Sub(state.Start)
+		return []byte(l.spinnerFrame(elapsed) + " " + name + " " + strings.TrimSpace(FormatDuration(elapsed)) + " " + string(styleEscapes("dim")) + output.getLastLine())
+	})
+	stopAnimating := l.animate()
+	task.Print(name + ":")
+
+	start := l.currentTime()
+	cmd := exec.CommandContext(ctx, name, args...)
+	cmd.Stdout = output
+	cmd.Stderr = output
+	err := cmd.Run()
+	result.Duration = l.currentTime().Sub(start)
+	result.Output = output.buf.Bytes()
+	if cmd.ProcessState != nil {
+		result.ExitCode = cmd.ProcessState.ExitCode()
+	}
+	stopAnimating()
+
+	task.SetTempRenderer(nil)
+	duration := strings.TrimSpace(FormatDuration(result.Duration))
+	if err == nil {
+		task.Printf(" done (%s)\n", duration)
+	} else {
+		task.Errorf(" failed: %v (%s)\n", err, duration)
+		if len(result.Output) > 0 {
+			lines := strings.Split(strings.TrimRight(string(result.Output), "\n"), "\n")
+			if len(lines) > maxFailureOutputLines {
+				lines = lines[len(lines)-maxFailureOutputLines:]
+			}
+			for _, line := range lines {
+				task.Println("  " + line)
+			}
+		}
+	}
+	task.Close()
+	return result, err
+}
+
+func RunCommand(ctx context.Context, name string, args ...string) (*CommandResult, error) {
+	return DefaultLogger.RunCommand(ctx, name, args...)
+}
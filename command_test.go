@@ -0,0 +1,47 @@
+package alog
+
+import (
+	"bytes"
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRunCommand(t *testing.T) {
+	assert := assert.New(t)
+	var buf bytes.Buffer
+	var writer = New(&buf, "", 0)
+	defer writer.Close()
+	writer.EnableUnicode()
+	writer.DisableColor()
+	result, err := writer.RunCommand(context.Background(), "sh", "-c", "echo one; echo two >&2")
+	assert.NoError(err)
+	assert.Equal(0, result.ExitCode)
+	assert.Equal("one\ntwo\n", string(result.Output))
+	assert.Contains(buf.String(), "⠋ sh ")
+	assert.Regexp("\rsh: done \\(.*\\)\n$", buf.String())
+
+	buf.Reset()
+	result, err = writer.RunCommand(context.Background(), "sh", "-c", "echo oops; exit 3")
+	assert.Error(err)
+	assert.Equal(3, result.ExitCode)
+	assert.Regexp("\rsh: failed: exit status 3 \\(.*\\)\n  oops\n$", buf.String())
+}
+
+func TestCommandOutputLastLine(t *testing.T) {
+	assert := assert.New(t)
+	var buf bytes.Buffer
+	output := &commandOutput{ws: getWriterState(&buf)}
+	for _, step := range []struct{ write, lastLine string }{
+		{"compil", "compil"},
+		{"ing\n", "compiling"},
+		{"\n", "compiling"},
+		{"10%\r20", "20"},
+		{"%\r", "20%"},
+		{"done\r\n", "done"},
+	} {
+		output.Write([]byte(step.write))
+		assert.Equal(step.lastLine, output.getLastLine(), "after %q", step.write)
+	}
+}
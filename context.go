@@ -0,0 +1,145 @@
+package alog
+
+import (
+	"context"
+	"fmt"
+	"sync"
+)
+
+// A Field is a key/value annotation attached to an Entry.
+type Field struct {
+	Key   string
+	Value string
+}
+
+// A ContextAnnotator extracts fields from a context.Context, e.g. the IDs of
+// the trace span it carries. See PrintContext.
+type ContextAnnotator func(ctx context.Context) []Field
+
+var contextAnnotators struct {
+	sync.RWMutex
+	list []*ContextAnnotator
+}
+
+// AddContextAnnotator registers an annotator that is consulted for every line
+// printed through one of the *Context functions. Calling the returned function
+// removes it again.
+func AddContextAnnotator(annotator ContextAnnotator) (remove func()) {
+	contextAnnotators.Lock()
+	defer contextAnnotators.Unlock()
+	entry := &annotator
+	contextAnnotators.list = append(contextAnnotators.list, entry)
+	return func() {
+		contextAnnotators.Lock()
+		defer contextAnnotators.Unlock()
+		for i, other := range contextAnnotators.list {
+			if other == entry {
+				contextAnnotators.list = append(contextAnnotators.list[:i:i], contextAnnotators.list[i+1:]...)
+				return
+			}
+		}
+	}
+}
+
+func annotateContext(ctx context.Context) []Field {
+	if ctx == nil {
+		return nil
+	}
+	contextAnnotators.RLock()
+	defer contextAnnotators.RUnlock()
+	var fields []Field
+	for _, annotator := range contextAnnotators.list {
+		fields = append(fields, (*annotator)(ctx)...)
+	}
+	return fields
+}
+
+// contextOutput writes s on behalf of ctx. Must be called with the writer lock
+// held; calldepth is relative to the caller of contextOutput.
+func (l *Logger) contextOutput(ctx context.Context, calldepth int, s []byte) {
+	prevCtx, prevFields := l.callCtx, l.callFields
+	l.callCtx, l.callFields = ctx, annotateContext(ctx)
+	l.intOutput(calldepth+1, s, true)
+	l.callCtx, l.callFields = prevCtx, prevFields
+}
+
+// PrintContext is like Print, but lines it completes carry ctx and the fields
+// extracted from it by the registered ContextAnnotators, for the benefit of
+// EntrySinks (e.g. to correlate them with traces).
+func (l *Logger) PrintContext(ctx context.Context, v ...interface{}) {
+	if !l.IsEnabled() {
+		return
+	}
+	s := []byte(fmt.Sprint(v...))
+	ws := getWriterState(l.out)
+	ws.lock()
+	defer ws.unlock()
+	l.contextOutput(ctx, 2, s)
+}
+
+// PrintfContext is like Printf, but see PrintContext.
+func (l *Logger) PrintfContext(ctx context.Context, format string, v ...interface{}) {
+	if !l.IsEnabled() {
+		return
+	}
+	buf := l.formatArgs(format, v)
+	defer buf.release()
+	ws := getWriterState(l.out)
+	ws.lock()
+	defer ws.unlock()
+	l.contextOutput(ctx, 2, buf.b)
+}
+
+// ErrorfContext is like Errorf, but see PrintContext.
+func (l *Logger) ErrorfContext(ctx context.Context, format string, v ...interface{}) {
+	if !l.IsEnabled() {
+		return
+	}
+	buf := l.formatArgs(format, v)
+	defer buf.release()
+	ws := getWriterState(l.out)
+	ws.lock()
+	defer ws.unlock()
+	prevLevel := l.callLevel
+	l.callLevel = LevelError
+	l.contextOutput(ctx, 2, l.styleForLevel(LevelError, buf.b))
+	l.callLevel = prevLevel
+}
+
+func PrintContext(ctx context.Context, v ...interface{}) {
+	if !DefaultLogger.IsEnabled() {
+		return
+	}
+	s := []byte(fmt.Sprint(v...))
+	ws := getWriterState(DefaultLogger.out)
+	ws.lock()
+	defer ws.unlock()
+	DefaultLogger.contextOutput(ctx, 2, s)
+}
+
+func PrintfContext(ctx context.Context, format string, v ...interface{}) {
+	if !DefaultLogger.IsEnabled() {
+		return
+	}
+	buf := DefaultLogger.formatArgs(format, v)
+	defer buf.release()
+	ws := getWriterState(DefaultLogger.out)
+	ws.lock()
+	defer ws.unlock()
+	DefaultLogger.contextOutput(ctx, 2, buf.b)
+}
+
+func ErrorfContext(ctx context.Context, format string, v ...interface{}) {
+	if !DefaultLogger.IsEnabled() {
+		return
+	}
+	buf := DefaultLogger.formatArgs(format, v)
+	defer buf.release()
+	ws := getWriterState(DefaultLogger.out)
+	ws.lock()
+	defer ws.unlock()
+	prevLevel := DefaultLogger.callLevel
+	DefaultLogger.callLevel = LevelError
+	DefaultLogger.contextOutput(ctx, 2, DefaultLogger.styleForLevel(LevelError, buf.b))
+	DefaultLogger.callLevel = prevLevel
+}
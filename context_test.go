@@ -0,0 +1,50 @@
+package alog
+
+import (
+	"bytes"
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type requestIDKey struct{}
+
+type entryRecorder struct {
+	entries []Entry
+}
+
+func (r *entryRecorder) WriteEntry(e *Entry) error {
+	r.entries = append(r.entries, *e)
+	return nil
+}
+
+func TestPrintContext(t *testing.T) {
+	assert := assert.New(t)
+	remove := AddContextAnnotator(func(ctx context.Context) []Field {
+		if id, ok := ctx.Value(requestIDKey{}).(string); ok {
+			return []Field{{Key: "request_id", Value: id}}
+		}
+		return nil
+	})
+	defer remove()
+	var buf bytes.Buffer
+	var recorder entryRecorder
+	writer := New(&buf, "", 0)
+	defer writer.Close()
+	writer.AddSink(&recorder)
+	ctx := context.WithValue(context.Background(), requestIDKey{}, "abc123")
+	writer.PrintfContext(ctx, "handling %s\n", "/index")
+	writer.Print("unrelated\n")
+	assert.Equal("handling /index\nunrelated\n", buf.String(), "fields don't affect rendering")
+	assert.Len(recorder.entries, 2)
+	assert.Equal([]Field{{Key: "request_id", Value: "abc123"}}, recorder.entries[0].Fields)
+	assert.Equal(ctx, recorder.entries[0].Context)
+	assert.Nil(recorder.entries[1].Fields)
+	assert.Nil(recorder.entries[1].Context)
+	remove()
+	writer.PrintfContext(ctx, "handling %s\n", "/about")
+	if assert.Len(recorder.entries, 3) {
+		assert.Nil(recorder.entries[2].Fields, "removed annotators aren't consulted")
+	}
+}
@@ -0,0 +1,111 @@
+package alog
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// A dedupEntry tracks how many times a line was suppressed during the current
+// dedup window for that line.
+type dedupEntry struct {
+	count int
+	start time.Time
+	level Level // level the line was first printed at
+	timer *time.Timer
+}
+
+// SetDedupWindow enables time-window deduplication of completed lines. The
+// first occurrence of a line is printed immediately; identical lines printed
+// within the following window are counted instead of printed, and a summary
+// line is emitted when the window closes. This is distinct from collapsing
+// consecutive duplicates: other lines may be interleaved freely. A window of
+// zero disables deduplication (pending summaries are emitted immediately).
+func (l *Logger) SetDedupWindow(window time.Duration) {
+	ws := getWriterState(l.out)
+	ws.lock()
+	defer ws.unlock()
+	l.customized = true
+	if window <= 0 {
+		l.flushDedupInt()
+	}
+	l.dedupWindow = window
+}
+
+func SetDedupWindow(window time.Duration) { DefaultLogger.SetDedupWindow(window) }
+
+// isDuplicateLine reports whether line should be suppressed because it was
+// already printed within the current dedup window. Must be called with the
+// writer lock held.
+func (l *Logger) isDuplicateLine(line []byte) bool {
+	if l.dedupWindow <= 0 {
+		return false
+	}
+	key := string(line)
+	if entry, ok := l.dedupEntries[key]; ok {
+		entry.count++
+		return true
+	}
+	if l.dedupEntries == nil {
+		l.dedupEntries = make(map[string]*dedupEntry)
+		ws := getWriterState(l.out)
+		ws.dedupLoggers = append(ws.dedupLoggers, l)
+	}
+	entry := &dedupEntry{start: l.now, level: l.lineLevel}
+	entry.timer = time.AfterFunc(l.dedupWindow, func() { l.closeDedupWindow(key) })
+	l.dedupEntries[key] = entry
+	return false
+}
+
+func (l *Logger) closeDedupWindow(key string) {
+	ws := getWriterState(l.out)
+	ws.lock()
+	defer ws.unlock()
+	entry, ok := l.dedupEntries[key]
+	if !ok {
+		return
+	}
+	delete(l.dedupEntries, key)
+	l.writeDedupSummary(key, entry)
+}
+
+// flushDedupInt closes all open dedup windows, emitting their summaries.
+func (l *Logger) flushDedupInt() {
+	for key, entry := range l.dedupEntries {
+		entry.timer.Stop()
+		l.writeDedupSummary(key, entry)
+	}
+	l.dedupEntries = nil
+	ws := getWriterState(l.out)
+	for i, logger := range ws.dedupLoggers {
+		if logger == l {
+			ws.dedupLoggers = append(ws.dedupLoggers[:i], ws.dedupLoggers[i+1:]...)
+			break
+		}
+	}
+}
+
+// writeDedupSummary writes the summary of a closed dedup window as a
+// completed line at the level the line was first printed at, leaving any
+// partial line in progress alone.
+func (l *Logger) writeDedupSummary(key string, entry *dedupEntry) {
+	if entry.count == 0 || l.isClosed {
+		return
+	}
+	l.updateNow()
+	times := "times"
+	if entry.count == 1 {
+		times = "time"
+	}
+	summary := fmt.Sprintf("(repeated %d more %s in %s)", entry.count, times, strings.TrimSpace(FormatDuration(l.now.Sub(entry.start))))
+	prevLevel, prevCtx, prevFields := l.lineLevel, l.lineCtx, l.lineFields
+	l.lineLevel, l.lineCtx, l.lineFields = entry.level, nil, nil
+	line := l.highlight(l.detectSeverity([]byte(key)))
+	line = append(append(line, ' '), styled("dim", summary)...)
+	if !l.isColorEnabled() {
+		line = uncolorize(line)
+	}
+	l.writeFormattedLine(line, l.getFormattedLine(line), false)
+	l.lineLevel, l.lineCtx, l.lineFields = prevLevel, prevCtx, prevFields
+	updateTempOutput(l.out)
+}
@@ -0,0 +1,96 @@
+package alog
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDedupWindow(t *testing.T) {
+	assert := assert.New(t)
+	var buf bytes.Buffer
+	var writer = New(&buf, "", 0)
+	writer.HidePartialLines()
+	writer.DisableColor()
+	writer.SetDedupWindow(time.Hour)
+	writer.Print("reconnecting...\n")
+	writer.Print("connected\n")
+	writer.Print("reconnecting...\n")
+	writer.Print("reconnecting...\n")
+	assert.Equal("reconnecting...\nconnected\n", buf.String(), "duplicates within the window are suppressed")
+	buf.Reset()
+	writer.Close()
+	assert.True(strings.HasPrefix(buf.String(), "reconnecting... (repeated 2 more times in "), buf.String())
+	assert.False(strings.Contains(buf.String(), "connected ("), "lines seen once get no summary")
+}
+
+func TestDedupWindowExpires(t *testing.T) {
+	assert := assert.New(t)
+	var buf bytes.Buffer
+	var writer = New(&buf, "", 0)
+	defer writer.Close()
+	writer.HidePartialLines()
+	writer.DisableColor()
+	writer.SetDedupWindow(10 * time.Millisecond)
+	writer.Print("retrying\n")
+	writer.Print("retrying\n")
+	time.Sleep(50 * time.Millisecond)
+	writer.Flush()
+	assert.Contains(buf.String(), "retrying (repeated 1 more time in ")
+	buf.Reset()
+	writer.Print("retrying\n")
+	assert.Equal("retrying\n", buf.String(), "a new window starts after the previous one closes")
+}
+
+func TestDedupSummaryWithoutColorTemplates(t *testing.T) {
+	assert := assert.New(t)
+	var buf bytes.Buffer
+	var writer = New(&buf, "", 0)
+	writer.HidePartialLines()
+	writer.EnableColor()
+	writer.DisableColorTemplate()
+	writer.SetDedupWindow(time.Hour)
+	writer.Print("polling\n")
+	writer.Print("polling\n")
+	buf.Reset()
+	writer.Close()
+	assert.NotContains(buf.String(), "@(")
+	assert.Contains(buf.String(), string(styleEscapes("dim"))+"(repeated 1 more time in ")
+}
+
+func TestDedupSummaryRouted(t *testing.T) {
+	assert := assert.New(t)
+	var buf, errBuf bytes.Buffer
+	var writer = New(&buf, "", 0)
+	writer.HidePartialLines()
+	writer.DisableColor()
+	writer.Route(LevelWarn, &errBuf)
+	writer.SetDedupWindow(time.Hour)
+	writer.Warnf("disk almost full\n")
+	writer.Warnf("disk almost full\n")
+	writer.Print("partial")
+	writer.Close()
+	assert.Equal("partial\n", buf.String())
+	assert.Contains(errBuf.String(), "disk almost full\ndisk almost full (repeated 1 more time in ", "the summary goes where the line went")
+	assert.Equal(uint64(2), writer.Stats().Warns)
+}
+
+func TestDedupSummaryOnExit(t *testing.T) {
+	assert := assert.New(t)
+	var buf bytes.Buffer
+	var writer = New(&buf, "", 0)
+	defer writer.Close()
+	writer.HidePartialLines()
+	writer.DisableColor()
+	writer.SetDedupWindow(time.Hour)
+	writer.Print("retrying\n")
+	writer.Print("retrying\n")
+	ws := getWriterState(&buf)
+	ws.lock()
+	ws.closeAll()
+	ws.unlock()
+	assert.Contains(buf.String(), "retrying (repeated 1 more time in ", "osExit flushes pending summaries")
+}
@@ -0,0 +1,52 @@
+package alog
+
+import "io"
+
+// SetEnabled sets whether the Logger writes anything. A disabled Logger is a
+// cheap no-op: its output methods return straight away, without formatting
+// their arguments, so verbose diagnostic Loggers can be left in hot code
+// paths. Fatal and Panic still exit and panic. Disabling a Logger completes
+// its partial line, if any. Loggers are enabled by default.
+func (l *Logger) SetEnabled(flag bool) {
+	if !flag && l.IsEnabled() {
+		ws := getWriterState(l.out)
+		ws.lock()
+		defer ws.unlock()
+		l.flushInt()
+	}
+	l.disabled.Store(!flag)
+}
+
+func (l *Logger) Enable()  { l.SetEnabled(true) }
+func (l *Logger) Disable() { l.SetEnabled(false) }
+
+// IsEnabled reports whether the Logger writes anything; see SetEnabled.
+func (l *Logger) IsEnabled() bool { return !l.disabled.Load() }
+
+func SetEnabled(flag bool) { DefaultLogger.SetEnabled(flag) }
+func Enable()              { DefaultLogger.Enable() }
+func Disable()             { DefaultLogger.Disable() }
+func IsEnabled() bool      { return DefaultLogger.IsEnabled() }
+
+// Discard is a disabled Logger, for passing where a Logger is required but
+// its output isn't wanted.
+var Discard = newDiscard()
+
+// If returns the Logger if cond is true, and a new disabled Logger otherwise,
+// for output that's only wanted sometimes, e.g. alog.If(verbose).Printf(...).
+// The disabled Logger isn't shared, so calling its setters affects nothing
+// else.
+func (l *Logger) If(cond bool) *Logger {
+	if cond {
+		return l
+	}
+	return newDiscard()
+}
+
+func If(cond bool) *Logger { return DefaultLogger.If(cond) }
+
+func newDiscard() *Logger {
+	l := New(io.Discard, "", 0)
+	l.disabled.Store(true)
+	return l
+}
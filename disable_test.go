@@ -0,0 +1,65 @@
+package alog
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type countingStringer struct{ n *int }
+
+func (c countingStringer) String() string {
+	*c.n++
+	return "counted"
+}
+
+func TestDisable(t *testing.T) {
+	assert := assert.New(t)
+	var buf bytes.Buffer
+	writer := New(&buf, "", 0)
+	defer writer.Close()
+	writer.Print("partial")
+	writer.Disable()
+	assert.Equal("partial\n", buf.String(), "disabling completes the partial line")
+	assert.False(writer.IsEnabled())
+	buf.Reset()
+	formatted := 0
+	writer.Printf("%v\n", countingStringer{&formatted})
+	writer.Println(countingStringer{&formatted})
+	writer.Errorf("%v\n", countingStringer{&formatted})
+	writer.Msg("message")
+	n, err := writer.Write([]byte("written\n"))
+	assert.Equal(8, n)
+	assert.NoError(err)
+	assert.Equal("", buf.String())
+	assert.Equal(0, formatted, "arguments aren't formatted")
+	writer.Enable()
+	writer.Printf("%v\n", countingStringer{&formatted})
+	assert.Equal("counted\n", buf.String())
+	assert.Equal(1, formatted)
+}
+
+func TestDiscard(t *testing.T) {
+	assert := assert.New(t)
+	formatted := 0
+	Discard.Printf("%v\n", countingStringer{&formatted})
+	Discard.Warn(countingStringer{&formatted})
+	assert.Equal(0, formatted)
+	assert.False(Discard.IsEnabled())
+}
+
+func TestIf(t *testing.T) {
+	assert := assert.New(t)
+	var buf bytes.Buffer
+	writer := New(&buf, "", 0)
+	defer writer.Close()
+	writer.If(false).Printf("hidden\n")
+	writer.If(true).Printf("shown\n")
+	assert.Equal("shown\n", buf.String())
+	hidden := writer.If(false)
+	hidden.Enable()
+	hidden.SetOutput(&buf)
+	writer.If(false).Printf("still hidden\n")
+	assert.Equal("shown\n", buf.String(), "each disabled Logger is separate")
+}
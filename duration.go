@@ -2,7 +2,11 @@ package alog
 
 import (
 	"fmt"
+	"math"
+	"strconv"
+	"strings"
 	"time"
+	"unicode/utf8"
 )
 
 type Timer time.Time
@@ -72,3 +76,150 @@ func FormatDuration(duration time.Duration) string {
 		}
 	}
 }
+
+// A DurationFormat describes how FormatDuration-style durations are laid out.
+// The zero value formats exactly like FormatDuration.
+type DurationFormat struct {
+	// Width is the number of characters for the number, 4 by default. Wider
+	// numbers show more decimals.
+	Width int
+	// Trim drops the padding that otherwise makes all results the same width.
+	Trim bool
+	// SubMillisecond shows durations under a millisecond in µs or ns rather
+	// than as fractions of a millisecond.
+	SubMillisecond bool
+	// Clock formats durations as h:mm:ss or m:ss, rounded to the second,
+	// instead of as a number and a unit.
+	Clock bool
+}
+
+// Format formats duration according to f.
+func (f DurationFormat) Format(duration time.Duration) string {
+	if f.Clock {
+		return f.formatClock(duration)
+	}
+	if f.Width == 0 && !f.SubMillisecond {
+		if f.Trim {
+			return strings.TrimSpace(FormatDuration(duration))
+		}
+		return FormatDuration(duration)
+	}
+	width := f.Width
+	if width == 0 {
+		width = 4
+	}
+	var value float64
+	var unit string
+	switch secs := duration.Seconds(); {
+	case secs >= 10*3600:
+		value, unit = duration.Hours(), "h"
+	case secs >= 600:
+		value, unit = duration.Minutes(), "m"
+	case secs >= 0.9995:
+		value, unit = secs, "s"
+	case !f.SubMillisecond || secs >= 0.0009995:
+		value, unit = 1000*secs, "ms"
+	case secs >= 0.0000009995:
+		value, unit = float64(duration)/float64(time.Microsecond), "µs"
+	default:
+		value, unit = float64(duration), "ns"
+	}
+	// Two-letter units take a character away from the number.
+	number := fitNumber(value, width+1-utf8.RuneCountInString(unit))
+	if f.Trim {
+		number = strings.TrimSpace(number)
+	}
+	return number + unit
+}
+
+func (f DurationFormat) formatClock(duration time.Duration) string {
+	secs := int64(duration.Round(time.Second) / time.Second)
+	sign := ""
+	if secs < 0 {
+		sign, secs = "-", -secs
+	}
+	var s string
+	if secs >= 3600 {
+		s = fmt.Sprintf("%s%d:%02d:%02d", sign, secs/3600, secs/60%60, secs%60)
+	} else {
+		s = fmt.Sprintf("%s%d:%02d", sign, secs/60, secs%60)
+	}
+	if !f.Trim && f.Width > len(s) {
+		s = strings.Repeat(" ", f.Width-len(s)) + s
+	}
+	return s
+}
+
+// fitNumber formats value in width characters, with as many decimals as fit.
+func fitNumber(value float64, width int) string {
+	intDigits := len(strconv.FormatFloat(math.Abs(value), 'f', 0, 64))
+	if value < 0 {
+		intDigits++
+	}
+	decimals := width - intDigits - 1
+	if decimals < 1 {
+		return fmt.Sprintf("%*.0f", width, value)
+	}
+	s := fmt.Sprintf("%*.*f", width, decimals, value)
+	if len(s) > width {
+		// Rounding added a digit.
+		s = strings.TrimSuffix(s[:width], ".")
+	}
+	return fmt.Sprintf("%*s", width, s)
+}
+
+// ParseDuration parses a duration as formatted by FormatDuration or a
+// DurationFormat, e.g. " 1.5s", "12.3m", "120µs" or "1:02:03". Other
+// durations accepted by time.ParseDuration are accepted too.
+func ParseDuration(s string) (time.Duration, error) {
+	s = strings.TrimSpace(s)
+	if strings.Contains(s, ":") {
+		return parseClockDuration(s)
+	}
+	for _, u := range durationUnits {
+		if number := strings.TrimSuffix(s, u.name); number != s && number != "" {
+			value, err := strconv.ParseFloat(number, 64)
+			if err != nil {
+				break
+			}
+			return time.Duration(value * float64(u.unit)), nil
+		}
+	}
+	return time.ParseDuration(s)
+}
+
+// durationUnits lists unit suffixes, longest first so that "ms" isn't taken
+// for "s".
+var durationUnits = []struct {
+	name string
+	unit time.Duration
+}{
+	{"ms", time.Millisecond},
+	{"µs", time.Microsecond},
+	{"us", time.Microsecond},
+	{"ns", time.Nanosecond},
+	{"h", time.Hour},
+	{"m", time.Minute},
+	{"s", time.Second},
+}
+
+func parseClockDuration(s string) (time.Duration, error) {
+	negative := strings.HasPrefix(s, "-")
+	parts := strings.Split(strings.TrimPrefix(s, "-"), ":")
+	if len(parts) > 3 {
+		return 0, fmt.Errorf("alog: invalid duration %q", s)
+	}
+	var duration time.Duration
+	for _, part := range parts {
+		n, err := strconv.ParseUint(part, 10, 32)
+		if err != nil {
+			return 0, fmt.Errorf("alog: invalid duration %q", s)
+		}
+		duration = duration*60 + time.Duration(n)
+	}
+	duration *= time.Second
+	if negative {
+		duration = -duration
+	}
+	return duration, nil
+}
@@ -0,0 +1,44 @@
+package alog
+
+import "sort"
+
+// wideEmoji are the ranges of characters that terminals draw as emoji, two
+// columns wide, even without a variation selector (Emoji_Presentation).
+var wideEmoji = [][2]rune{
+	{0x231a, 0x231b}, {0x23e9, 0x23ec}, {0x23f0, 0x23f0}, {0x23f3, 0x23f3},
+	{0x25fd, 0x25fe}, {0x2614, 0x2615}, {0x2648, 0x2653}, {0x267f, 0x267f},
+	{0x2693, 0x2693}, {0x26a1, 0x26a1}, {0x26aa, 0x26ab}, {0x26bd, 0x26be},
+	{0x26c4, 0x26c5}, {0x26ce, 0x26ce}, {0x26d4, 0x26d4}, {0x26ea, 0x26ea},
+	{0x26f2, 0x26f3}, {0x26f5, 0x26f5}, {0x26fa, 0x26fa}, {0x26fd, 0x26fd},
+	{0x2705, 0x2705}, {0x270a, 0x270b}, {0x2728, 0x2728}, {0x274c, 0x274c},
+	{0x274e, 0x274e}, {0x2753, 0x2755}, {0x2757, 0x2757}, {0x2795, 0x2797},
+	{0x27b0, 0x27b0}, {0x27bf, 0x27bf}, {0x2b1b, 0x2b1c}, {0x2b50, 0x2b50},
+	{0x2b55, 0x2b55}, {0x1f004, 0x1f004}, {0x1f0cf, 0x1f0cf}, {0x1f18e, 0x1f18e},
+	{0x1f191, 0x1f19a}, {0x1f1e6, 0x1f1ff}, {0x1f300, 0x1f64f}, {0x1f680, 0x1f6ff},
+	{0x1f7e0, 0x1f7eb}, {0x1f900, 0x1f9ff}, {0x1fa70, 0x1faff},
+}
+
+func isWideEmoji(r rune) bool {
+	i := sort.Search(len(wideEmoji), func(i int) bool { return wideEmoji[i][1] >= r })
+	return i < len(wideEmoji) && wideEmoji[i][0] <= r
+}
+
+// EmojiLevelIcons are level icons for use with SetLevelIcons.
+var EmojiLevelIcons = map[Level]string{
+	LevelWarn:  "⚠️",
+	LevelError: "❌",
+}
+
+// SetLevelIcons sets icons, such as EmojiLevelIcons, to start the messages
+// of the given levels with, e.g. to match a theme. An icon takes the place of
+// the monochrome symbols marker for its level. nil, the default, means no
+// icons.
+func (l *Logger) SetLevelIcons(icons map[Level]string) {
+	ws := getWriterState(l.out)
+	ws.lock()
+	defer ws.unlock()
+	l.customized = true
+	l.levelIcons = icons
+}
+
+func SetLevelIcons(icons map[Level]string) { DefaultLogger.SetLevelIcons(icons) }
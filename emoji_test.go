@@ -0,0 +1,34 @@
+package alog
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestEmojiWidth(t *testing.T) {
+	assert := assert.New(t)
+	assert.Equal(2, stringLen([]byte("🚀")))
+	assert.Equal(2, stringLen([]byte("⚠️")))
+	assert.Equal(2, stringLen([]byte("🇩🇪")))
+	assert.Equal(1, stringLen([]byte("✓")))
+	assert.Equal(6, stringLen([]byte("a🚀b👍🏽")))
+	assert.Equal("a🚀", string(trimString([]byte("a🚀b"), 3)))
+	assert.Equal("a", string(trimString([]byte("a🚀b"), 2)))
+	assert.Equal([][]byte{[]byte("a🚀"), []byte("bc")}, wrapString([]byte("a🚀bc"), 3))
+}
+
+func TestLevelIcons(t *testing.T) {
+	assert := assert.New(t)
+	var buf bytes.Buffer
+	var writer = New(&buf, "", 0)
+	defer writer.Close()
+	writer.DisableColor()
+	writer.EnableMonochromeSymbols()
+	writer.SetLevelIcons(EmojiLevelIcons)
+	writer.Warnf("careful\n")
+	writer.Errorf("broken\n")
+	writer.Printf("fine\n")
+	assert.Equal("⚠️ careful\n❌ broken\nfine\n", buf.String())
+}
@@ -0,0 +1,33 @@
+package alog
+
+// A LineFilter is applied to each completed line before it is output. It
+// returns the line to output in its place, which may be line itself, and
+// whether to output anything at all. The line passed in is only valid for the
+// duration of the call.
+type LineFilter func(line []byte) ([]byte, bool)
+
+// AddLineFilter appends filter to the chain of filters applied to this
+// Logger's completed lines, after any redactors. Each filter sees the output
+// of the previous one; once a filter drops a line, later filters aren't
+// called. Partial lines are displayed unfiltered.
+func (l *Logger) AddLineFilter(filter LineFilter) {
+	ws := getWriterState(l.out)
+	ws.lock()
+	defer ws.unlock()
+	l.customized = true
+	l.filters = append(l.filters, filter)
+}
+
+func AddLineFilter(filter LineFilter) { DefaultLogger.AddLineFilter(filter) }
+
+// filterLine runs line through the Logger's filters. Must be called with the
+// writer lock held.
+func (l *Logger) filterLine(line []byte) ([]byte, bool) {
+	for _, filter := range l.filters {
+		var keep bool
+		if line, keep = filter(line); !keep {
+			return nil, false
+		}
+	}
+	return line, true
+}
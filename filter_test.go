@@ -0,0 +1,30 @@
+package alog
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAddLineFilter(t *testing.T) {
+	assert := assert.New(t)
+	var buf bytes.Buffer
+	var writer = New(&buf, "", 0)
+	writer.SetTerminalWidth(80)
+	defer writer.Close()
+	writer.AddLineFilter(func(line []byte) ([]byte, bool) {
+		return line, !bytes.HasPrefix(line, []byte("DEBUG"))
+	})
+	writer.AddLineFilter(func(line []byte) ([]byte, bool) {
+		return bytes.ToUpper(line), true
+	})
+	writer.Print("DEBUG: noise\nhello\n")
+	assert.Equal("HELLO\n", buf.String())
+	buf.Reset()
+	writer.Print("DEBUG: partial")
+	assert.Equal("DEBUG: partial", buf.String(), "partial lines are not filtered")
+	buf.Reset()
+	writer.Print("\n")
+	assert.Equal("\r              ", buf.String(), "the dropped partial line is erased")
+}
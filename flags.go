@@ -0,0 +1,89 @@
+package alog
+
+import (
+	"flag"
+	"os"
+	"strconv"
+)
+
+// RegisterFlags defines the usual logging switches on fs (flag.CommandLine if
+// nil), which apply to the Logger as they're parsed:
+//
+//	-log-level=warn  drop output below the given level (see SetMinLevel)
+//	-no-color        don't write colors
+//	-log-file=path   append the log to a file instead of the Logger's writer
+//	-log-json        write completed lines as JSON objects (see SetJSONOutput)
+//
+// A file opened for -log-file is closed when the Logger is closed. Both -name
+// and --name are accepted, as usual for the flag package. To use them with
+// pflag, register them on a flag.FlagSet and add it with pflag's
+// AddGoFlagSet.
+func (l *Logger) RegisterFlags(fs *flag.FlagSet) {
+	if fs == nil {
+		fs = flag.CommandLine
+	}
+	f := &logFlags{l: l}
+	fs.Func("log-level", "only log at this level (info, warn or error) and above", func(s string) error {
+		level, err := ParseLevel(s)
+		if err == nil {
+			l.SetMinLevel(level)
+		}
+		return err
+	})
+	fs.Var(boolFlagFunc(func(noColor bool) {
+		// -no-color=false leaves the default alone rather than forcing colors.
+		if noColor {
+			l.DisableColor()
+		}
+	}), "no-color", "don't colorize the log")
+	fs.Func("log-file", "append the log to this file", f.setFile)
+	fs.Var(boolFlagFunc(l.SetJSONOutput), "log-json", "log as JSON, one object per line")
+	l.addCloseFunc(f.close)
+}
+
+func RegisterFlags(fs *flag.FlagSet) { DefaultLogger.RegisterFlags(fs) }
+
+// logFlags tracks the file a Logger's output was redirected to by -log-file.
+type logFlags struct {
+	l    *Logger
+	file *os.File
+}
+
+func (f *logFlags) setFile(path string) error {
+	file, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_APPEND, 0644)
+	if err != nil {
+		return err
+	}
+	// A file isn't a terminal, so don't fill it with escapes.
+	f.l.DisableColor()
+	f.l.SetOutput(file)
+	f.close()
+	f.file = file
+	return nil
+}
+
+// close closes the file opened for -log-file, if any, once everything
+// queued for it has been written.
+func (f *logFlags) close() error {
+	if f.file == nil {
+		return nil
+	}
+	getWriterState(f.file).queue.wait()
+	err := f.file.Close()
+	f.file = nil
+	return err
+}
+
+// A boolFlagFunc is a boolean flag that calls a function when set.
+type boolFlagFunc func(bool)
+
+func (fn boolFlagFunc) IsBoolFlag() bool { return true }
+func (fn boolFlagFunc) String() string   { return "false" }
+
+func (fn boolFlagFunc) Set(s string) error {
+	value, err := strconv.ParseBool(s)
+	if err == nil {
+		fn(value)
+	}
+	return err
+}
@@ -0,0 +1,84 @@
+package alog
+
+import (
+	"bytes"
+	"flag"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func newFlagTestLogger(buf *bytes.Buffer) (*Logger, *flag.FlagSet) {
+	writer := New(buf, "", 0)
+	writer.EnableColor()
+	fs := flag.NewFlagSet("test", flag.ContinueOnError)
+	fs.SetOutput(io.Discard)
+	writer.RegisterFlags(fs)
+	return writer, fs
+}
+
+func TestRegisterFlags(t *testing.T) {
+	assert := assert.New(t)
+	var buf bytes.Buffer
+	writer, fs := newFlagTestLogger(&buf)
+	defer writer.Close()
+	assert.NoError(fs.Parse([]string{"--log-level=warn", "--no-color"}))
+	writer.Printf("info\n")
+	writer.Msg("message")
+	writer.Warnf("warning\n")
+	writer.Errorf("error\n")
+	assert.Equal("warning\nerror\n", buf.String())
+	assert.Error(fs.Parse([]string{"--log-level=chatty"}))
+}
+
+func TestRegisterFlagsNoColorFalse(t *testing.T) {
+	assert := assert.New(t)
+	var buf bytes.Buffer
+	writer := New(&buf, "", 0)
+	defer writer.Close()
+	fs := flag.NewFlagSet("test", flag.ContinueOnError)
+	writer.RegisterFlags(fs)
+	assert.NoError(fs.Parse([]string{"--no-color=false"}))
+	assert.False(writer.colorEnabled.isSet(), "colors are left to the default")
+}
+
+func TestRegisterFlagsFileAndJSON(t *testing.T) {
+	assert := assert.New(t)
+	var buf bytes.Buffer
+	writer, fs := newFlagTestLogger(&buf)
+	defer writer.Close()
+	path := filepath.Join(t.TempDir(), "app.log")
+	assert.NoError(fs.Parse([]string{"--log-json", "--log-file", path}))
+	writer.Print("partial")
+	writer.Warnf(" line\n")
+	assert.Equal("", buf.String())
+	data, err := os.ReadFile(path)
+	assert.NoError(err)
+	assert.Regexp(`^\{"time":"[^"]+","level":"warn","message":"partial line"\}\n$`, string(data))
+	assert.NoError(fs.Parse([]string{"--log-json=false"}))
+	writer.Printf("plain\n")
+	data, _ = os.ReadFile(path)
+	assert.Regexp(`\}\nplain\n$`, string(data))
+	writer.Close()
+	_, err = writer.Writer().(*os.File).Write([]byte("x"))
+	assert.Error(err, "the file is closed along with the Logger")
+}
+
+func TestRegisterFlagsJSONSharedWriter(t *testing.T) {
+	assert := assert.New(t)
+	var buf bytes.Buffer
+	writer, fs := newFlagTestLogger(&buf)
+	defer writer.Close()
+	other := New(&buf, "", 0)
+	defer other.Close()
+	other.SetTerminalWidth(80)
+	other.ShowPartialLines()
+	assert.NoError(fs.Parse([]string{"--log-json"}))
+	other.Print("working...")
+	buf.Reset()
+	writer.Printf("done\n")
+	assert.Regexp(`^\r\{"time":"[^"]+","level":"info","message":"done"\}\nworking\.\.\.$`, buf.String(), "the partial line is redrawn below")
+}
@@ -0,0 +1,118 @@
+package alog
+
+import (
+	"io"
+	"os"
+	"time"
+)
+
+// followPollInterval is how often Follow checks for new output.
+var followPollInterval = 250 * time.Millisecond
+
+// Follow tails the file at path, like tail -F, and writes whatever is
+// appended to it to l, so that log files of other processes can be shown
+// along with the program's own output. Output starts at the end of the file
+// as it is when Follow is called. If the file is truncated, it is read again
+// from the start; if it is replaced (e.g. rotated), the rest of the old file
+// is read before moving on to the new one. The file need not exist yet.
+// Follow returns immediately; call stop to stop following, which waits until
+// nothing more will be written to l.
+func Follow(path string, l *Logger) (stop func()) {
+	stopChan := make(chan struct{})
+	done := make(chan struct{})
+	f := &follower{path: path, logger: l}
+	f.open(true)
+	go func() {
+		defer close(done)
+		defer f.close()
+		ticker := time.NewTicker(followPollInterval)
+		defer ticker.Stop()
+		for {
+			f.poll()
+			select {
+			case <-stopChan:
+				return
+			case <-ticker.C:
+			}
+		}
+	}()
+	return func() {
+		close(stopChan)
+		<-done
+	}
+}
+
+type follower struct {
+	path   string
+	logger *Logger
+	file   *os.File
+	offset int64
+	buf    []byte
+}
+
+// open opens the file at path, if it exists, starting at its end if
+// atEnd is set.
+func (f *follower) open(atEnd bool) {
+	file, err := os.Open(f.path)
+	if err != nil {
+		return
+	}
+	f.file, f.offset = file, 0
+	if atEnd {
+		f.offset, _ = file.Seek(0, io.SeekEnd)
+	}
+}
+
+func (f *follower) close() {
+	if f.file != nil {
+		f.file.Close()
+		f.file = nil
+	}
+}
+
+// poll copies any new output to the logger, handling truncation and
+// replacement of the file.
+func (f *follower) poll() {
+	if f.file == nil {
+		f.open(false)
+		if f.file == nil {
+			return
+		}
+	}
+	if stat, err := f.file.Stat(); err == nil && stat.Size() < f.offset {
+		// Truncated; start over.
+		f.offset, _ = f.file.Seek(0, io.SeekStart)
+	}
+	f.copy()
+	if stat, err := os.Stat(f.path); err != nil || !sameFile(f.file, stat) {
+		// Replaced or removed. Anything written to the old file before
+		// that has been copied above.
+		f.close()
+		f.logger.Flush()
+		if err == nil {
+			f.open(false)
+			f.copy()
+		}
+	}
+}
+
+func (f *follower) copy() {
+	if f.buf == nil {
+		f.buf = make([]byte, 32<<10)
+	}
+	for {
+		n, err := f.file.Read(f.buf)
+		if n > 0 {
+			f.offset += int64(n)
+			f.logger.Write(f.buf[:n])
+		}
+		if err != nil || n == 0 {
+			return
+		}
+	}
+}
+
+func sameFile(file *os.File, stat os.FileInfo) bool {
+	fileStat, err := file.Stat()
+	return err == nil && os.SameFile(fileStat, stat)
+}
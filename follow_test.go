@@ -0,0 +1,58 @@
+package alog
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type syncBuffer struct {
+	sync.Mutex
+	buf bytes.Buffer
+}
+
+func (b *syncBuffer) Write(p []byte) (int, error) {
+	b.Lock()
+	defer b.Unlock()
+	return b.buf.Write(p)
+}
+
+func (b *syncBuffer) String() string {
+	b.Lock()
+	defer b.Unlock()
+	return b.buf.String()
+}
+
+func TestFollow(t *testing.T) {
+	assert := assert.New(t)
+	prevInterval := followPollInterval
+	followPollInterval = time.Millisecond
+	defer func() { followPollInterval = prevInterval }()
+	path := filepath.Join(t.TempDir(), "worker.log")
+	assert.NoError(os.WriteFile(path, []byte("old\n"), 0644))
+
+	var out syncBuffer
+	var writer = New(&out, "worker: ", 0)
+	defer writer.Close()
+	stop := Follow(path, writer)
+	appendTo := func(s string) {
+		f, err := os.OpenFile(path, os.O_WRONLY|os.O_APPEND, 0644)
+		assert.NoError(err)
+		f.WriteString(s)
+		f.Close()
+	}
+	appendTo("one\n")
+	assert.Eventually(func() bool { return out.String() == "worker: one\n" }, time.Second, time.Millisecond)
+
+	// Rotate
+	appendTo("two\n")
+	assert.NoError(os.Rename(path, path+".1"))
+	assert.NoError(os.WriteFile(path, []byte("three\n"), 0644))
+	assert.Eventually(func() bool { return out.String() == "worker: one\nworker: two\nworker: three\n" }, time.Second, time.Millisecond)
+	stop()
+}
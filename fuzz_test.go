@@ -0,0 +1,37 @@
+package alog
+
+import (
+	"bytes"
+	"testing"
+)
+
+// FuzzWrite feeds arbitrary output, split into several writes, through the
+// code that tracks the virtual cursor, carriage returns and ANSI escapes.
+func FuzzWrite(f *testing.F) {
+	f.Add([]byte("hello\rjello\n"), uint8(3))
+	f.Add([]byte("\033[31mred\033[0m\rab\n"), uint8(2))
+	f.Add([]byte("progress 10%\rprogress 20%\r\n"), uint8(5))
+	f.Add([]byte("a‍b́c\r\033[1;2\n"), uint8(1))
+	f.Add([]byte("☃☃☃\r\033[32mx\r\r\n\r"), uint8(4))
+	f.Add([]byte("abc\ré\nd\r\n"), uint8(7))
+	f.Fuzz(func(t *testing.T, data []byte, chunk uint8) {
+		var buf bytes.Buffer
+		writer := New(&buf, "", 0)
+		writer.SetTerminalWidth(20)
+		writer.ShowPartialLines()
+		writer.EnableColorTemplate()
+		size := int(chunk)%8 + 1
+		for len(data) > 0 {
+			n := size
+			if n > len(data) {
+				n = len(data)
+			}
+			writer.Write(data[:n])
+			data = data[n:]
+			if writer.cursorByteIndex < 0 || writer.cursorByteIndex > len(writer.buf) {
+				t.Fatalf("cursor at %d in a %d byte line", writer.cursorByteIndex, len(writer.buf))
+			}
+		}
+		writer.Close()
+	})
+}
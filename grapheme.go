@@ -0,0 +1,111 @@
+package alog
+
+import (
+	"unicode"
+	"unicode/utf8"
+)
+
+// isGraphemeExtender reports whether r attaches to the character before it
+// rather than starting a new one: combining marks, variation selectors, emoji
+// skin tone modifiers, tag characters and the like.
+func isGraphemeExtender(r rune) bool {
+	switch {
+	case r == 0x200d, // zero width joiner, handled by the caller too
+		r == 0x20e3, // combining enclosing keycap
+		r >= 0xfe00 && r <= 0xfe0f,
+		r >= 0x1f3fb && r <= 0x1f3ff,
+		r >= 0xe0020 && r <= 0xe007f,
+		r >= 0xe0100 && r <= 0xe01ef:
+		return true
+	}
+	return unicode.In(r, unicode.Mn, unicode.Me, unicode.Mc)
+}
+
+func isRegionalIndicator(r rune) bool {
+	return r >= 0x1f1e6 && r <= 0x1f1ff
+}
+
+// graphemeLen returns the length in bytes of the user-perceived character
+// (approximately, a grapheme cluster) at the start of buf, so that text is
+// never cut in the middle of an accented letter, flag or emoji sequence.
+func graphemeLen(buf []byte) int {
+	r, n := utf8.DecodeRune(buf)
+	if n == 0 {
+		return 0
+	}
+	if isRegionalIndicator(r) {
+		// Flags are pairs of regional indicators.
+		if r2, n2 := utf8.DecodeRune(buf[n:]); isRegionalIndicator(r2) {
+			n += n2
+		}
+	}
+	for n < len(buf) {
+		r, size := utf8.DecodeRune(buf[n:])
+		if !isGraphemeExtender(r) {
+			break
+		}
+		n += size
+		if r == 0x200d && n < len(buf) {
+			// A zero width joiner glues on the next character as well.
+			_, size = utf8.DecodeRune(buf[n:])
+			n += size
+		}
+	}
+	return n
+}
+
+// nextToken returns the length of the color escape or character at the start
+// of buf, and whether it is an escape.
+func nextToken(buf []byte) (int, bool) {
+	if buf[0] == '\033' {
+		if loc := ansiColorPrefixRegexp.FindIndex(buf); loc != nil {
+			return loc[1], true
+		}
+	}
+	return graphemeLen(buf), false
+}
+
+// eastAsianWide are the characters with an East Asian Width of Wide or
+// Fullwidth, which terminals draw two columns wide: CJK ideographs, kana,
+// Hangul, fullwidth forms and the like.
+var eastAsianWide = &unicode.RangeTable{
+	R16: []unicode.Range16{
+		{0x1100, 0x115f, 1}, {0x2329, 0x232a, 1}, {0x2e80, 0x2e99, 1},
+		{0x2e9b, 0x2ef3, 1}, {0x2f00, 0x2fd5, 1}, {0x2ff0, 0x2fff, 1},
+		{0x3000, 0x303e, 1}, {0x3041, 0x3096, 1}, {0x3099, 0x30ff, 1},
+		{0x3105, 0x312f, 1}, {0x3131, 0x318e, 1}, {0x3190, 0x31e5, 1},
+		{0x31ef, 0x321e, 1}, {0x3220, 0x3247, 1}, {0x3250, 0xa48c, 1},
+		{0xa490, 0xa4c6, 1}, {0xa960, 0xa97c, 1}, {0xac00, 0xd7a3, 1},
+		{0xf900, 0xfaff, 1}, {0xfe10, 0xfe19, 1}, {0xfe30, 0xfe52, 1},
+		{0xfe54, 0xfe66, 1}, {0xfe68, 0xfe6b, 1}, {0xff01, 0xff60, 1},
+		{0xffe0, 0xffe6, 1},
+	},
+	R32: []unicode.Range32{
+		{0x16fe0, 0x16fe4, 1}, {0x16ff0, 0x16ff1, 1}, {0x17000, 0x187f7, 1},
+		{0x18800, 0x18cd5, 1}, {0x18d00, 0x18d08, 1}, {0x1aff0, 0x1affe, 1},
+		{0x1b000, 0x1b122, 1}, {0x1b132, 0x1b132, 1}, {0x1b150, 0x1b152, 1},
+		{0x1b155, 0x1b155, 1}, {0x1b164, 0x1b167, 1}, {0x1b170, 0x1b2fb, 1},
+		{0x1f200, 0x1f202, 1}, {0x1f210, 0x1f23b, 1}, {0x1f240, 0x1f248, 1},
+		{0x1f250, 0x1f251, 1}, {0x1f260, 0x1f265, 1}, {0x20000, 0x2fffd, 1},
+		{0x30000, 0x3fffd, 1},
+	},
+}
+
+// graphemeWidth returns the number of columns taken by the user-perceived
+// character (as delimited by graphemeLen) grapheme: 2 for East Asian wide
+// characters and emoji, including characters turned into emoji by a
+// variation selector, and 1 otherwise.
+func graphemeWidth(grapheme []byte) int {
+	r, n := utf8.DecodeRune(grapheme)
+	if isWideEmoji(r) || unicode.Is(eastAsianWide, r) {
+		return 2
+	}
+	for n < len(grapheme) {
+		r, size := utf8.DecodeRune(grapheme[n:])
+		if r == 0xfe0f {
+			return 2
+		}
+		n += size
+	}
+	return 1
+}
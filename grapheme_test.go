@@ -0,0 +1,32 @@
+package alog
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGraphemeAwareTrimming(t *testing.T) {
+	assert := assert.New(t)
+	family := "👨‍👩‍👧"
+	flag := "🇳🇿"
+	accented := "é"
+	thumbs := "👍🏽"
+	line := []byte(family + flag + accented + thumbs + "x")
+	assert.Equal(8, stringLen(line)) // emoji and flags are two columns wide
+	assert.Equal(family, string(trimString(line, 2)))
+	assert.Equal(family+flag+accented, string(trimString(line, 5)))
+	assert.Equal(family+flag+accented+thumbs, string(trimString(line, 7)))
+	assert.Equal("ab\033[31m"+accented, string(trimString([]byte("ab\033[31m"+accented+"cd"), 3)))
+	assert.Equal(family+"...", string(trimStringEllipsis([]byte(family+family+family+family+family), 5)))
+}
+
+func TestEastAsianWidth(t *testing.T) {
+	assert := assert.New(t)
+	assert.Equal(4, stringLen([]byte("漢字")))
+	assert.Equal(6, stringLen([]byte("한국어")))
+	assert.Equal(2, stringLen([]byte("Ａ")))
+	assert.Equal("a漢", string(trimString([]byte("a漢字"), 4)))
+	// Combining marks don't take up a column of their own.
+	assert.Equal(3, stringLen([]byte("ನನಗೆ")))
+}
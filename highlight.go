@@ -0,0 +1,81 @@
+package alog
+
+import (
+	"regexp"
+	"strings"
+)
+
+type highlight struct {
+	pattern *regexp.Regexp
+	start   []byte
+}
+
+// Highlight colorizes every match of pattern in subsequent lines (partial and
+// completed) written to this Logger, including text that's already colored.
+// style is a comma-separated list of color template names, e.g. "red,bright";
+// unrecognized names are ignored. Highlighting has no visible effect when
+// color is disabled.
+func (l *Logger) Highlight(pattern *regexp.Regexp, style string) {
+	ws := getWriterState(l.out)
+	ws.lock()
+	defer ws.unlock()
+	l.customized = true
+	l.highlights = append(l.highlights, highlight{pattern, styleEscapes(style)})
+}
+
+// styleEscapes returns the escapes for a comma-separated list of color
+// template names, ignoring any it doesn't recognize.
+func styleEscapes(style string) []byte {
+	var buf []byte
+	for _, name := range strings.Split(style, ",") {
+		if code, ok := lookupColorCode(strings.TrimSpace(name)); ok {
+			for _, ansiCode := range code.GetAnsiCodes() {
+				buf = append(buf, ansiEscapeBytes(ansiCode)...)
+			}
+		}
+	}
+	return buf
+}
+
+func Highlight(pattern *regexp.Regexp, style string) { DefaultLogger.Highlight(pattern, style) }
+
+// highlight applies the Logger's highlights to line, returning line itself if
+// nothing matched. Must be called with the writer lock held.
+func (l *Logger) highlight(line []byte) []byte {
+	for _, h := range l.highlights {
+		matches := h.pattern.FindAllIndex(line, -1)
+		if len(matches) == 0 {
+			continue
+		}
+		escapes := ansiColorRegexp.FindAllIndex(line, -1)
+		var out []byte
+		last := 0
+		for _, match := range matches {
+			if match[0] == match[1] || overlapsAny(match, escapes) {
+				continue
+			}
+			out = append(out, line[last:match[0]]...)
+			out = append(out, h.start...)
+			out = append(out, line[match[0]:match[1]]...)
+			// Go back to whatever colors the line would have had here.
+			out = append(out, ansiBytesResetAll...)
+			out = append(out, getActiveAnsiCodes(line[:match[1]]).getRestoreBytes()...)
+			last = match[1]
+		}
+		if out != nil {
+			line = append(out, line[last:]...)
+		}
+	}
+	return line
+}
+
+// overlapsAny reports whether the range r overlaps any of ranges, so that we
+// never highlight part of an escape sequence.
+func overlapsAny(r []int, ranges [][]int) bool {
+	for _, other := range ranges {
+		if r[0] < other[1] && other[0] < r[1] {
+			return true
+		}
+	}
+	return false
+}
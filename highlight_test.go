@@ -0,0 +1,23 @@
+package alog
+
+import (
+	"bytes"
+	"regexp"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestHighlight(t *testing.T) {
+	assert := assert.New(t)
+	var buf bytes.Buffer
+	var writer = New(&buf, "", 0)
+	writer.EnableColor()
+	defer writer.Close()
+	writer.Highlight(regexp.MustCompile(`E\d+`), "red,bright")
+	writer.Print("failed with E42 and E7\n")
+	assert.Equal("failed with \033[31m\033[1mE42\033[0m and \033[31m\033[1mE7\033[0m\n", buf.String())
+	buf.Reset()
+	writer.Print("\033[32mgreen E1 green\033[39m\n")
+	assert.Equal("\033[32mgreen \033[31m\033[1mE1\033[0m\033[32m green\033[39m\n", buf.String(), "restores the surrounding color")
+}
@@ -0,0 +1,119 @@
+package alog
+
+import (
+	"regexp"
+	"sync"
+	"time"
+)
+
+// A History keeps the most recent lines completed by a Logger in memory, for
+// tools that want to show them again, e.g. a "show recent errors" command in
+// a REPL. See SetHistorySize. All methods are safe for concurrent use.
+type History struct {
+	mutex   sync.Mutex
+	entries []Entry // ring buffer
+	next    int     // index of the oldest entry once the buffer is full
+}
+
+// SetHistorySize sets how many of its most recent completed lines the
+// Logger keeps in its History, discarding any history kept so far. 0, the
+// default, keeps none.
+func (l *Logger) SetHistorySize(size int) {
+	ws := getWriterState(l.out)
+	ws.lock()
+	defer ws.unlock()
+	l.customized = true
+	if l.history != nil {
+		l.removeSinkLocked(l.history)
+		l.history = nil
+	}
+	if size > 0 {
+		l.history = &History{entries: make([]Entry, 0, size)}
+		l.addSinkLocked(l.history)
+	}
+}
+
+// History returns the Logger's History, which is empty unless enabled with
+// SetHistorySize.
+func (l *Logger) History() *History {
+	ws := getWriterState(l.out)
+	ws.lock()
+	defer ws.unlock()
+	if l.history == nil {
+		return &History{}
+	}
+	return l.history
+}
+
+func SetHistorySize(size int) { DefaultLogger.SetHistorySize(size) }
+func GetHistory() *History    { return DefaultLogger.History() }
+
+func (h *History) WriteEntry(e *Entry) error {
+	h.mutex.Lock()
+	defer h.mutex.Unlock()
+	entry := *e
+	entry.Fields = append([]Field(nil), e.Fields...)
+	if len(h.entries) < cap(h.entries) {
+		h.entries = append(h.entries, entry)
+		return nil
+	}
+	h.entries[h.next] = entry
+	h.next = (h.next + 1) % len(h.entries)
+	return nil
+}
+
+// Entries returns all the lines in the History, oldest first.
+func (h *History) Entries() []Entry {
+	return h.Filter(LevelInfo, nil, time.Time{})
+}
+
+// Filter returns the lines in the History, oldest first, with at least the
+// given level, matching pattern (unless it is nil) and completed no earlier
+// than since (unless it is zero).
+func (h *History) Filter(minLevel Level, pattern *regexp.Regexp, since time.Time) []Entry {
+	h.mutex.Lock()
+	defer h.mutex.Unlock()
+	var entries []Entry
+	for i := range h.entries {
+		e := &h.entries[(h.next+i)%len(h.entries)]
+		if e.Level < minLevel || e.Time.Before(since) {
+			continue
+		}
+		if pattern != nil && !pattern.MatchString(e.PlainMessage()) {
+			continue
+		}
+		entries = append(entries, *e)
+	}
+	return entries
+}
+
+// A HistoryFilter selects lines from a History, as for History.Filter.
+type HistoryFilter struct {
+	MinLevel Level
+	Pattern  *regexp.Regexp // nil matches everything
+	Since    time.Time      // zero means forever
+}
+
+// ReprintHistory prints the lines of the Logger's History selected by filter
+// again to dest, at their original levels, so that they are shown with
+// dest's current prefix and settings. dest may be l itself; the reprinted
+// lines aren't added to its History again.
+func (l *Logger) ReprintHistory(dest *Logger, filter HistoryFilter) {
+	entries := l.History().Filter(filter.MinLevel, filter.Pattern, filter.Since)
+	ws := getWriterState(dest.out)
+	ws.lock()
+	defer ws.unlock()
+	dest.reprintingHistory = true
+	defer func() { dest.reprintingHistory = false }()
+	prevLevel := dest.callLevel
+	for _, e := range entries {
+		// The message already carries its level's style.
+		dest.callLevel = e.Level
+		dest.intOutput(2, []byte(e.Message+"\n"), true)
+	}
+	dest.callLevel = prevLevel
+}
+
+func ReprintHistory(dest *Logger, filter HistoryFilter) {
+	DefaultLogger.ReprintHistory(dest, filter)
+}
@@ -0,0 +1,46 @@
+package alog
+
+import (
+	"bytes"
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestHistory(t *testing.T) {
+	assert := assert.New(t)
+	var buf bytes.Buffer
+	var writer = New(&buf, "> ", 0)
+	defer writer.Close()
+	now := time.Date(2020, 1, 2, 3, 4, 5, 0, time.UTC)
+	writer.SetClock(func() time.Time { return now })
+	assert.Empty(writer.History().Entries())
+	writer.SetHistorySize(3)
+	writer.Println("one")
+	writer.Errorf("two failed\n")
+	now = now.Add(time.Minute)
+	writer.Println("three")
+	writer.Errorf("four failed\n")
+	var messages []string
+	for _, e := range writer.History().Entries() {
+		messages = append(messages, e.PlainMessage())
+	}
+	assert.Equal([]string{"two failed", "three", "four failed"}, messages)
+	assert.Len(writer.History().Filter(LevelError, nil, time.Time{}), 2)
+	assert.Len(writer.History().Filter(LevelError, nil, now), 1)
+	assert.Len(writer.History().Filter(LevelInfo, regexp.MustCompile("^t"), time.Time{}), 2)
+
+	var out bytes.Buffer
+	other := New(&out, "# ", 0)
+	defer other.Close()
+	writer.ReprintHistory(other, HistoryFilter{MinLevel: LevelError})
+	assert.Equal("# "+string(styleForLevel(LevelError, []byte("two failed")))+"\n# "+string(styleForLevel(LevelError, []byte("four failed")))+"\n", out.String())
+
+	buf.Reset()
+	writer.ReprintHistory(writer, HistoryFilter{MinLevel: LevelError})
+	assert.Equal("> "+string(styleForLevel(LevelError, []byte("two failed")))+"\n> "+string(styleForLevel(LevelError, []byte("four failed")))+"\n", buf.String())
+	assert.Len(writer.History().Entries(), 3, "reprinted lines aren't recorded again")
+	assert.Equal("four failed", writer.History().Entries()[2].PlainMessage())
+}
@@ -0,0 +1,286 @@
+package alog
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"io"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+// An IngestFormat selects what Ingest reads.
+type IngestFormat int
+
+const (
+	// IngestAuto reads lines that are JSON objects as JSON, and other lines
+	// as logfmt if they are made up of key=value pairs. This is the default.
+	IngestAuto IngestFormat = iota
+	// IngestJSON reads JSON lines only.
+	IngestJSON
+	// IngestLogfmt reads logfmt only.
+	IngestLogfmt
+)
+
+// IngestOptions configures Ingest. The zero value is usable; empty key lists
+// default to the names used by zap, zerolog, slog, logrus and the like.
+type IngestOptions struct {
+	Format      IngestFormat
+	TimeKeys    []string // default "time", "ts", "timestamp", "@timestamp", "t"
+	LevelKeys   []string // default "level", "lvl", "severity", "log.level"
+	MessageKeys []string // default "msg", "message", "@message"
+	ShowTime    bool     // show each record's own time before its message
+	KeyStyle    string   // style of field names, e.g. "dim,cyan" (default "dim")
+	// FieldOrder lists fields to show first, in this order; the others
+	// follow in the order they appear.
+	FieldOrder []string
+	// FieldStyles maps field names to the style of their values, e.g.
+	// {"err": "red"}.
+	FieldStyles map[string]string
+}
+
+var (
+	defaultTimeKeys    = []string{"time", "ts", "timestamp", "@timestamp", "t"}
+	defaultLevelKeys   = []string{"level", "lvl", "severity", "log.level"}
+	defaultMessageKeys = []string{"msg", "message", "@message"}
+)
+
+// An ingestRecord is one structured log record read by Ingest.
+type ingestRecord struct {
+	time    string
+	level   Level
+	message string
+	fields  []Field
+
+	haveTime, haveLevel, haveMessage bool
+}
+
+// Ingest reads JSON lines or logfmt from r, as written by structured loggers
+// such as zap, zerolog and slog's handlers, and writes each record to l as a line
+// at the record's level: the message, followed by the other fields as
+// key=value in the order they appear. Lines that can't be read as records
+// are written as they are. Control characters and escapes in what is read are
+// shown in caret notation, as with SetSanitizeEnabled, and templates such as
+// {rpad} in it are written literally. It returns when r is exhausted, with the error that
+// ended reading unless it was io.EOF. A program that pretty-prints the output
+// of another, as in "mytool | pretty", can be as little as
+// Ingest(os.Stdin, nil).
+func (l *Logger) Ingest(r io.Reader, opts *IngestOptions) error {
+	var o IngestOptions
+	if opts != nil {
+		o = *opts
+	}
+	if len(o.TimeKeys) == 0 {
+		o.TimeKeys = defaultTimeKeys
+	}
+	if len(o.LevelKeys) == 0 {
+		o.LevelKeys = defaultLevelKeys
+	}
+	if len(o.MessageKeys) == 0 {
+		o.MessageKeys = defaultMessageKeys
+	}
+	if o.KeyStyle == "" {
+		o.KeyStyle = "dim"
+	}
+	reader := bufio.NewReader(r)
+	for {
+		line, err := reader.ReadBytes('\n')
+		if len(line) > 0 {
+			line = bytes.TrimRight(line, "\r\n")
+			if record, ok := parseRecord(line, &o); ok {
+				l.writeRecord(record, &o)
+			} else {
+				l.writeIngested(LevelInfo, append(escapeIngested(line), byteNewline))
+			}
+		}
+		if err == io.EOF {
+			return nil
+		} else if err != nil {
+			return err
+		}
+	}
+}
+
+func Ingest(r io.Reader, opts *IngestOptions) error { return DefaultLogger.Ingest(r, opts) }
+
+func parseRecord(line []byte, o *IngestOptions) (*ingestRecord, bool) {
+	switch o.Format {
+	case IngestJSON:
+		return parseJSONRecord(line, o)
+	case IngestLogfmt:
+		return parseLogfmtRecord(line, o)
+	}
+	if record, ok := parseJSONRecord(line, o); ok {
+		return record, true
+	}
+	return parseLogfmtRecord(line, o)
+}
+
+// writeRecord writes a record as a single line at its level.
+func (l *Logger) writeRecord(record *ingestRecord, o *IngestOptions) {
+	var line []byte
+	if o.ShowTime && record.time != "" {
+		line = append(line, styled("dim", string(escapeIngested([]byte(record.time))))...)
+		line = append(line, ' ')
+	}
+	line = append(line, escapeIngested([]byte(record.message))...)
+	keyStyle := styleEscapes(o.KeyStyle)
+	// Go back to the level's style after each key.
+	levelStyle := styleEscapes(levelColors[record.level])
+	keyReset := append(getActiveAnsiCodes(keyStyle).getResetBytes(), levelStyle...)
+	for _, field := range orderFields(record.fields, o.FieldOrder) {
+		if len(line) > 0 {
+			line = append(line, ' ')
+		}
+		line = append(line, keyStyle...)
+		line = append(line, escapeIngested([]byte(field.Key))...)
+		line = append(line, '=')
+		line = append(line, keyReset...)
+		if style, ok := o.FieldStyles[field.Key]; ok {
+			valueStyle := styleEscapes(style)
+			line = append(line, valueStyle...)
+			line = append(line, escapeIngested([]byte(field.Value))...)
+			line = append(line, getActiveAnsiCodes(valueStyle).getResetBytes()...)
+			line = append(line, levelStyle...)
+		} else {
+			line = append(line, escapeIngested([]byte(field.Value))...)
+		}
+	}
+	l.writeIngested(record.level, append(line, byteNewline))
+}
+
+// writeIngested writes a line made from foreign text at level.
+func (l *Logger) writeIngested(level Level, line []byte) {
+	ws := getWriterState(l.out)
+	ws.lock()
+	defer ws.unlock()
+	l.callVerbatim = true
+	l.levelOutput(level, 3, line)
+	l.callVerbatim = false
+}
+
+// escapeIngested shows the control characters in foreign text in caret
+// notation, so that it can't move the cursor, change colors or overwrite the
+// line, whether or not sanitizing is enabled.
+func escapeIngested(s []byte) []byte {
+	var out []byte
+	for i, c := range s {
+		if c == '\r' || c == '\033' {
+			if out == nil {
+				out = append(out, s[:i]...)
+			}
+			out = append(out, '^', c+'@')
+		} else if out != nil {
+			out = append(out, c)
+		}
+	}
+	if out == nil {
+		out = s
+	}
+	return sanitize(out)
+}
+
+// parseJSONRecord parses a JSON object, keeping the order of its fields.
+func parseJSONRecord(line []byte, o *IngestOptions) (*ingestRecord, bool) {
+	if len(bytes.TrimSpace(line)) == 0 || bytes.TrimSpace(line)[0] != '{' {
+		return nil, false
+	}
+	dec := json.NewDecoder(bytes.NewReader(line))
+	dec.UseNumber()
+	if token, err := dec.Token(); err != nil || token != json.Delim('{') {
+		return nil, false
+	}
+	record := &ingestRecord{}
+	for dec.More() {
+		token, err := dec.Token()
+		if err != nil {
+			return nil, false
+		}
+		key, _ := token.(string)
+		var raw json.RawMessage
+		if err := dec.Decode(&raw); err != nil {
+			return nil, false
+		}
+		value, isString := formatJSONValue(raw)
+		record.add(key, value, isString, o)
+	}
+	if _, err := dec.Token(); err != nil {
+		return nil, false
+	}
+	return record, true
+}
+
+// add sets the record's time, level or message if key names one that isn't
+// set yet, and adds a field otherwise. String values are quoted as needed.
+func (record *ingestRecord) add(key, value string, isString bool, o *IngestOptions) {
+	switch {
+	case !record.haveTime && containsKey(o.TimeKeys, key):
+		record.time, record.haveTime = value, true
+	case !record.haveLevel && containsKey(o.LevelKeys, key):
+		record.level, record.haveLevel = ingestLevel(value), true
+	case !record.haveMessage && containsKey(o.MessageKeys, key):
+		record.message, record.haveMessage = value, true
+	case isString:
+		record.fields = append(record.fields, Field{key, quoteFieldValue(value)})
+	default:
+		record.fields = append(record.fields, Field{key, value})
+	}
+}
+
+func containsKey(keys []string, key string) bool {
+	for _, k := range keys {
+		if k == key {
+			return true
+		}
+	}
+	return false
+}
+
+// formatJSONValue returns strings unquoted and anything else as compact JSON,
+// and whether the value was a string.
+func formatJSONValue(raw json.RawMessage) (string, bool) {
+	var s string
+	if json.Unmarshal(raw, &s) == nil {
+		return s, true
+	}
+	var buf bytes.Buffer
+	if json.Compact(&buf, raw) == nil {
+		return buf.String(), false
+	}
+	return string(raw), false
+}
+
+// quoteFieldValue quotes a field value if it would otherwise be ambiguous,
+// as logfmt does.
+func quoteFieldValue(value string) string {
+	needsQuotes := strings.IndexFunc(value, func(r rune) bool {
+		return r <= ' ' || r == '=' || r == '"' || r == 0x7f || r == utf8.RuneError
+	}) != -1
+	if value == "" || needsQuotes {
+		return strconv.Quote(value)
+	}
+	return value
+}
+
+// ingestLevel maps the level names and numbers of common structured loggers
+// to a Level. Anything unrecognized, including debug and trace, is LevelInfo.
+func ingestLevel(value string) Level {
+	if n, err := strconv.ParseFloat(value, 64); err == nil {
+		// bunyan and pino: 40 is warn, 50 is error, 60 is fatal.
+		switch {
+		case n >= 50:
+			return LevelError
+		case n >= 40:
+			return LevelWarn
+		}
+		return LevelInfo
+	}
+	switch strings.ToLower(value) {
+	case "warn", "warning":
+		return LevelWarn
+	case "error", "err", "fatal", "panic", "dpanic", "critical", "crit", "alert", "emergency", "emerg":
+		return LevelError
+	}
+	return LevelInfo
+}
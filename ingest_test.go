@@ -0,0 +1,68 @@
+package alog
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestIngest(t *testing.T) {
+	assert := assert.New(t)
+	var buf bytes.Buffer
+	writer := New(&buf, "", 0)
+	defer writer.Close()
+	input := strings.Join([]string{
+		`{"level":"info","ts":1700000000.5,"msg":"started","port":8080,"tags":["a", "b"]}`,
+		`{"time":"2024-01-02T03:04:05Z","level":"WARN","msg":"slow request","path":"/api v1","ms":1500}`,
+		`{"level":50,"msg":"failed","err":""}`,
+		`plain text`,
+		`{"msg":"no newline"}`,
+	}, "\n")
+	assert.NoError(writer.Ingest(strings.NewReader(input), nil))
+	assert.Equal(strings.Join([]string{
+		`started port=8080 tags=["a","b"]`,
+		`slow request path="/api v1" ms=1500`,
+		`failed err=""`,
+		`plain text`,
+		`no newline`,
+		``,
+	}, "\n"), string(uncolorize(buf.Bytes())))
+	assert.Contains(buf.String(), string(styleEscapes("dim"))+"port=", "field names are dimmed")
+}
+
+func TestIngestEscapes(t *testing.T) {
+	assert := assert.New(t)
+	var buf bytes.Buffer
+	writer := New(&buf, "", 0)
+	defer writer.Close()
+	writer.EnableColorTemplate()
+	writer.SetTerminalWidth(10)
+	input := strings.Join([]string{
+		`{"msg":"a{rpad}b\r\u001b[2Jc","key\u001b":"\u0007"}`,
+		"x{rpad}y\033[31mz",
+	}, "\n")
+	assert.NoError(writer.Ingest(strings.NewReader(input), nil))
+	writer.Printf("a{rpad}b\n")
+	assert.Equal(strings.Join([]string{
+		`a{rpad}b^M^[[2Jc key^[="\a"`,
+		`x{rpad}y^[[31mz`,
+		`a        b`,
+		``,
+	}, "\n"), string(uncolorize(buf.Bytes())))
+}
+
+func TestIngestOptions(t *testing.T) {
+	assert := assert.New(t)
+	var buf bytes.Buffer
+	writer := New(&buf, "", 0)
+	defer writer.Close()
+	writer.SetMinLevel(LevelWarn)
+	input := `{"when":"12:00","sev":"warning","text":"disk low","pct":95}
+{"when":"12:01","sev":"debug","text":"noise"}
+`
+	opts := &IngestOptions{TimeKeys: []string{"when"}, LevelKeys: []string{"sev"}, MessageKeys: []string{"text"}, ShowTime: true}
+	assert.NoError(writer.Ingest(strings.NewReader(input), opts))
+	assert.Equal("12:00 disk low pct=95\n", string(uncolorize(buf.Bytes())))
+}
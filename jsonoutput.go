@@ -0,0 +1,32 @@
+package alog
+
+import "encoding/json"
+
+// SetJSONOutput makes the Logger write each completed line to its writer as
+// a JSON object followed by a newline, in the same format as a NetWriter
+// using NetFormatJSON, in place of the rendered line. Only completed lines
+// make sense as JSON, so partial lines aren't shown while it is enabled.
+func (l *Logger) SetJSONOutput(enabled bool) {
+	ws := getWriterState(l.out)
+	ws.lock()
+	defer ws.unlock()
+	l.customized = true
+	l.flushInt()
+	l.jsonOutput = enabled
+}
+
+func SetJSONOutput(enabled bool) { DefaultLogger.SetJSONOutput(enabled) }
+
+// writeJSONLine writes a completed line as JSON, to the writer its level is
+// routed to. Must be called with the writer lock held.
+func (l *Logger) writeJSONLine(line []byte) {
+	var header []byte
+	l.formatHeader(&header, line)
+	buf, _ := json.Marshal(netEntry{Time: l.now, Level: l.lineLevel.String(), Prefix: string(uncolorize(header)), Message: string(uncolorize(line))})
+	if out := l.routeFor(l.lineLevel); out != nil && out != l.out {
+		ws := getWriterState(l.out)
+		ws.routed = append(ws.routed, routedLine{out, buf})
+		return
+	}
+	writeLine(l.out, buf)
+}
@@ -0,0 +1,59 @@
+package alog
+
+// tempLines remembers what's shown on each partial line, top to bottom, so
+// that redraws can skip or shorten unchanged output. There is always at least
+// one line, the one the next partial line will be drawn on. Lines out of
+// range read as blank, and setting one grows the list as needed, so a change
+// of layout can't make a redraw index past the end.
+type tempLines struct {
+	lines [][]byte
+}
+
+// reset forgets all lines, leaving a single blank one.
+func (t *tempLines) reset() {
+	t.lines = [][]byte{[]byte{}}
+}
+
+// len returns the number of lines.
+func (t *tempLines) len() int {
+	if len(t.lines) == 0 {
+		return 1
+	}
+	return len(t.lines)
+}
+
+// get returns what's shown on line i, or nothing if there's no such line.
+func (t *tempLines) get(i int) []byte {
+	if i < 0 || i >= len(t.lines) {
+		return bytesEmpty
+	}
+	return t.lines[i]
+}
+
+// set records that buf is shown on line i, adding blank lines up to it if
+// needed. It keeps a copy of buf.
+func (t *tempLines) set(i int, buf []byte) {
+	if i < 0 {
+		return
+	}
+	for len(t.lines) <= i {
+		t.lines = append(t.lines, []byte{})
+	}
+	t.lines[i] = append([]byte{}, buf...)
+}
+
+// add adds a blank line at the bottom.
+func (t *tempLines) add() {
+	t.lines = append(t.lines, []byte{})
+}
+
+// shift removes the top line, as when it's completed and scrolls up into the
+// log, and reports whether it was the only one; if so, a blank line is left.
+func (t *tempLines) shift() bool {
+	if len(t.lines) <= 1 {
+		t.reset()
+		return true
+	}
+	t.lines = t.lines[1:]
+	return false
+}
@@ -0,0 +1,75 @@
+package alog
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLastTempLines(t *testing.T) {
+	assert := assert.New(t)
+	var lines tempLines
+	assert.Equal(1, lines.len())
+	assert.Equal("", string(lines.get(5)))
+	lines.set(2, []byte("c"))
+	assert.Equal(3, lines.len())
+	assert.Equal("", string(lines.get(1)))
+	assert.Equal("c", string(lines.get(2)))
+	assert.False(lines.shift())
+	assert.Equal("c", string(lines.get(1)))
+	lines.set(-1, []byte("x"))
+	assert.False(lines.shift())
+	assert.True(lines.shift())
+	assert.Equal(1, lines.len())
+	assert.Equal("", string(lines.get(0)))
+}
+
+func TestToggleMultilineWithTempLines(t *testing.T) {
+	if !cursorMovementSupported {
+		t.Skip("multiline mode isn't supported here")
+	}
+	assert := assert.New(t)
+	var buf bytes.Buffer
+	writer := New(&buf, "", 0)
+	defer writer.Close()
+	writer.EnableMultilineMode()
+	writer.Segment("status").Set("ready")
+	writer.Print("working...")
+	other := New(&buf, "", 0)
+	defer other.Close()
+	other.Print("waiting...")
+	ws := getWriterState(&buf)
+	assert.Equal(3, ws.lastTemp.len())
+	writer.EnableSinglelineMode()
+	assert.Equal(1, ws.lastTemp.len(), "only one line is used in single-line mode")
+	assert.Equal(0, ws.cursorLineIndex)
+	writer.Print("again...")
+	writer.EnableMultilineMode()
+	writer.Print(" done.\n")
+	assert.NotPanics(func() { writer.Segment("status").Set("done") })
+}
+
+func TestSwitchModeKeepsTempLines(t *testing.T) {
+	if !cursorMovementSupported {
+		t.Skip("multiline mode isn't supported here")
+	}
+	assert := assert.New(t)
+	var buf bytes.Buffer
+	writer1 := New(&buf, "", 0)
+	defer writer1.Close()
+	writer2 := New(&buf, "", 0)
+	defer writer2.Close()
+	writer1.SetTerminalWidth(80)
+	writer1.Print("writer1...")
+	writer2.Print("writer2...")
+	buf.Reset()
+	writer1.EnableMultilineMode()
+	assert.True(strings.HasSuffix(buf.String(), "\rwriter1..."+tput("cud", "1")+"\rwriter2...\033[?25h"), "%q", buf.String())
+	buf.Reset()
+	writer1.EnableSinglelineMode()
+	assert.True(strings.HasSuffix(buf.String(), "\rwriter1... | writer2..."), "%q", buf.String())
+	writer2.Print(" done.\n")
+	assert.True(strings.HasSuffix(buf.String(), "writer2... done.       \nwriter1..."), "%q", buf.String())
+}
@@ -0,0 +1,192 @@
+package alog
+
+import (
+	"fmt"
+	"strings"
+)
+
+// A Level describes the severity of a logged line. Lines written through the
+// plain Print functions are LevelInfo.
+type Level int
+
+const (
+	LevelInfo Level = iota
+	LevelWarn
+	LevelError
+)
+
+var levelNames = map[Level]string{
+	LevelInfo:  "info",
+	LevelWarn:  "warn",
+	LevelError: "error",
+}
+
+func (level Level) String() string {
+	if name, ok := levelNames[level]; ok {
+		return name
+	}
+	return fmt.Sprintf("level(%d)", int(level))
+}
+
+// ParseLevel returns the Level with the given name, e.g. "warn".
+func ParseLevel(name string) (Level, error) {
+	for level, levelName := range levelNames {
+		if strings.EqualFold(name, levelName) {
+			return level, nil
+		}
+	}
+	return LevelInfo, fmt.Errorf("unknown log level %q", name)
+}
+
+// SetMinLevel makes the Logger drop output below level, e.g. SetMinLevel(LevelWarn)
+// to only show warnings and errors. By default nothing is dropped.
+func (l *Logger) SetMinLevel(level Level) {
+	ws := getWriterState(l.out)
+	ws.lock()
+	defer ws.unlock()
+	l.customized = true
+	l.minLevel = level
+}
+
+func SetMinLevel(level Level) { DefaultLogger.SetMinLevel(level) }
+
+// levelColors maps levels to the color names used to style their messages.
+var levelColors = map[Level]string{
+	LevelWarn:  "warn",
+	LevelError: "error",
+}
+
+// styleForLevel wraps s in the color associated with level. The color is
+// written as raw escapes so that it applies whether or not color templates
+// are enabled; it is stripped later along with everything else if color is
+// disabled.
+func styleForLevel(level Level, s []byte) []byte {
+	name, ok := levelColors[level]
+	if !ok {
+		return s
+	}
+	var codes ActiveAnsiCodes
+	tmp := []byte{}
+	colorCode, _ := lookupColorCode(name)
+	for _, code := range colorCode.GetAnsiCodes() {
+		codes.add(code)
+		tmp = append(tmp, ansiEscapeBytes(code)...)
+	}
+	tmp = append(tmp, s...)
+	if n := len(tmp); n > 0 && tmp[n-1] == byteNewline {
+		// Reset before the newline rather than after it, so that the color
+		// doesn't bleed into whatever is printed next on the new line.
+		tmp = append(tmp[:n-1], codes.getResetBytes()...)
+		return append(tmp, byteNewline)
+	}
+	return append(tmp, codes.getResetBytes()...)
+}
+
+// levelOutput writes s at the given level. Must be called with the writer lock
+// held; calldepth is relative to the caller of levelOutput.
+func (l *Logger) levelOutput(level Level, calldepth int, s []byte) {
+	prevLevel := l.callLevel
+	l.callLevel = level
+	l.intOutput(calldepth+1, l.styleForLevel(level, s), true)
+	l.callLevel = prevLevel
+}
+
+// Warn prints to the logger at LevelWarn, styled with the "warn" color.
+// Arguments are handled in the manner of fmt.Print.
+func (l *Logger) Warn(v ...interface{}) {
+	if !l.IsEnabled() {
+		return
+	}
+	s := []byte(fmt.Sprint(v...))
+	ws := getWriterState(l.out)
+	ws.lock()
+	defer ws.unlock()
+	l.levelOutput(LevelWarn, 2, s)
+}
+
+// Warnf prints to the logger at LevelWarn, styled with the "warn" color.
+// Arguments are handled in the manner of fmt.Printf.
+func (l *Logger) Warnf(format string, v ...interface{}) {
+	if !l.IsEnabled() {
+		return
+	}
+	buf := l.formatArgs(format, v)
+	defer buf.release()
+	ws := getWriterState(l.out)
+	ws.lock()
+	defer ws.unlock()
+	l.levelOutput(LevelWarn, 2, buf.b)
+}
+
+// Error prints to the logger at LevelError, styled with the "error" color.
+// Arguments are handled in the manner of fmt.Print.
+func (l *Logger) Error(v ...interface{}) {
+	if !l.IsEnabled() {
+		return
+	}
+	s := []byte(fmt.Sprint(v...))
+	ws := getWriterState(l.out)
+	ws.lock()
+	defer ws.unlock()
+	l.levelOutput(LevelError, 2, s)
+}
+
+// Errorf prints to the logger at LevelError, styled with the "error" color.
+// Arguments are handled in the manner of fmt.Printf.
+func (l *Logger) Errorf(format string, v ...interface{}) {
+	if !l.IsEnabled() {
+		return
+	}
+	buf := l.formatArgs(format, v)
+	defer buf.release()
+	ws := getWriterState(l.out)
+	ws.lock()
+	defer ws.unlock()
+	l.levelOutput(LevelError, 2, buf.b)
+}
+
+func Warn(v ...interface{}) {
+	if !DefaultLogger.IsEnabled() {
+		return
+	}
+	s := []byte(fmt.Sprint(v...))
+	ws := getWriterState(DefaultLogger.out)
+	ws.lock()
+	defer ws.unlock()
+	DefaultLogger.levelOutput(LevelWarn, 2, s)
+}
+
+func Warnf(format string, v ...interface{}) {
+	if !DefaultLogger.IsEnabled() {
+		return
+	}
+	buf := DefaultLogger.formatArgs(format, v)
+	defer buf.release()
+	ws := getWriterState(DefaultLogger.out)
+	ws.lock()
+	defer ws.unlock()
+	DefaultLogger.levelOutput(LevelWarn, 2, buf.b)
+}
+
+func Error(v ...interface{}) {
+	if !DefaultLogger.IsEnabled() {
+		return
+	}
+	s := []byte(fmt.Sprint(v...))
+	ws := getWriterState(DefaultLogger.out)
+	ws.lock()
+	defer ws.unlock()
+	DefaultLogger.levelOutput(LevelError, 2, s)
+}
+
+func Errorf(format string, v ...interface{}) {
+	if !DefaultLogger.IsEnabled() {
+		return
+	}
+	buf := DefaultLogger.formatArgs(format, v)
+	defer buf.release()
+	ws := getWriterState(DefaultLogger.out)
+	ws.lock()
+	defer ws.unlock()
+	DefaultLogger.levelOutput(LevelError, 2, buf.b)
+}
@@ -0,0 +1,141 @@
+package alog
+
+import (
+	"bytes"
+	"strconv"
+)
+
+// A LineLengthPolicy determines what happens to completed lines that are
+// wider than the terminal (or a fixed maximum width).
+type LineLengthPolicy int
+
+const (
+	// LineLengthNone writes completed lines at full length, leaving it to the
+	// terminal to wrap them. This is the default.
+	LineLengthNone LineLengthPolicy = iota
+	// LineLengthTruncateEllipsis cuts long lines short, ending them with "...".
+	LineLengthTruncateEllipsis
+	// LineLengthWrap breaks long lines into several, each no wider than the
+	// maximum.
+	LineLengthWrap
+)
+
+// SetLineLengthPolicy sets how completed lines longer than maxWidth
+// characters (not counting ANSI escapes) are written. A maxWidth of zero means
+// the terminal width. Partial lines are always truncated to fit the terminal.
+func (l *Logger) SetLineLengthPolicy(policy LineLengthPolicy, maxWidth int) {
+	ws := getWriterState(l.out)
+	ws.lock()
+	defer ws.unlock()
+	l.customized = true
+	l.lineLengthPolicy = policy
+	l.maxLineWidth = maxWidth
+}
+
+func SetLineLengthPolicy(policy LineLengthPolicy, maxWidth int) {
+	DefaultLogger.SetLineLengthPolicy(policy, maxWidth)
+}
+
+// writeCompletedLine writes a formatted line according to the Logger's line
+// length policy. Must be called with the writer lock held.
+func (l *Logger) writeCompletedLine(buf []byte) {
+	if l.lineLengthPolicy == LineLengthNone {
+		writeLine(l.out, buf)
+		return
+	}
+	width := l.getLineWidth()
+	if l.lineLengthPolicy == LineLengthTruncateEllipsis {
+		writeLine(l.out, trimStringEllipsis(buf, width))
+		return
+	}
+	chunks := wrapString(buf, width)
+	if cont := l.getContinuationPrefix(); len(cont) > 0 && len(chunks) > 1 {
+		// Rewrap what follows the first line to leave room for the prefix.
+		rest := bytes.Join(chunks[1:], nil)
+		chunks = chunks[:1]
+		for _, chunk := range wrapString(rest, width-stringLen(cont)) {
+			chunks = append(chunks, append(append([]byte{}, cont...), chunk...))
+		}
+	}
+	for _, chunk := range chunks {
+		writeLine(l.out, chunk)
+	}
+}
+
+// SetContinuationPrefix sets a template written at the start of each
+// continuation line when a long line is wrapped by the LineLengthWrap
+// policy, e.g. "  ↪ ". The template may contain color templates, and
+// "{header}", which stands for the formatted prefix of the line (timestamp
+// and all), so that every physical line can be found with grep. By default,
+// continuation lines start with nothing.
+func (l *Logger) SetContinuationPrefix(template string) {
+	ws := getWriterState(l.out)
+	ws.lock()
+	defer ws.unlock()
+	l.customized = true
+	l.continuationPrefix = []byte(l.applyColorTemplates(template))
+}
+
+func SetContinuationPrefix(template string) { DefaultLogger.SetContinuationPrefix(template) }
+
+var bytesHeaderTemplate = []byte("{header}")
+
+// getContinuationPrefix returns the formatted continuation prefix. Must be
+// called with the writer lock held.
+func (l *Logger) getContinuationPrefix() []byte {
+	if len(l.continuationPrefix) == 0 {
+		return nil
+	}
+	var cont []byte
+	for i, part := range bytes.Split(l.continuationPrefix, bytesHeaderTemplate) {
+		if i > 0 {
+			l.formatHeader(&cont, nil)
+			cont = append(cont, getActiveAnsiCodes(cont).getResetBytes()...)
+		}
+		cont = append(cont, part...)
+	}
+	if !l.isColorEnabled() {
+		cont = uncolorize(cont)
+	}
+	return cont
+}
+
+// getLineWidth returns the width completed lines are laid out for. Must be
+// called with the writer lock held.
+func (l *Logger) getLineWidth() int {
+	if l.maxLineWidth > 0 {
+		return l.maxLineWidth
+	}
+	return getTermWidth(l.out)
+}
+
+// wrapString splits buf into pieces of at most width characters, carrying
+// colors over from each piece to the next.
+func wrapString(buf []byte, width int) [][]byte {
+	if width <= 0 || stringLen(buf) <= width {
+		return [][]byte{buf}
+	}
+	var chunks [][]byte
+	var chunk []byte
+	var ansiActive ActiveAnsiCodes
+	length := 0
+	for i := 0; i < len(buf); {
+		n, isEscape := nextToken(buf[i:])
+		token := buf[i : i+n]
+		i += n
+		if isEscape {
+			code, _ := strconv.Atoi(string(ansiColorRegexp.FindSubmatch(token)[1]))
+			ansiActive.add(code)
+			chunk = append(chunk, token...)
+			continue
+		}
+		tokenWidth := graphemeWidth(token)
+		if length+tokenWidth > width && length > 0 {
+			chunks = append(chunks, chunk)
+			chunk, length = ansiActive.getRestoreBytes(), 0
+		}
+		chunk = append(chunk, token...)
+		length += tokenWidth
+	}
+	return append(chunks, chunk)
+}
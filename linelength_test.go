@@ -0,0 +1,51 @@
+package alog
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLineLengthPolicy(t *testing.T) {
+	assert := assert.New(t)
+	var buf bytes.Buffer
+	var writer = New(&buf, "", 0)
+	writer.SetTerminalWidth(10)
+	defer writer.Close()
+	writer.Print("0123456789abcdef\n")
+	assert.Equal("0123456789abcdef\n", buf.String())
+	buf.Reset()
+	writer.SetLineLengthPolicy(LineLengthTruncateEllipsis, 0)
+	writer.Print("0123456789abcdef\n")
+	assert.Equal("0123456...\n", buf.String())
+	buf.Reset()
+	writer.SetLineLengthPolicy(LineLengthWrap, 6)
+	writer.Print("0123456789abcdef\n")
+	assert.Equal("012345\n6789ab\ncdef\n", buf.String())
+}
+
+func TestWrapStringCarriesColors(t *testing.T) {
+	assert := assert.New(t)
+	chunks := wrapString([]byte("ab\033[31mcd\033[39mef"), 3)
+	assert.Equal([][]byte{
+		[]byte("ab\033[31mc"),
+		[]byte("\033[31md\033[39mef"),
+	}, chunks)
+}
+
+func TestContinuationPrefix(t *testing.T) {
+	assert := assert.New(t)
+	var buf bytes.Buffer
+	var writer = New(&buf, "P: ", 0)
+	defer writer.Close()
+	writer.DisableColor()
+	writer.SetLineLengthPolicy(LineLengthWrap, 8)
+	writer.SetContinuationPrefix("> ")
+	writer.Print("0123456789abcdef\n")
+	assert.Equal("P: 01234\n> 56789a\n> bcdef\n", buf.String())
+	buf.Reset()
+	writer.SetContinuationPrefix("{header}")
+	writer.Print("0123456789abcdef\n")
+	assert.Equal("P: 01234\nP: 56789\nP: abcde\nP: f\n", buf.String())
+}
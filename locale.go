@@ -0,0 +1,107 @@
+package alog
+
+import (
+	"bytes"
+	"strings"
+	"time"
+)
+
+// A Locale adapts the human-facing parts of a Logger's output to a language
+// and region. Machine-oriented output, such as {isodate} and entries sent to
+// sinks, is left alone.
+type Locale interface {
+	// FormatDate formats the date for Ldate and the {date} template.
+	FormatDate(t time.Time) string
+	// DecimalSeparator returns the decimal separator used in durations and
+	// rates, e.g. ",".
+	DecimalSeparator() string
+	// LevelName returns the name of level for the {level} template.
+	LevelName(level Level) string
+}
+
+// SetLocale sets the Locale used to format dates, durations and level names.
+// nil, the default, means the built-in English formats.
+func (l *Logger) SetLocale(locale Locale) {
+	ws := getWriterState(l.out)
+	ws.lock()
+	defer ws.unlock()
+	l.customized = true
+	l.locale = locale
+}
+
+func SetLocale(locale Locale) { DefaultLogger.SetLocale(locale) }
+
+// A SimpleLocale is a Locale configured with a date layout and translations.
+// Empty fields fall back to the built-in English formats.
+type SimpleLocale struct {
+	// DateLayout is a time.Format layout, e.g. "02.01.2006" or "2 Jan 2006".
+	// Month and day names in it are taken from the fields below.
+	DateLayout      string
+	MonthNames      [12]string // January, February, ...
+	ShortMonthNames [12]string // Jan, Feb, ...
+	DayNames        [7]string  // Sunday, Monday, ...
+	ShortDayNames   [7]string  // Sun, Mon, ...
+	Decimal         string
+	LevelNames      map[Level]string
+}
+
+// Placeholders for names in layouts, which time.Format passes through.
+const (
+	placeholderMonth      = "\x01"
+	placeholderShortMonth = "\x02"
+	placeholderDay        = "\x03"
+	placeholderShortDay   = "\x04"
+)
+
+func (sl *SimpleLocale) FormatDate(t time.Time) string {
+	if sl.DateLayout == "" {
+		return t.Format("2006/01/02")
+	}
+	layout := strings.NewReplacer(
+		"January", placeholderMonth, "Jan", placeholderShortMonth,
+		"Monday", placeholderDay, "Mon", placeholderShortDay,
+	).Replace(sl.DateLayout)
+	month, day := int(t.Month())-1, int(t.Weekday())
+	return strings.NewReplacer(
+		placeholderMonth, orDefault(sl.MonthNames[month], t.Month().String()),
+		placeholderShortMonth, orDefault(sl.ShortMonthNames[month], t.Month().String()[:3]),
+		placeholderDay, orDefault(sl.DayNames[day], t.Weekday().String()),
+		placeholderShortDay, orDefault(sl.ShortDayNames[day], t.Weekday().String()[:3]),
+	).Replace(t.Format(layout))
+}
+
+func (sl *SimpleLocale) DecimalSeparator() string { return orDefault(sl.Decimal, ".") }
+
+func (sl *SimpleLocale) LevelName(level Level) string {
+	return orDefault(sl.LevelNames[level], level.String())
+}
+
+func orDefault(s, fallback string) string {
+	if s == "" {
+		return fallback
+	}
+	return s
+}
+
+// localizeDecimal replaces the decimal point in number, which was just
+// appended to buf starting at start, with the locale's separator.
+func (l *Logger) localizeDecimal(buf *[]byte, start int) {
+	if l.locale == nil {
+		return
+	}
+	if sep := l.locale.DecimalSeparator(); sep != "." {
+		if i := bytes.IndexByte((*buf)[start:], '.'); i >= 0 {
+			i += start
+			*buf = append((*buf)[:i], append([]byte(sep), (*buf)[i+1:]...)...)
+		}
+	}
+}
+
+// appendLevel appends the name of the current line's level.
+func (l *Logger) appendLevel(buf *[]byte) {
+	if l.locale != nil {
+		*buf = append(*buf, l.locale.LevelName(l.lineLevel)...)
+	} else {
+		*buf = append(*buf, l.lineLevel.String()...)
+	}
+}
@@ -0,0 +1,37 @@
+package alog
+
+import (
+	"bytes"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSimpleLocale(t *testing.T) {
+	assert := assert.New(t)
+	de := &SimpleLocale{
+		DateLayout:    "Mon, 2. January 2006",
+		MonthNames:    [12]string{"Januar", "Februar", "März"},
+		ShortDayNames: [7]string{"So", "Mo", "Di", "Mi", "Do", "Fr", "Sa"},
+		Decimal:       ",",
+		LevelNames:    map[Level]string{LevelWarn: "Warnung"},
+	}
+	date := time.Date(2020, 3, 4, 5, 6, 7, 0, time.UTC)
+	assert.Equal("Mi, 4. März 2020", de.FormatDate(date))
+	assert.Equal("Wed, 4. March 2020", (&SimpleLocale{DateLayout: "Mon, 2. January 2006"}).FormatDate(date))
+	assert.Equal("Warnung", de.LevelName(LevelWarn))
+	assert.Equal("error", de.LevelName(LevelError))
+
+	var buf bytes.Buffer
+	var writer = New(&buf, "{date} {level} {elapsed} ", 0)
+	defer writer.Close()
+	writer.DisableColor()
+	writer.EnableColorTemplate()
+	writer.SetClock(func() time.Time { return date })
+	writer.SetLocale(de)
+	writer.Print("working")
+	date = date.Add(1500 * time.Millisecond)
+	writer.Warnf(" done\n")
+	assert.Contains(buf.String(), "\rMi, 4. März 2020 Warnung 1,50s working done\n")
+}
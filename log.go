@@ -22,6 +22,7 @@ package alog
 
 import (
 	"bytes"
+	"context"
 	"errors"
 	"fmt"
 	"io"
@@ -29,13 +30,13 @@ import (
 	"os/exec"
 	"regexp"
 	"runtime"
+	"sort"
 	"strconv"
 	"strings"
 	"sync"
-	"syscall"
+	"sync/atomic"
 	"time"
 	"unicode/utf8"
-	"unsafe"
 )
 
 // These flags define which text to prefix to each log entry generated by the Logger.
@@ -100,6 +101,9 @@ func (code ColorCode) GetAnsiCodes() []int {
 	return codes
 }
 
+// ansiColorCodes is guarded by ansiColorCodesMutex, since palettes may change
+// it while Loggers are formatting; use lookupColorCode and setColorCode.
+var ansiColorCodesMutex sync.RWMutex
 var ansiColorCodes = map[string]ColorCode{
 	"r":       ColorResetAll,
 	"reset":   ColorResetAll,
@@ -122,33 +126,92 @@ var ansiColorCodes = map[string]ColorCode{
 }
 
 var tputCache = make(map[string]string)
+var tputMutex sync.Mutex
+var tputDisabled bool
+
+// SetTputEnabled sets whether tput may be run to look up the escape sequences
+// for moving the cursor in multiline mode. When it may not, or it fails,
+// standard ANSI escapes are used instead. Disable it where running another
+// program isn't allowed, e.g. under a seccomp profile that kills the process
+// for it.
+func SetTputEnabled(flag bool) {
+	tputMutex.Lock()
+	defer tputMutex.Unlock()
+	tputDisabled = !flag
+	tputCache = make(map[string]string)
+}
+func EnableTput()  { SetTputEnabled(true) }
+func DisableTput() { SetTputEnabled(false) }
 
 func tput(strs ...string) string {
+	tputMutex.Lock()
+	defer tputMutex.Unlock()
 	key := strings.Join(strs, "-")
 	val, ok := tputCache[key]
 	if !ok {
-		cmd := exec.Command("tput", strs...)
-		out, err := cmd.Output()
-		if err != nil {
-			msg := fmt.Sprintf("\nFailed to execute `tput %s`. That probably means you need to disable MultilineMode.\n", strings.Join(strs, " "))
-			os.Stderr.WriteString(msg)
-			return ""
+		val = builtinTput(strs...)
+		if !tputDisabled {
+			if out, err := exec.Command("tput", strs...).Output(); err == nil {
+				val = string(out)
+			}
 		}
-		val = string(out)
 		tputCache[key] = val
 	}
 	return val
 }
 
+// builtinTput returns the ANSI escape for the capabilities alog asks tput
+// for.
+func builtinTput(strs ...string) string {
+	if len(strs) == 2 {
+		switch strs[0] {
+		case "cuu":
+			return "\033[" + strs[1] + "A"
+		case "cud":
+			return "\033[" + strs[1] + "B"
+		}
+	}
+	return ""
+}
+
 type WriterState struct {
 	mutex           sync.Mutex
-	lastTemp        [][]byte
+	out             io.Writer
+	pending         []byte     // output accumulated while the lock is held
+	queue           writeQueue // output waiting to be written to out
+	recorder        *castRecorder
+	lastTemp        tempLines
 	tempLoggers     []*Logger
+	dedupLoggers    []*Logger // loggers with open dedup windows, flushed on exit
+	sinkLoggers     []*Logger // loggers with sinks, whose FlushingSinks are flushed on exit
 	termWidth       int
+	termHeight      int
+	maxTempLines    int
+	eviction        EvictionPolicy
+	finished        []*finishedLine // completed lines shown with the partial lines until they collapse
+	refreshStop     chan struct{}
+	refreshInterval time.Duration         // set with SetRefreshInterval
+	refreshRunning  time.Duration         // interval of the running ticker, if any
+	refreshUsers    map[time.Duration]int // see addRefreshUser
+	segmentSep      []byte
+	namedSegments   []*StatusSegment
+	statusBarLines  int
+	statusBarHeight int // terminal height the scroll region was set up for
+	title           string
+	shownTitle      string
+	mirrorTitle     bool
+	taskbarProgress bool // whether a progress indicator is being shown
+	ci              CIProvider
+	routed          []routedLine // completed lines to deliver to other writers after unlocking
 	multiline       bool
 	cursorLineIndex int
 	cursorIsInline  bool
 	cursorIsAtBegin bool
+	cursorMoves     int            // number of times the cursor has been moved between lines
+	quirks          TerminalQuirks // see SetTerminalQuirks
+	paused          bool           // see Pause
+	held            []byte         // output held back while paused
+	suspended       bool           // paused because the process was stopped
 }
 
 func (w *WriterState) removeTempLogger(l *Logger) {
@@ -165,22 +228,96 @@ func (w *WriterState) removeTempLogger(l *Logger) {
 	}
 }
 
-func (w *WriterState) lock()   { w.mutex.Lock() }
-func (w *WriterState) unlock() { w.mutex.Unlock() }
+func (w *WriterState) lock() {
+	w.mutex.Lock()
+	if dropped := w.queue.takeDropped(); dropped > 0 {
+		w.writeDroppedNotice(dropped)
+	}
+}
+
+// writeDroppedNotice reports output dropped by the overflow policy. As dropped
+// output may have included cursor movement, forget what we think is on screen
+// and start over on a fresh line.
+func (w *WriterState) writeDroppedNotice(dropped int) {
+	if !w.cursorIsAtBegin || w.lastTemp.len() > 1 {
+		w.write(bytesNewline)
+	}
+	w.cursorLineIndex = 0
+	w.lastTemp.reset()
+	w.cursorIsAtBegin = true
+	w.cursorIsInline = false
+	w.write([]byte(fmt.Sprintf("[alog: dropped %d writes while output was blocked]\n", dropped)))
+	updateTempOutput(w.out)
+}
+
+// unlock releases the writer lock, then writes out any output produced while
+// it was held. Output is queued before the lock is released so that it stays in
+// order, but written after, so that a slow Writer only holds up the goroutine
+// doing the writing rather than everyone formatting output for this writer.
+func (w *WriterState) unlock() {
+	w.enqueuePending()
+	routed := w.routed
+	w.routed = nil
+	w.mutex.Unlock()
+	w.queue.drain(w.out)
+	deliverRoutedLines(routed)
+}
+
+// write buffers p to be written once the lock is released. Must be called with
+// the writer lock held.
+func (w *WriterState) write(p []byte) {
+	w.pending = append(w.pending, p...)
+}
+
+func (w *WriterState) enqueuePending() {
+	if w.paused {
+		w.held = append(w.held, w.pending...)
+		w.pending = w.pending[:0]
+		return
+	}
+	if len(w.pending) > 0 {
+		if w.recorder != nil {
+			w.recorder.record(w.pending)
+		}
+		w.queue.push(w.pending)
+		w.pending = w.pending[:0]
+		if cap(w.pending) > maxRecycledChunkSize {
+			w.pending = nil
+		}
+	}
+}
+
+// flushLocked writes out all pending output without releasing the writer lock,
+// and waits for it to have been written.
+func (w *WriterState) flushLocked() {
+	w.enqueuePending()
+	w.queue.flush(w.out)
+}
 
 func (w *WriterState) addTempLogger(l *Logger) {
 	w.tempLoggers = append(w.tempLoggers, l)
 }
 
 func (w *WriterState) flushAll() {
-	for _, logger := range w.tempLoggers {
+	// Flushing a logger removes it from tempLoggers.
+	loggers := append([]*Logger(nil), w.tempLoggers...)
+	for _, logger := range loggers {
 		logger.flushInt()
 	}
 }
 
 func (w *WriterState) closeAll() {
-	for _, logger := range w.tempLoggers {
+	w.flushFinishedLines(nil)
+	// Flushing a logger removes it from tempLoggers.
+	loggers := append([]*Logger(nil), w.tempLoggers...)
+	for _, logger := range loggers {
 		logger.flushInt()
+	}
+	// Flushing dedup windows removes the logger from dedupLoggers.
+	for _, logger := range append([]*Logger(nil), w.dedupLoggers...) {
+		logger.flushDedupInt()
+	}
+	for _, logger := range loggers {
 		logger.closeInt()
 	}
 }
@@ -190,13 +327,21 @@ func getWriterState(writer io.Writer) *WriterState {
 	ws, ok := writers[writer]
 	mutexGlobal.RUnlock()
 	if !ok {
+		shared := sharedWriterState(writer)
 		mutexGlobal.Lock()
 		ws, ok = writers[writer]
 		if !ok {
-			ws = &WriterState{}
-			ws.cursorIsAtBegin = true
-			ws.cursorIsInline = false
-			ws.lastTemp = [][]byte{[]byte{}}
+			if shared != nil {
+				ws = shared
+			} else {
+				ws = &WriterState{}
+				ws.out = writer
+				ws.queue.hideTempOnBrokenPipe = true
+				ws.cursorIsAtBegin = true
+				ws.cursorIsInline = false
+				ws.lastTemp.reset()
+				ws.setQuirks(defaultTerminalQuirks(writer))
+			}
 			writers[writer] = ws
 		}
 		mutexGlobal.Unlock()
@@ -204,11 +349,69 @@ func getWriterState(writer io.Writer) *WriterState {
 	return ws
 }
 
+// sharedWriterState returns the WriterState of another writer that writer
+// should share because they are the same terminal, e.g. stdout and stderr
+// when neither is redirected, so that partial lines written to each don't
+// draw over each other, and completed lines written to one interleave
+// correctly with partial lines written to the other. All output is then
+// written to the first of them to have been used, which on the same terminal
+// makes no difference.
+func sharedWriterState(writer io.Writer) *WriterState {
+	file, ok := writer.(*os.File)
+	if !ok || !isSharedTerminal(file) {
+		return nil
+	}
+	mutexGlobal.RLock()
+	defer mutexGlobal.RUnlock()
+	for other, ws := range writers {
+		if otherFile, ok := other.(*os.File); ok && otherFile != file && sameTerminal(file, otherFile) {
+			return ws
+		}
+	}
+	return nil
+}
+
+// isSharedTerminal is the terminal check used when looking for writers on
+// the same terminal; tests replace it, as they may not have a terminal.
+var isSharedTerminal = isTerminal
+
+// isTerminalWriter reports whether out is a terminal, for escape sequences
+// that only make sense on one; tests replace it too.
+var isTerminalWriter = func(out io.Writer) bool {
+	file, ok := out.(*os.File)
+	return ok && isTerminal(file)
+}
+
+// sameTerminal reports whether a and b are both the same terminal.
+func sameTerminal(a, b *os.File) bool {
+	if !isSharedTerminal(a) || !isSharedTerminal(b) {
+		return false
+	}
+	statA, errA := a.Stat()
+	statB, errB := b.Stat()
+	return errA == nil && errB == nil && os.SameFile(statA, statB)
+}
+
 // ensures atomic writes; shared by all Logger instances
 var mutexGlobal sync.RWMutex
 
 var writers map[io.Writer]*WriterState = make(map[io.Writer]*WriterState)
 
+// uniqueWriterStates returns every WriterState in writers once, even those
+// shared by several writers, so that each can be locked in turn. Must be
+// called with mutexGlobal held.
+func uniqueWriterStates() []*WriterState {
+	seen := make(map[*WriterState]bool, len(writers))
+	var states []*WriterState
+	for _, ws := range writers {
+		if !seen[ws] {
+			seen[ws] = true
+			states = append(states, ws)
+		}
+	}
+	return states
+}
+
 const ansiCodeResetAll = 0
 const ansiCodeHighestIntensity = 2
 const ansiCodeResetForecolor = 39
@@ -218,10 +421,16 @@ var bytesCarriageReturn = []byte("\r")
 var byteNewline = byte('\n')
 var bytesNewline = []byte{byteNewline}
 var bytesSpace = []byte(" ")
+var bytesTab = []byte("\t")
+var bytesTabSpaces = []byte("        ")
 
 var bytesComma = []byte(",")
 var ansiColorRegexp = regexp.MustCompile("\033\\[(\\d+)m")
-var ansiColorOrCharRegexp = regexp.MustCompile("(\033\\[\\d+m)|.")
+var ansiColorPrefixRegexp = regexp.MustCompile("^\033\\[\\d+m")
+
+// ansiEscapeRegexp matches any CSI or OSC escape sequence, including the ones
+// used to move the cursor and clear lines when redrawing temporary lines.
+var ansiEscapeRegexp = regexp.MustCompile("\033(\\[[0-9;?]*[ -/]*[@-~]|\\][^\007\033]*(\007|\033\\\\))")
 var ansiBytesEscapeStart = []byte("\033[")
 var ansiBytesColorEscapeEnd = []byte("m")
 var ansiBytesResetAll = []byte("\033[0m")
@@ -234,17 +443,6 @@ var tempLineEllipsisLength = stringLen(tempLineEllipsis)
 
 const minTempSegmentLength = 6
 
-// These facilitate "nullable" bools for some settings
-var yes = true
-var no = false
-
-func boolPointer(flag bool) *bool {
-	if flag {
-		return &yes
-	}
-	return &no
-}
-
 type ActiveAnsiCodes struct {
 	intensity int
 	forecolor int
@@ -277,6 +475,18 @@ func (codes *ActiveAnsiCodes) getResetBytes() []byte {
 	return bytesEmpty
 }
 
+// getRestoreBytes returns the escapes that turn the active codes back on.
+func (codes *ActiveAnsiCodes) getRestoreBytes() []byte {
+	var buf []byte
+	if codes.intensity != 0 {
+		buf = append(buf, ansiEscapeBytes(codes.intensity)...)
+	}
+	if codes.forecolor != 0 {
+		buf = append(buf, ansiEscapeBytes(codes.forecolor)...)
+	}
+	return buf
+}
+
 func getActiveAnsiCodes(buf []byte) *ActiveAnsiCodes {
 	var ansiActive ActiveAnsiCodes
 	for _, groups := range ansiColorRegexp.FindAllSubmatch(buf, -1) {
@@ -288,6 +498,12 @@ func getActiveAnsiCodes(buf []byte) *ActiveAnsiCodes {
 
 // GetSize returns the dimensions of the given terminal.
 func getTermWidth(writer io.Writer) int {
+	// A width set for this writer, e.g. by BindPTY for a remote terminal,
+	// takes precedence over our own environment.
+	ws := getWriterState(writer)
+	if ws.termWidth != 0 {
+		return ws.termWidth
+	}
 	envColumns := os.Getenv("COLUMNS")
 	if envColumns != "" {
 		num, _ := strconv.Atoi(envColumns)
@@ -295,25 +511,30 @@ func getTermWidth(writer io.Writer) int {
 			return num
 		}
 	}
-	ws := getWriterState(writer)
-	if ws.termWidth != 0 {
-		return ws.termWidth
-	}
-	var fd int
-	if writer == os.Stdout {
-		fd = syscall.Stdout
-	} else {
-		// For custom writers, just use the width we get for stderr. This might not be true in some
-		// cases (and for those cases, we should add an option to explicitly set width), but it will
-		// be true in most cases.
-		fd = syscall.Stderr
+	if width, ok := probeWidth(writer); ok {
+		return width
 	}
-	var dimensions [4]uint16
-	if _, _, err := syscall.Syscall6(syscall.SYS_IOCTL, uintptr(fd), uintptr(syscall.TIOCGWINSZ), uintptr(unsafe.Pointer(&dimensions)), 0, 0, 0); err != 0 {
+	_, width, ok := getWinsize(writer)
+	if !ok || width == 0 {
 		// Fall back to a width of 80
 		return 80
 	}
-	return int(dimensions[1])
+	return width
+}
+
+// getTermHeight returns the number of rows of the given terminal.
+func getTermHeight(writer io.Writer) int {
+	if ws := getWriterState(writer); ws.termHeight != 0 {
+		return ws.termHeight
+	}
+	if num, _ := strconv.Atoi(os.Getenv("LINES")); num > 0 {
+		return num
+	}
+	height, _, ok := getWinsize(writer)
+	if !ok || height == 0 {
+		return 24
+	}
+	return height
 }
 
 // A Logger represents an active logging object that generates lines of
@@ -327,19 +548,80 @@ type Logger struct {
 	buf                  []byte    // for accumulating text to write
 	tmp                  []byte    // for formatting the current line
 	prefixFormatted      []byte
+	suffix               []byte // template written at the right edge of completed lines
+	suffixFormatted      []byte
+	prefixFunc           func(e *Entry) string
 	cursorByteIndex      int
 	tempLineActive       bool
 	isClosed             bool
-	partialLinesEnabled  *bool
-	colorEnabled         *bool
-	colorTemplateEnabled *bool
-	autoAppendNewline    *bool
-	colorRegexp          *regexp.Regexp
+	partialLinesEnabled  triState
+	colorEnabled         triState
+	colorTemplateEnabled triState
+	autoAppendNewline    triState
+	colorRegexp          regexpSetting
 	termWidth            int
 	callerFile           string
 	callerLine           int
+	callerPath           string // full path of callerFile, for source snippets
+	trimCallerPaths      bool
+	callerPathPrefixes   []string
+	snippetsEnabled      bool
+	snippetContext       int // lines of context around source snippets
 	now                  time.Time
+	clock                clockSetting
+	redactors            []redactor
+	filters              []LineFilter
+	highlights           []highlight
+	lineLengthPolicy     LineLengthPolicy
+	maxLineWidth         int
+	continuationPrefix   []byte
+	blockMode            bool
+	maxPartialLineBytes  int
+	sanitizeEnabled      bool
+	heldBytes            []byte // incomplete UTF-8 sequence at the end of the last Write
+	collapseAfter        time.Duration
+	collapseDemote       bool
+	lastOutputTime       time.Time
+	stallAfter           time.Duration
+	stallStyle           []byte
+	stallRefresh         bool // whether the stall warning keeps partial lines redrawn
+	tempRenderer         func(state LineState, width int) []byte
+	segmentWeight        float64
+	segmentOrder         int
 	lineStartTime        time.Time
+	lineUpdates          int // number of writes to the current partial line, for {count} and {rate}
+	dedupWindow          time.Duration
+	dedupEntries         map[string]*dedupEntry
+	callLevel            Level // level of the Print call in progress
+	minLevel             Level // see SetMinLevel
+	severityDetection    bool
+	severityRules        []severityRule
+	lineLevel            Level // highest level contributing to the current line
+	stats                Stats
+	sinks                []EntrySink
+	callCtx              context.Context // context of the Print call in progress
+	callFields           []Field         // fields extracted from callCtx
+	callPrefix           []byte          // formatted prefix of the call in progress, in place of the Logger's (see WithPrefix)
+	lineCtx              context.Context // context of the current line
+	lineFields           []Field         // fields of the current line
+	callVerbatim         bool            // whether the call in progress writes foreign text (see Ingest)
+	lineVerbatim         bool            // whether the current line has foreign text, whose alignment templates are left alone
+	routes               []route
+	monochromeSymbols    triState
+	disabled             atomic.Bool // see Disable
+	verbosity            intSetting
+	unicodeEnabled       triState
+	levelIcons           map[Level]string
+	locale               Locale
+	location             *time.Location
+	history              *History
+	reprintingHistory    bool // see ReprintHistory
+	jsonOutput           bool // see SetJSONOutput
+	ownPrefix            bool // set by SetPrefix, so Stdout and Stderr stop following DefaultLogger
+	ownFlags             bool // likewise for SetFlags
+	closeFuncs           []func() error
+	throttled            map[string]time.Time // last output time by Once/Every key
+	customized           bool                 // set by every setter; Msg only takes its fast path while false
 }
 
 type LoggerInt interface {
@@ -399,45 +681,48 @@ func New(out io.Writer, prefix string, flag int) *Logger {
 // reprocessPrefix here (as it creates a circular reference back to DefaultLogger)
 func newStd() *Logger {
 	var l = &Logger{out: os.Stderr, prefix: []byte("@(dim:{isodate}) "), flag: 0}
-	l.partialLinesEnabled = &yes
-	l.colorRegexp = regexp.MustCompile("@\\(([\\w,]+?)(:([^)]*?))?\\)")
-	l.colorEnabled = &yes
-	l.colorTemplateEnabled = &yes
-	l.autoAppendNewline = &no
+	l.partialLinesEnabled.set(true)
+	l.colorRegexp.set(regexp.MustCompile("@\\(([\\w,]+?)(:([^)]*?))?\\)"))
+	l.colorEnabled.set(true)
+	l.colorTemplateEnabled.set(true)
+	l.autoAppendNewline.set(false)
+	l.unicodeEnabled.set(detectUnicode())
 	// This is like calling reprocessPrefix:
-	l.prefixFormatted = processColorTemplates(l.colorRegexp, l.prefix)
+	l.prefixFormatted = processColorTemplates(l.colorRegexp.get(), l.prefix)
 	return l
 }
 
 var DefaultLogger = newStd()
 
-func isTrueDefaulted(flag *bool, fallback *bool) bool {
-	if flag != nil {
-		return *flag
-	}
-	return *fallback
-}
+// Stdout and Stderr are Loggers writing to standard output and standard
+// error, with the prefix and flags of DefaultLogger (following changes to
+// them) and otherwise its settings unless set on them. When both streams are the same terminal,
+// they share partial lines rather than drawing over each other.
+var (
+	Stdout = New(os.Stdout, string(DefaultLogger.prefix), DefaultLogger.flag)
+	Stderr = New(os.Stderr, string(DefaultLogger.prefix), DefaultLogger.flag)
+)
 
 func (l *Logger) isColorEnabled() bool {
-	return isTrueDefaulted(l.colorEnabled, DefaultLogger.colorEnabled)
+	return isTrueDefaulted(&l.colorEnabled, &DefaultLogger.colorEnabled)
 }
 
 func (l *Logger) isPartialLinesEnabled() bool {
-	return isTrueDefaulted(l.partialLinesEnabled, DefaultLogger.partialLinesEnabled)
+	return isTrueDefaulted(&l.partialLinesEnabled, &DefaultLogger.partialLinesEnabled)
 }
 
 func (l *Logger) isAutoNewlineEnabled() bool {
-	return isTrueDefaulted(l.autoAppendNewline, DefaultLogger.autoAppendNewline)
+	return isTrueDefaulted(&l.autoAppendNewline, &DefaultLogger.autoAppendNewline)
 }
 
 func (l *Logger) getColorTemplateRegexp() *regexp.Regexp {
-	if !isTrueDefaulted(l.colorTemplateEnabled, DefaultLogger.colorTemplateEnabled) {
+	if !isTrueDefaulted(&l.colorTemplateEnabled, &DefaultLogger.colorTemplateEnabled) {
 		return nil
 	}
-	if l.colorRegexp != nil {
-		return l.colorRegexp
+	if rgx := l.colorRegexp.get(); rgx != nil {
+		return rgx
 	}
-	return DefaultLogger.colorRegexp
+	return DefaultLogger.colorRegexp.get()
 }
 
 // SetOutput sets the output destination for the logger.
@@ -447,6 +732,7 @@ func (l *Logger) SetOutput(w io.Writer) {
 	ws := getWriterState(l.out)
 	ws.lock()
 	defer ws.unlock()
+	l.customized = true
 	l.flushInt()
 	l.out = w
 }
@@ -469,6 +755,10 @@ func itoa(buf *[]byte, i int, wid int) {
 }
 
 func (l *Logger) appendDate(buf *[]byte, useIsoDate bool) {
+	if l.locale != nil && !useIsoDate {
+		*buf = append(*buf, l.locale.FormatDate(l.now)...)
+		return
+	}
 	dateSep := "/"
 	if useIsoDate {
 		dateSep = "-"
@@ -502,16 +792,59 @@ func (l *Logger) appendIsoDate(buf *[]byte, includeMicros bool) {
 
 func (l *Logger) appendElapsed(buf *[]byte) {
 	if !l.lineStartTime.IsZero() && l.now != l.lineStartTime {
+		start := len(*buf)
 		*buf = append(*buf, FormatDuration(l.now.Sub(l.lineStartTime))...)
+		l.localizeDecimal(buf, start)
 	} else {
 		*buf = append(*buf, '-')
 	}
 }
 
-var prefixTemplateRegexp = regexp.MustCompile("{(date|time|isodate|elapsed)( micros)?}|.+?")
+// appendCount appends the number of updates to the current partial line.
+func (l *Logger) appendCount(buf *[]byte) {
+	*buf = strconv.AppendInt(*buf, int64(l.lineUpdates), 10)
+}
+
+// appendRate appends the rate of updates to the current partial line per
+// second, e.g. "12.5/s".
+func (l *Logger) appendRate(buf *[]byte) {
+	if l.lineStartTime.IsZero() || !l.now.After(l.lineStartTime) {
+		*buf = append(*buf, '-')
+		return
+	}
+	rate := float64(l.lineUpdates) / l.now.Sub(l.lineStartTime).Seconds()
+	start := len(*buf)
+	*buf = strconv.AppendFloat(*buf, rate, 'f', 1, 64)
+	l.localizeDecimal(buf, start)
+	*buf = append(*buf, "/s"...)
+}
+
+// Templates available in the prefix. {count} and {rate} are the number of
+// writes to the current partial line (e.g. calls to Replace in a progress
+// loop) and how many of them there have been per second. {level} is the
+// name of the line's level, as given by the Logger's Locale if it has one.
+// {zone} is the abbreviation of the time zone, e.g. "CET".
+var prefixTemplateRegexp = regexp.MustCompile("{(date|time|isodate|elapsed|count|rate|level|zone)( micros)?}|.+?")
+
+// formatHeader appends the header for line to buf.
+func (l *Logger) formatHeader(buf *[]byte, line []byte) {
+	if l.callPrefix != nil {
+		l.expandTemplate(buf, l.callPrefix)
+	} else if l.prefixFunc != nil {
+		entry := Entry{Time: l.now, Start: l.now, Level: l.lineLevel, Message: string(line), Context: l.lineCtx, Fields: l.lineFields}
+		if !l.lineStartTime.IsZero() {
+			entry.Start = l.lineStartTime
+		}
+		l.expandTemplate(buf, []byte(l.applyColorTemplates(l.prefixFunc(&entry))))
+	} else {
+		l.expandTemplate(buf, l.prefixFormatted)
+	}
+	l.formatFlagsHeader(buf)
+}
 
-func (l *Logger) formatHeader(buf *[]byte) {
-	for _, groups := range prefixTemplateRegexp.FindAllSubmatch(l.prefixFormatted, -1) {
+// expandTemplate appends tmpl to buf, replacing {date}, {time} and the like.
+func (l *Logger) expandTemplate(buf *[]byte, tmpl []byte) {
+	for _, groups := range prefixTemplateRegexp.FindAllSubmatch(tmpl, -1) {
 		if len(groups[1]) != 0 {
 			s := string(groups[1])
 			includeMicros := len(groups[2]) > 0
@@ -523,12 +856,23 @@ func (l *Logger) formatHeader(buf *[]byte) {
 				l.appendIsoDate(buf, includeMicros)
 			} else if s == "elapsed" {
 				l.appendElapsed(buf)
+			} else if s == "count" {
+				l.appendCount(buf)
+			} else if s == "rate" {
+				l.appendRate(buf)
+			} else if s == "level" {
+				l.appendLevel(buf)
+			} else if s == "zone" {
+				*buf = l.now.AppendFormat(*buf, "MST")
 			}
 		} else {
 			*buf = append(*buf, groups[0]...)
 		}
 	}
+}
 
+// formatFlagsHeader appends the parts of the header controlled by l.flag.
+func (l *Logger) formatFlagsHeader(buf *[]byte) {
 	if l.flag&Lisodate != 0 {
 		l.appendIsoDate(buf, l.flag&Lmicroseconds != 0)
 		*buf = append(*buf, ' ')
@@ -571,7 +915,8 @@ func moveCursorToLine(out io.Writer, line int) bool {
 		}
 	}
 	tmp = append(tmp, bytesCarriageReturn...)
-	out.Write(tmp)
+	ws.write(tmp)
+	ws.cursorMoves++
 	ws.cursorLineIndex = line
 	ws.cursorIsAtBegin = true
 	ws.cursorIsInline = false
@@ -579,9 +924,17 @@ func moveCursorToLine(out io.Writer, line int) bool {
 }
 
 func setTempLineOutput(out io.Writer, line int, buf []byte) {
+	drawTempLine(out, line, buf, true)
+}
+
+// drawTempLine replaces what's shown on the given partial line with buf. If
+// diff is set and it's shorter, only the cells that changed are redrawn;
+// lines being completed are always written in full, so that they read
+// correctly wherever the output ends up.
+func drawTempLine(out io.Writer, line int, buf []byte, diff bool) {
 	ws := getWriterState(out)
 	cursorIsOnlineAndInline := ws.cursorLineIndex == line && ws.cursorIsInline
-	lastBuf := ws.lastTemp[line]
+	lastBuf := ws.lastTemp.get(line)
 	// These lengths are actually fine being in bytes
 	lastLen := len(lastBuf)
 	currLen := len(buf)
@@ -589,96 +942,183 @@ func setTempLineOutput(out io.Writer, line int, buf []byte) {
 		// Don't need to do anything
 		return
 	} else if cursorIsOnlineAndInline && (currLen >= lastLen && bytes.Equal(lastBuf, buf[:lastLen])) {
-		out.Write(buf[lastLen:])
+		ws.write(buf[lastLen:])
+	} else if diff && ws.writeCellDiff(line, lastBuf, buf) {
+		// Only the changed cells were redrawn.
 	} else {
-		out.Write(getActiveAnsiCodes(lastBuf).getResetBytes())
+		ws.write(getActiveAnsiCodes(lastBuf).getResetBytes())
 		if !moveCursorToLine(out, line) && !ws.cursorIsAtBegin {
-			out.Write(bytesCarriageReturn)
+			ws.write(bytesCarriageReturn)
 		}
-		out.Write(buf)
+		ws.write(buf)
 		currStringLen := stringLen(buf)
 		lastStringLen := stringLen(lastBuf)
 		for i := currStringLen; i < lastStringLen; i++ {
-			out.Write(bytesSpace)
+			ws.write(bytesSpace)
 		}
 		ws.cursorIsInline = currStringLen >= lastStringLen
 	}
 	ws.cursorIsAtBegin = false
 	// This does a lot of copying to avoid aliasing; maybe some could be avoided?
-	ws.lastTemp[line] = append([]byte{}, buf...)
+	ws.lastTemp.set(line, buf)
 }
 
 func writeLine(out io.Writer, buf []byte) {
-	setTempLineOutput(out, 0, buf)
-	out.Write(getActiveAnsiCodes(buf).getResetBytes())
 	ws := getWriterState(out)
+	drawTempLine(out, 0, buf, false)
+	ws.write(getActiveAnsiCodes(buf).getResetBytes())
 	if ws.multiline {
-		ws.lastTemp = ws.lastTemp[1:]
 		// Always keep an empty line at the bottom
-		if len(ws.lastTemp) == 0 {
-			ws.lastTemp = append(ws.lastTemp, []byte{})
+		if ws.lastTemp.shift() {
 			moveCursorToLine(out, 0)
-			out.Write(bytesNewline)
+			ws.write(bytesNewline)
 		} else {
 			ws.cursorLineIndex = -1
 			moveCursorToLine(out, 0)
 		}
 	} else {
-		out.Write(bytesNewline)
-		ws.lastTemp[0] = bytesEmpty
+		ws.write(bytesNewline)
+		ws.lastTemp.set(0, bytesEmpty)
 		ws.cursorIsAtBegin = true
 		ws.cursorIsInline = false
 	}
 }
 
+var (
+	bytesHideCursor = []byte("\033[?25l")
+	bytesShowCursor = []byte("\033[?25h")
+)
+
+// hideCursorSince hides the cursor for the output written since pending was
+// start bytes long, so that it doesn't visibly jump around during a redraw
+// that takes several steps. The cursor is shown again at the end of the same
+// chunk of output, so it can't be left hidden. Must be called with the writer
+// lock held.
+func (w *WriterState) hideCursorSince(start int) {
+	if len(w.pending) == start {
+		return
+	}
+	begin, end := bytesHideCursor, bytesShowCursor
+	if w.quirks&SynchronizedOutput != 0 {
+		begin = append(append([]byte{}, w.syncEscape(bytesBeginSync)...), begin...)
+		end = append(append([]byte{}, end...), w.syncEscape(bytesEndSync)...)
+	}
+	redraw := append(append([]byte{}, begin...), w.pending[start:]...)
+	w.pending = append(append(w.pending[:start], redraw...), end...)
+}
+
 func updateTempOutput(out io.Writer) {
 	ws := getWriterState(out)
+	if ws.queue.tempLinesSuspended() || ws.ci != NoCI || ws.paused {
+		return
+	}
 	maxWidth := getTermWidth(out) - 1
-	var bufs [][]byte
+	sep := ws.getSegmentSeparator()
+	sepLength := stringLen(sep)
+	// Renderers get an even share of the width in singleline mode.
+	budget := maxWidth
+	if numLines := len(ws.finished) + len(ws.tempLoggers); !ws.multiline && numLines > 1 {
+		budget = (maxWidth - sepLength*(numLines-1)) / numLines
+	}
+	var segments []tempSegment
+	for _, finished := range ws.finished {
+		segments = append(segments, tempSegment{buf: finished.buf, weight: 1})
+	}
 	for _, logger := range ws.tempLoggers {
-		bufs = append(bufs, logger.getFormattedLine(logger.buf))
+		var buf []byte
+		if logger.tempRenderer != nil {
+			buf = logger.renderTempLine(budget)
+		} else {
+			buf = logger.getFormattedLine(logger.highlight(logger.redact(logger.buf)))
+			if logger.getColorTemplateRegexp() != nil && !logger.lineVerbatim {
+				buf = removeAlignTemplates(buf)
+			}
+		}
+		segments = append(segments, tempSegment{logger.appendStallWarning(buf), logger.getSegmentWeight(), logger.segmentOrder})
+	}
+	for _, named := range ws.namedSegments {
+		if named.hidden {
+			continue
+		}
+		if segment := named.tempSegment(); len(segment.buf) > 0 {
+			segments = append(segments, segment)
+		}
 	}
+	limit := ws.maxTempLines
 	if ws.multiline {
-		for i := len(ws.lastTemp); i < len(bufs); i++ {
+		// Scrolling the terminal would throw off our cursor movements, so
+		// never show more lines than fit.
+		if maxLines := getTermHeight(out) - 1; maxLines > 0 && (limit <= 0 || maxLines < limit) {
+			limit = maxLines
+		}
+	}
+	if ws.mirrorTitle {
+		ws.mirrorTitleFrom(segments)
+	}
+	if ws.statusBarLines > 0 {
+		start := len(ws.pending)
+		ws.drawStatusBar(segments, maxWidth, sep)
+		ws.hideCursorSince(start)
+		return
+	}
+	if limit > 0 && len(segments) > limit {
+		segments = ws.evictTempLines(segments, limit)
+	}
+	if ws.multiline {
+		start, moves := len(ws.pending), ws.cursorMoves
+		defer func() {
+			if ws.cursorMoves != moves {
+				ws.hideCursorSince(start)
+			}
+		}()
+		for i := ws.lastTemp.len(); i < len(segments); i++ {
 			moveCursorToLine(out, i-1)
-			out.Write(bytesNewline)
+			ws.write(bytesNewline)
 			ws.cursorLineIndex = i
 			ws.cursorIsAtBegin = true
 			ws.cursorIsInline = false
-			ws.lastTemp = append(ws.lastTemp, []byte{})
+			ws.lastTemp.add()
 		}
-		for i, buf := range bufs {
-			setTempLineOutput(out, i, trimStringEllipsis(buf, maxWidth))
+		for i, segment := range segments {
+			setTempLineOutput(out, i, trimStringEllipsis(segment.buf, maxWidth))
+		}
+		// Blank out lines that are no longer needed, e.g. collapsed finished lines.
+		for i := len(segments); i < ws.lastTemp.len(); i++ {
+			setTempLineOutput(out, i, bytesEmpty)
 		}
 	} else {
-		numBufs := len(bufs)
+		sort.SliceStable(segments, func(i, j int) bool { return segments[i].order < segments[j].order })
+		numBufs := len(segments)
 		lengths := make([]int, 0)
 		lengthSum := 0
-		for _, buf := range bufs {
-			length := stringLen(buf)
+		for _, segment := range segments {
+			length := stringLen(segment.buf)
 			lengths = append(lengths, length)
 			lengthSum += length
 		}
-		charsLeft := maxWidth - tempLineSepLength*(numBufs-1)
-		var outputBuf []byte
+		charsLeft := maxWidth - sepLength*(numBufs-1)
+		bufs := make([][]byte, numBufs)
+		for i, segment := range segments {
+			bufs[i] = segment.buf
+		}
 		if len(bufs) > 1 {
 			if charsLeft < lengthSum {
 				shortenedLengths := make([]int, numBufs)
 				copy(shortenedLengths, lengths)
 				for charsLeft < lengthSum {
-					longestIndex := 0
-					longestLength := 0
+					// Shorten whichever segment is longest relative to its weight.
+					longestIndex := -1
 					for i, length := range shortenedLengths {
-						if length > longestLength {
+						if length >= minTempSegmentLength && (longestIndex == -1 ||
+							float64(length)/segments[i].weight > float64(shortenedLengths[longestIndex])/segments[longestIndex].weight) {
 							longestIndex = i
-							longestLength = length
 						}
 					}
-					if longestLength < minTempSegmentLength {
+					if longestIndex == -1 {
 						// Don't bother making segments shorter than this
 						break
 					}
-					if longestLength == lengths[longestIndex] {
+					if shortenedLengths[longestIndex] == lengths[longestIndex] {
 						// It's at max length; we need to lop off space for the ellipsis
 						shortenedLengths[longestIndex] -= tempLineEllipsisLength + 1
 					} else {
@@ -686,17 +1126,14 @@ func updateTempOutput(out io.Writer) {
 					}
 					lengthSum -= 1
 				}
-				var bufs2 [][]byte
 				for i, buf := range bufs {
 					if shortenedLengths[i] < lengths[i] {
-						buf = append(trimString(buf, shortenedLengths[i]), tempLineEllipsis...)
+						bufs[i] = append(trimString(buf, shortenedLengths[i]), tempLineEllipsis...)
 					}
-					bufs2 = append(bufs2, buf)
 				}
-				bufs = bufs2
 			}
 		}
-		outputBuf = bytes.Join(bufs, tempLineSep)
+		outputBuf := bytes.Join(bufs, sep)
 		outputBuf = trimStringEllipsis(outputBuf, maxWidth)
 		setTempLineOutput(out, 0, outputBuf)
 	}
@@ -719,12 +1156,18 @@ func trimString(buf []byte, length int) []byte {
 		return bytesEmpty
 	}
 	tmp := []byte{}
-	for _, groups := range ansiColorOrCharRegexp.FindAllSubmatch(buf, -1) {
-		tmp = append(tmp, groups[0]...)
-		if len(groups[1]) == 0 {
-			// This match was not an ANSI escape, so count it towards the length
-			length -= 1
+	for i := 0; i < len(buf); {
+		n, isEscape := nextToken(buf[i:])
+		tmp = append(tmp, buf[i:i+n]...)
+		i += n
+		if !isEscape {
+			// This was not an ANSI escape, so count it towards the length
+			length -= graphemeWidth(buf[i-n : i])
 			if length <= 0 {
+				if length < 0 {
+					// Don't let a wide character stick out.
+					tmp = tmp[:len(tmp)-n]
+				}
 				return tmp
 			}
 		}
@@ -740,12 +1183,19 @@ func trimStringEllipsis(buf []byte, length int) []byte {
 }
 
 func stringLen(buf []byte) int {
-	return utf8.RuneCount(uncolorize(buf))
+	buf = uncolorize(buf)
+	length := 0
+	for i := 0; i < len(buf); {
+		n := graphemeLen(buf[i:])
+		length += graphemeWidth(buf[i : i+n])
+		i += n
+	}
+	return length
 }
 
 func (l *Logger) getFormattedLine(line []byte) []byte {
 	l.tmp = l.tmp[:0]
-	l.formatHeader(&l.tmp)
+	l.formatHeader(&l.tmp, line)
 	codes := getActiveAnsiCodes(l.tmp)
 	l.tmp = append(l.tmp, codes.getResetBytes()...)
 	l.tmp = append(l.tmp, line...)
@@ -756,12 +1206,17 @@ func (l *Logger) getFormattedLine(line []byte) []byte {
 }
 
 func (l *Logger) reprocessPrefix() {
-	colorTemplateRegexp := l.getColorTemplateRegexp()
-	if colorTemplateRegexp != nil {
-		l.prefixFormatted = processColorTemplates(colorTemplateRegexp, l.prefix)
-	} else {
-		l.prefixFormatted = l.prefix
+	l.prefixFormatted = l.processTemplates(l.prefix)
+	l.suffixFormatted = l.processTemplates(l.suffix)
+}
+
+// processTemplates applies color templates to a prefix or suffix, if they're
+// enabled.
+func (l *Logger) processTemplates(tmpl []byte) []byte {
+	if colorTemplateRegexp := l.getColorTemplateRegexp(); colorTemplateRegexp != nil {
+		return processColorTemplates(colorTemplateRegexp, tmpl)
 	}
+	return tmpl
 }
 
 func processColorTemplates(colorTemplateRegexp *regexp.Regexp, buf []byte) []byte {
@@ -773,7 +1228,7 @@ func processColorTemplates(colorTemplateRegexp *regexp.Regexp, buf []byte) []byt
 		groups := colorTemplateRegexp.FindSubmatch(token)
 		var ansiActive ActiveAnsiCodes
 		for _, codeBytes := range bytes.Split(groups[1], bytesComma) {
-			colorCode, ok := ansiColorCodes[string(codeBytes)]
+			colorCode, ok := lookupColorCode(string(codeBytes))
 			if !ok {
 				// Don't modify the text if we don't recognize any of the codes
 				return groups[0]
@@ -795,6 +1250,9 @@ func processColorTemplates(colorTemplateRegexp *regexp.Regexp, buf []byte) []byt
 func (l *Logger) applyColorTemplates(s string) string {
 	colorTemplateRegexp := l.getColorTemplateRegexp()
 	if colorTemplateRegexp != nil {
+		if l.useMonochromeSymbols() {
+			s = insertTemplateSymbols(l.glyphs(), colorTemplateRegexp, s)
+		}
 		return string(processColorTemplates(colorTemplateRegexp, []byte(s)))
 	} else {
 		return s
@@ -806,8 +1264,18 @@ func (l *Logger) injectAtVirtualCursor(input []byte) {
 		// Append s to l.buf[:cursorByteIndex], consuming l.buf[cursorByteIndex:] with
 		// each rune, but also injecting ansi escapes at the new old/new transition
 		// column to keep the colors consistent.
+		if indexNewline := bytes.IndexByte(input, '\n'); indexNewline != -1 {
+			// A newline ends the line without consuming the rest of it, just as
+			// on a terminal.
+			l.injectAtVirtualCursor(input[:indexNewline])
+			l.buf = append(l.buf, input[indexNewline:]...)
+			l.cursorByteIndex = len(l.buf)
+			return
+		}
 		before := l.buf[:l.cursorByteIndex]
-		after := l.buf[l.cursorByteIndex:]
+		// after shares l.buf's backing array, which appending to before
+		// overwrites, so copy it first.
+		after := append([]byte{}, l.buf[l.cursorByteIndex:]...)
 		afterLength := stringLen(after)
 		inputLength := stringLen(input)
 		if inputLength >= afterLength {
@@ -817,8 +1285,8 @@ func (l *Logger) injectAtVirtualCursor(input []byte) {
 			l.cursorByteIndex += len(input)
 		} else {
 			removed := trimString(after, inputLength)
-			ansiOld := getActiveAnsiCodes(append(before, removed...))
-			ansiNew := getActiveAnsiCodes(append(before, input...))
+			ansiOld := getActiveAnsiCodes(append(append([]byte{}, before...), removed...))
+			ansiNew := getActiveAnsiCodes(append(append([]byte{}, before...), input...))
 			escapes := []byte{}
 			changedIntensity := ansiNew.intensity != ansiOld.intensity
 			changedForecolor := ansiNew.forecolor != ansiOld.forecolor
@@ -860,20 +1328,42 @@ func (l *Logger) intOutput(calldepth int, s []byte, haveLock bool) error {
 		ws.lock()
 		defer ws.unlock()
 	}
-	l.now = time.Now() // get this early.
-	if l.flag&LUTC != 0 {
-		l.now = l.now.UTC()
+	if !l.IsEnabled() || l.callLevel < l.minLevel {
+		return nil
 	}
+	l.updateNow() // get this early.
 	if l.isClosed {
 		return errors.New("Attempted to write to closed Logger.")
 	}
+	l.lastOutputTime = l.now
+	if len(l.buf) == 0 {
+		l.resetLineState()
+	} else {
+		// Continuing a partial line: it gets the highest level and the most
+		// recent context of the calls contributing to it.
+		if l.callLevel > l.lineLevel {
+			l.lineLevel = l.callLevel
+		}
+		if l.callCtx != nil {
+			l.lineCtx, l.lineFields = l.callCtx, l.callFields
+		}
+		l.lineVerbatim = l.lineVerbatim || l.callVerbatim
+	}
+	if l.sanitizeEnabled {
+		s = sanitize(s)
+	}
 	// This is kind of kludgy, but better than nothing:
-	s = []byte(strings.Replace(string(s), "\t", "        ", -1))
-	if l.isAutoNewlineEnabled() && len(s) > 0 && s[len(s)-1] != byteNewline {
-		s = append(s, byteNewline)
+	if bytes.IndexByte(s, '\t') != -1 {
+		s = bytes.Replace(s, bytesTab, bytesTabSpaces, -1)
 	}
+	// s may belong to the caller (e.g. via Write), so never append to it in place.
 	l.injectAtVirtualCursor(s)
-	wroteFullLine := false
+	if l.isAutoNewlineEnabled() && len(s) > 0 && s[len(s)-1] != byteNewline {
+		l.injectAtVirtualCursor(bytesNewline)
+	}
+	linesWritten := 0
+	forcedNewline := false
+	snippetDue := false
 	for true {
 		indexNewline := bytes.IndexByte(l.buf, '\n')
 		var currLine []byte
@@ -887,28 +1377,54 @@ func (l *Logger) intOutput(calldepth int, s []byte, haveLock bool) error {
 		if indexCr != -1 && indexCr != indexNewline-1 {
 			// For every carriage return found within the current line, detach the text
 			// after the carriage return and inject at the beginning of the line.
-			after := l.buf[indexCr+1:]
+			after := append([]byte{}, l.buf[indexCr+1:]...)
 			l.buf = l.buf[:indexCr]
 			l.cursorByteIndex = 0
 			l.injectAtVirtualCursor(after)
 			continue
 		}
 		if indexNewline == -1 {
-			break
+			if l.maxPartialLineBytes <= 0 || len(l.buf) <= l.maxPartialLineBytes {
+				break
+			}
+			// The partial line is too long; complete it as if a newline had been written.
+			l.buf = append(l.buf, byteNewline)
+			indexNewline = len(l.buf) - 1
+			currLine = l.buf[:indexNewline]
+			forcedNewline = true
 		}
 		l.buf = l.buf[indexNewline+1:]
 		l.cursorByteIndex -= indexNewline + 1
+		if forcedNewline {
+			// Carry the colors over to the rest of the line.
+			l.buf = append(getActiveAnsiCodes(currLine).getRestoreBytes(), l.buf...)
+			l.cursorByteIndex = len(l.buf)
+			forcedNewline = false
+		}
+		currLine = l.redact(currLine)
+		var keep bool
+		if currLine, keep = l.filterLine(currLine); !keep || l.isDuplicateLine(currLine) {
+			ws.removeTempLogger(l)
+			l.tempLineActive = false
+			l.resetLineState()
+			continue
+		}
+		currLine = l.detectSeverity(currLine)
+		currLine = l.highlight(currLine)
 		if l.flag&(Lshortfile|Llongfile) != 0 && len(l.callerFile) == 0 {
-			// release lock while getting caller info - it's expensive.
-			if !haveLock {
-				ws.unlock()
-			}
+			// The lock stays held: releasing it here would let another
+			// goroutine printing to this Logger change l.buf under us.
 			var ok bool
-			_, l.callerFile, l.callerLine, ok = runtime.Caller(calldepth)
+			var pc uintptr
+			pc, l.callerFile, l.callerLine, ok = runtime.Caller(calldepth)
 			if !ok {
 				l.callerFile = "???"
 				l.callerLine = 0
 			}
+			l.callerPath = l.callerFile
+			if l.flag&Lshortfile == 0 && ok {
+				l.callerFile = l.trimCallerPath(pc, l.callerFile)
+			}
 			if l.flag&Lshortfile != 0 {
 				for i := len(l.callerFile) - 1; i > 0; i-- {
 					if l.callerFile[i] == '/' {
@@ -917,15 +1433,20 @@ func (l *Logger) intOutput(calldepth int, s []byte, haveLock bool) error {
 					}
 				}
 			}
-			if !haveLock {
-				ws.lock()
-			}
 		}
 		// ansiActive := getActiveAnsiCodes(currLine)
+		wasTempLine := l.tempLineActive
 		ws.removeTempLogger(l)
 		l.tempLineActive = false
-		writeLine(l.out, l.getFormattedLine(currLine))
-		wroteFullLine = true
+		var formatted []byte
+		if l.blockMode && linesWritten > 0 {
+			formatted = l.getBlockLine(currLine)
+		} else {
+			formatted = l.getFormattedLine(currLine)
+		}
+		linesWritten++
+		snippetDue = snippetDue || l.lineLevel >= LevelError
+		l.writeFormattedLine(currLine, formatted, wasTempLine)
 		// // XXX This is probably inefficient?:
 		// prepends := []byte{}
 		// if ansiActive.intensity != 0 {
@@ -939,19 +1460,107 @@ func (l *Logger) intOutput(calldepth int, s []byte, haveLock bool) error {
 		//     l.cursorByteIndex += len(prepends)
 		// }
 	}
-	if wroteFullLine {
+	if linesWritten > 0 {
+		if snippetDue && l.snippetsEnabled && len(l.callerPath) > 0 {
+			l.writeSourceSnippet()
+		}
 		l.callerFile = ""
+		l.callerPath = ""
 		l.callerLine = 0
 	}
-	if !l.tempLineActive && l.isPartialLinesEnabled() && stringLen(l.buf) > 0 {
+	if !l.tempLineActive && l.isPartialLinesEnabled() && !l.jsonOutput && !ws.queue.tempLinesSuspended() && stringLen(l.buf) > 0 {
 		ws.addTempLogger(l)
 		l.tempLineActive = true
 		l.lineStartTime = l.now
+		l.lineUpdates = 0
+	}
+	if l.tempLineActive {
+		l.lineUpdates++
+		if len(l.sinks) > 0 {
+			l.emitPartialEntry()
+		}
 	}
 	updateTempOutput(l.out)
 	return nil
 }
 
+// writeFormattedLine writes a completed line, formatted with its header, to
+// wherever it belongs, and passes it on to the Logger's sinks and stats. Must
+// be called with the writer lock held.
+func (l *Logger) writeFormattedLine(line, formatted []byte, wasTempLine bool) {
+	ws := getWriterState(l.out)
+	if l.getColorTemplateRegexp() != nil && !l.lineVerbatim {
+		formatted = l.alignRight(formatted)
+	}
+	formatted = l.appendSuffix(formatted)
+	if l.jsonOutput {
+		l.writeJSONLine(line)
+	} else if out := l.routeFor(l.lineLevel); out != nil && out != l.out {
+		l.routeLine(out, formatted)
+	} else if ws.ci != NoCI {
+		l.writeCILine(formatted)
+	} else if wasTempLine && l.collapseAfter > 0 {
+		ws.addFinishedLine(l, formatted)
+	} else {
+		l.writeCompletedLine(formatted)
+	}
+	l.emitEntry(line, wasTempLine)
+	l.countLine(formatted)
+}
+
+// resetLineState prepares the per-line level and context for the next line,
+// which starts out with those of the call in progress.
+func (l *Logger) resetLineState() {
+	l.lineLevel = l.callLevel
+	l.lineCtx, l.lineFields = l.callCtx, l.callFields
+	l.lineVerbatim = l.callVerbatim
+}
+
+func (l *Logger) updateNow() {
+	l.now = l.clockNow()
+	if l.flag&LUTC != 0 {
+		l.now = l.now.UTC()
+	} else if l.location != nil {
+		l.now = l.now.In(l.location)
+	}
+}
+
+// clockNow reads the Logger's clock. Must be called with the writer lock held.
+func (l *Logger) clockNow() time.Time {
+	if clock := l.clock.get(); clock != nil {
+		return clock()
+	} else if clock := DefaultLogger.clock.get(); clock != nil {
+		return clock()
+	}
+	return time.Now()
+}
+
+// Don't hang on to the formatting buffer after an unusually large message.
+const maxRetainedFmtBufSize = 64 << 10
+
+// A fmtBuf holds the formatted arguments of one call to Printf and friends.
+// Each call gets its own, so that arguments can be formatted before taking
+// the writer lock, and String methods that log don't deadlock.
+type fmtBuf struct {
+	b []byte
+}
+
+var fmtBufPool = sync.Pool{New: func() interface{} { return new(fmtBuf) }}
+
+// formatArgs formats according to format into a buffer that should be
+// released once the output has been written.
+func (l *Logger) formatArgs(format string, v []interface{}) *fmtBuf {
+	buf := fmtBufPool.Get().(*fmtBuf)
+	buf.b = fmt.Appendf(buf.b[:0], l.applyColorTemplates(format), v...)
+	return buf
+}
+
+func (buf *fmtBuf) release() {
+	if cap(buf.b) <= maxRetainedFmtBufSize {
+		fmtBufPool.Put(buf)
+	}
+}
+
 func (l *Logger) truncateBuf() {
 	l.buf = l.buf[:0]
 	l.cursorByteIndex = 0
@@ -960,35 +1569,57 @@ func (l *Logger) truncateBuf() {
 // Printf calls l.Output to print to the logger.
 // Arguments are handled in the manner of fmt.Printf.
 func (l *Logger) Printf(format string, v ...interface{}) {
+	if !l.IsEnabled() {
+		return
+	}
+	buf := l.formatArgs(format, v)
+	defer buf.release()
 	ws := getWriterState(l.out)
 	ws.lock()
 	defer ws.unlock()
-	l.intOutput(2, []byte(fmt.Sprintf(l.applyColorTemplates(format), v...)), true)
+	l.intOutput(2, buf.b, true)
 }
 
 // Print calls l.Output to print to the logger.
 // Arguments are handled in the manner of fmt.Print.
-func (l *Logger) Print(v ...interface{}) { l.intOutput(2, []byte(fmt.Sprint(v...)), false) }
+func (l *Logger) Print(v ...interface{}) {
+	if l.IsEnabled() {
+		l.intOutput(2, []byte(fmt.Sprint(v...)), false)
+	}
+}
 
 func (l *Logger) Replacef(format string, v ...interface{}) {
+	if !l.IsEnabled() {
+		return
+	}
+	buf := l.formatArgs(format, v)
+	defer buf.release()
 	ws := getWriterState(l.out)
 	ws.lock()
 	defer ws.unlock()
 	l.truncateBuf()
-	l.intOutput(2, []byte(fmt.Sprintf(l.applyColorTemplates(format), v...)), true)
+	l.intOutput(2, buf.b, true)
 }
 
 func (l *Logger) Replace(v ...interface{}) {
+	if !l.IsEnabled() {
+		return
+	}
+	s := []byte(fmt.Sprint(v...))
 	ws := getWriterState(l.out)
 	ws.lock()
 	defer ws.unlock()
 	l.truncateBuf()
-	l.intOutput(2, []byte(fmt.Sprint(v...)), true)
+	l.intOutput(2, s, true)
 }
 
 // Println calls l.intOutput to print to the logger.
 // Arguments are handled in the manner of fmt.Println.
-func (l *Logger) Println(v ...interface{}) { l.intOutput(2, []byte(fmt.Sprintln(v...)), false) }
+func (l *Logger) Println(v ...interface{}) {
+	if l.IsEnabled() {
+		l.intOutput(2, []byte(fmt.Sprintln(v...)), false)
+	}
+}
 
 // Fatal is equivalent to l.Print() followed by a call to os.Exit(1).
 func (l *Logger) Fatal(v ...interface{}) {
@@ -998,9 +1629,10 @@ func (l *Logger) Fatal(v ...interface{}) {
 
 // Fatalf is equivalent to l.Printf() followed by a call to os.Exit(1).
 func (l *Logger) Fatalf(format string, v ...interface{}) {
+	buf := l.formatArgs(format, v)
 	ws := getWriterState(l.out)
 	ws.lock()
-	l.intOutput(2, []byte(fmt.Sprintf(l.applyColorTemplates(format), v...)), true)
+	l.intOutput(2, buf.b, true)
 	ws.unlock()
 	osExit()
 }
@@ -1021,9 +1653,11 @@ func (l *Logger) Panic(v ...interface{}) {
 
 // Panicf is equivalent to l.Printf() followed by a call to panic().
 func (l *Logger) Panicf(format string, v ...interface{}) {
+	buf := l.formatArgs(format, v)
+	s := string(buf.b)
+	buf.release()
 	ws := getWriterState(l.out)
 	ws.lock()
-	s := fmt.Sprintf(l.applyColorTemplates(format), v...)
 	l.intOutput(2, []byte(s), true)
 	l.flushInt()
 	ws.unlock()
@@ -1038,33 +1672,22 @@ func (l *Logger) Panicln(v ...interface{}) {
 }
 
 func (l *Logger) Bail(err error) {
+	l.bail(err, []byte(fmt.Sprintf("Bailed due to error: %s\n", err.Error())))
+}
+
+// bail prints the stack and message, then panics with err.
+func (l *Logger) bail(err error, message []byte) {
 	// This works best if l.out == os.Stderr, but it should kind of work regardless
 	ws := getWriterState(l.out)
 	ws.lock()
 	l.flushInt()
-	size := 4096
-	for {
-		buf := make([]byte, size)
-		bytesWritten := runtime.Stack(buf, false)
-		if bytesWritten == size {
-			size *= 2
-			continue
-		}
-		stackTrace := strings.TrimSpace(string(buf[:bytesWritten]))
-		lines := strings.Split(stackTrace, "\n")
-		for i, line := range lines {
-			// Try to cut out the Bail() call from the stack trace:
-			if (i == 1 || i == 3) && strings.Contains(line, "ansi-log.(*Logger).Bail") {
-				continue
-			}
-			if (i == 2 || i == 4) && strings.Contains(line, "duppercloud/ansi-log/log.go") {
-				continue
-			}
-			l.intOutput(2, []byte(line+"\n"), true)
-		}
-		break
+	for _, line := range callerStack() {
+		l.intOutput(3, []byte(line+"\n"), true)
 	}
-	l.intOutput(2, []byte(fmt.Sprintf("Bailed due to error: %s\n", err.Error())), true)
+	prevLevel := l.callLevel
+	l.callLevel = LevelError
+	l.intOutput(3, message, true)
+	l.callLevel = prevLevel
 	ws.unlock()
 	panic(err)
 }
@@ -1085,10 +1708,46 @@ func (l *Logger) Flags() int {
 
 // SetFlags sets the output flags for the logger.
 func (l *Logger) SetFlags(flag int) {
+	l.setFlags(flag, true)
+	if l == DefaultLogger {
+		Stdout.setFlags(flag, false)
+		Stderr.setFlags(flag, false)
+	}
+}
+
+// setFlags sets the flags, unless they are only inherited from DefaultLogger
+// and the Logger has flags of its own.
+func (l *Logger) setFlags(flag int, own bool) {
 	ws := getWriterState(l.out)
 	ws.lock()
 	defer ws.unlock()
-	l.flag = flag
+	l.customized = true
+	if own || !l.ownFlags {
+		l.flag = flag
+		l.ownFlags = l.ownFlags || own
+	}
+}
+
+// SetClock sets the function used to read the current time for timestamps
+// and elapsed times, e.g. to make output deterministic in tests or to replay
+// logs. A nil clock restores the default, which is DefaultLogger's clock if it
+// has one and time.Now otherwise.
+func (l *Logger) SetClock(clock func() time.Time) {
+	ws := getWriterState(l.out)
+	ws.lock()
+	defer ws.unlock()
+	l.customized = true
+	l.clock.set(clock)
+}
+
+// SetLocation sets the time zone timestamps are shown in, rather than the
+// local one. LUTC takes precedence. A nil location restores the default.
+func (l *Logger) SetLocation(location *time.Location) {
+	ws := getWriterState(l.out)
+	ws.lock()
+	defer ws.unlock()
+	l.customized = true
+	l.location = location
 }
 
 // Prefix returns the output prefix for the logger.
@@ -1099,20 +1758,111 @@ func (l *Logger) Prefix() string {
 	return string(l.prefix)
 }
 
+// Writer returns the output destination for the logger. (Output, as in the
+// standard log package, writes a logging event.)
+func (l *Logger) Writer() io.Writer {
+	ws := getWriterState(l.out)
+	ws.lock()
+	defer ws.unlock()
+	return l.out
+}
+
+// IsTerminal reports whether the logger writes to a terminal.
+func (l *Logger) IsTerminal() bool {
+	file, ok := l.Writer().(*os.File)
+	return ok && isTerminal(file)
+}
+
+// ColorEnabledEffective reports whether the logger writes colors, taking into
+// account the standard logger's setting if it has none of its own.
+func (l *Logger) ColorEnabledEffective() bool {
+	ws := getWriterState(l.out)
+	ws.lock()
+	defer ws.unlock()
+	return l.isColorEnabled()
+}
+
 // SetPrefix sets the output prefix for the logger.
 func (l *Logger) SetPrefix(prefix string) {
+	l.setPrefix(prefix, true)
+	if l == DefaultLogger {
+		Stdout.setPrefix(prefix, false)
+		Stderr.setPrefix(prefix, false)
+	}
+}
+
+// setPrefix sets the prefix, unless it is only inherited from DefaultLogger
+// and the Logger has a prefix of its own.
+func (l *Logger) setPrefix(prefix string, own bool) {
 	ws := getWriterState(l.out)
 	ws.lock()
 	defer ws.unlock()
-	l.prefix = []byte(prefix)
-	l.reprocessPrefix()
+	l.customized = true
+	if own || !l.ownPrefix {
+		l.prefix = []byte(prefix)
+		l.reprocessPrefix()
+		l.ownPrefix = l.ownPrefix || own
+	}
 }
 
+// SetPrefixFunc sets a function that returns the prefix for each line as it
+// is formatted, in place of the one set with SetPrefix, e.g. to include the
+// ID of the request being handled. The Entry describes the line; its Prefix
+// is empty. The result is a template, as for SetPrefix. The function is
+// called with the writer lock held, so it must not log to the same writer.
+// A nil function restores the prefix set with SetPrefix.
+func (l *Logger) SetPrefixFunc(fn func(e *Entry) string) {
+	ws := getWriterState(l.out)
+	ws.lock()
+	defer ws.unlock()
+	l.customized = true
+	l.prefixFunc = fn
+}
+
+// SetPrefixFunc sets the prefix function for the standard logger.
+func SetPrefixFunc(fn func(e *Entry) string) {
+	DefaultLogger.SetPrefixFunc(fn)
+}
+
+// Write outputs p, which need not end at a line boundary. A multi-byte UTF-8
+// character split between calls is held back until the rest of it arrives.
 func (l *Logger) Write(p []byte) (n int, err error) {
-	err = l.intOutput(2, p, false)
+	if !l.IsEnabled() {
+		return len(p), nil
+	}
+	ws := getWriterState(l.out)
+	ws.lock()
+	defer ws.unlock()
+	chunk := p
+	if len(l.heldBytes) > 0 {
+		chunk = append(append([]byte{}, l.heldBytes...), p...)
+	}
+	complete := len(chunk) - incompleteRuneSuffix(chunk)
+	l.heldBytes = append(l.heldBytes[:0], chunk[complete:]...)
+	if complete > 0 {
+		err = l.intOutput(2, chunk[:complete], true)
+	}
 	return len(p), err
 }
 
+// incompleteRuneSuffix returns the length of the UTF-8 sequence that buf ends
+// partway through, if any.
+func incompleteRuneSuffix(buf []byte) int {
+	for i := 1; i <= utf8.UTFMax-1 && i <= len(buf); i++ {
+		c := buf[len(buf)-i]
+		if c < utf8.RuneSelf {
+			return 0
+		}
+		if utf8.RuneStart(c) {
+			if utf8.FullRune(buf[len(buf)-i:]) {
+				return 0
+			}
+			return i
+		}
+	}
+	return 0
+}
+
 func (l *Logger) Colorify(s string) string {
 	ws := getWriterState(l.out)
 	ws.lock()
@@ -1121,6 +1871,12 @@ func (l *Logger) Colorify(s string) string {
 }
 
 func (l *Logger) flushInt() {
+	if len(l.heldBytes) > 0 {
+		// The rest of the character isn't coming; write what we have.
+		held := l.heldBytes
+		l.heldBytes = nil
+		l.intOutput(2, held, true)
+	}
 	if len(l.buf) > 0 {
 		l.intOutput(2, []byte("\n"), true)
 	}
@@ -1132,27 +1888,54 @@ func (l *Logger) closeInt() {
 
 func (l *Logger) Flush() {
 	ws := getWriterState(l.out)
+	defer ws.queue.wait()
 	ws.lock()
 	defer ws.unlock()
 	l.flushInt()
 }
 
 func (l *Logger) Close() error {
-	if len(l.buf) > 0 {
-		ws := getWriterState(l.out)
-		ws.lock()
-		defer ws.unlock()
-		l.flushInt()
-	}
+	ws := getWriterState(l.out)
+	ws.lock()
+	ws.flushFinishedLines(l)
+	l.flushInt()
+	l.flushDedupInt()
 	l.closeInt()
-	return nil
+	l.stopStallRefresh(ws)
+	closeFuncs := l.closeFuncs
+	l.closeFuncs = nil
+	sinks := l.flushingSinks()
+	ws.unlock()
+	ws.queue.wait()
+	var err error
+	for _, sink := range sinks {
+		if flushErr := sink.Flush(); err == nil {
+			err = flushErr
+		}
+	}
+	for _, fn := range closeFuncs {
+		if closeErr := fn(); err == nil {
+			err = closeErr
+		}
+	}
+	return err
+}
+
+// addCloseFunc arranges for fn to be called once the Logger is closed and
+// its output has been written, e.g. to close a file it owns.
+func (l *Logger) addCloseFunc(fn func() error) {
+	ws := getWriterState(l.out)
+	ws.lock()
+	defer ws.unlock()
+	l.closeFuncs = append(l.closeFuncs, fn)
 }
 
 func (l *Logger) SetPartialLinesEnabled(flag bool) {
 	ws := getWriterState(l.out)
 	ws.lock()
 	defer ws.unlock()
-	l.partialLinesEnabled = boolPointer(flag)
+	l.customized = true
+	l.partialLinesEnabled.set(flag)
 }
 func (l *Logger) ShowPartialLines() { l.SetPartialLinesEnabled(true) }
 func (l *Logger) HidePartialLines() { l.SetPartialLinesEnabled(false) }
@@ -1161,7 +1944,8 @@ func (l *Logger) SetColorEnabled(flag bool) {
 	ws := getWriterState(l.out)
 	ws.lock()
 	defer ws.unlock()
-	l.colorEnabled = boolPointer(flag)
+	l.customized = true
+	l.colorEnabled.set(flag)
 }
 func (l *Logger) EnableColor()  { l.SetColorEnabled(true) }
 func (l *Logger) DisableColor() { l.SetColorEnabled(false) }
@@ -1170,7 +1954,8 @@ func (l *Logger) SetColorTemplateEnabled(flag bool) {
 	ws := getWriterState(l.out)
 	ws.lock()
 	defer ws.unlock()
-	l.colorTemplateEnabled = boolPointer(flag)
+	l.customized = true
+	l.colorTemplateEnabled.set(flag)
 	l.reprocessPrefix()
 }
 func (l *Logger) EnableColorTemplate()  { l.SetColorTemplateEnabled(true) }
@@ -1180,7 +1965,8 @@ func (l *Logger) SetAutoNewlines(flag bool) {
 	ws := getWriterState(l.out)
 	ws.lock()
 	defer ws.unlock()
-	l.autoAppendNewline = boolPointer(flag)
+	l.customized = true
+	l.autoAppendNewline.set(flag)
 }
 func (l *Logger) EnableAutoNewlines()  { l.SetAutoNewlines(true) }
 func (l *Logger) DisableAutoNewlines() { l.SetAutoNewlines(false) }
@@ -1189,7 +1975,8 @@ func (l *Logger) SetColorTemplateRegexp(rgx *regexp.Regexp) {
 	ws := getWriterState(l.out)
 	ws.lock()
 	defer ws.unlock()
-	l.colorRegexp = rgx
+	l.customized = true
+	l.colorRegexp.set(rgx)
 }
 
 func (l *Logger) SetTerminalWidth(width int) {
@@ -1200,13 +1987,58 @@ func (l *Logger) SetTerminalWidth(width int) {
 	getWriterState(l.out).termWidth = width
 }
 
+// SetTerminalHeight overrides the number of rows the terminal is assumed to
+// have, which limits how many partial lines are shown in multiline mode.
+func (l *Logger) SetTerminalHeight(height int) {
+	ws := getWriterState(l.out)
+	ws.lock()
+	defer ws.unlock()
+	ws.termHeight = height
+	updateTempOutput(l.out)
+}
+
+// SetMaxPartialLineBytes limits how long a partial line may grow. Once more
+// than n bytes have been written without a newline, the line is completed as
+// if one had been written, and the rest continues on a new line in the same
+// colors. Zero (the default) means no limit.
+func (l *Logger) SetMaxPartialLineBytes(n int) {
+	ws := getWriterState(l.out)
+	ws.lock()
+	defer ws.unlock()
+	l.customized = true
+	l.maxPartialLineBytes = n
+}
+
+// SetMultilineEnabled sets whether each partial line gets a line of its own,
+// rather than sharing one line with the others. Partial lines already shown
+// are redrawn in the new layout.
 func (l *Logger) SetMultilineEnabled(flag bool) {
 	ws := getWriterState(l.out)
 	ws.lock()
 	defer ws.unlock()
-	getWriterState(l.out).flushAll()
-	getWriterState(l.out).multiline = flag
+	ws.setMultiline(flag && cursorMovementSupported)
+	if flag {
+		watchResize()
+	}
+}
+
+// setMultiline switches between single-line and multiline mode. Partial
+// lines are laid out differently in each, so what's on screen is cleared and
+// every partial line is redrawn from scratch in the new layout. Must be
+// called with the writer lock held.
+func (w *WriterState) setMultiline(flag bool) {
+	if flag == w.multiline {
+		return
+	}
+	w.clearTempLines()
+	w.lastTemp.reset()
+	w.cursorLineIndex = 0
+	w.cursorIsAtBegin = true
+	w.cursorIsInline = false
+	w.multiline = flag
+	updateTempOutput(w.out)
 }
+
 func (l *Logger) EnableMultilineMode()  { l.SetMultilineEnabled(true) }
 func (l *Logger) EnableSinglelineMode() { l.SetMultilineEnabled(false) }
 
@@ -1234,11 +2066,49 @@ func SetFlags(flag int) {
 	DefaultLogger.SetFlags(flag)
 }
 
+// SetMaxPartialLineBytes limits how long partial lines of the standard logger
+// may grow.
+func SetMaxPartialLineBytes(n int) {
+	DefaultLogger.SetMaxPartialLineBytes(n)
+}
+
+func SetLocation(location *time.Location) { DefaultLogger.SetLocation(location) }
+
+// SetClock sets the clock of the standard logger, which is also used by
+// loggers that don't have their own.
+func SetClock(clock func() time.Time) {
+	DefaultLogger.SetClock(clock)
+}
+
 // Prefix returns the output prefix for the standard logger.
 func Prefix() string {
 	return DefaultLogger.Prefix()
 }
 
+// Writer returns the output destination for the standard logger.
+func Writer() io.Writer {
+	return DefaultLogger.Writer()
+}
+
+// IsTerminal reports whether the standard logger writes to a terminal.
+func IsTerminal() bool {
+	return DefaultLogger.IsTerminal()
+}
+
+// WriterIsTerminal reports whether w writes to a terminal. A RawWriter does if
+// its Logger's output does.
+func WriterIsTerminal(w io.Writer) bool {
+	if raw, ok := w.(rawWriter); ok {
+		w = raw.ws.out
+	}
+	return isTerminalWriter(w)
+}
+
+// ColorEnabledEffective reports whether the standard logger writes colors.
+func ColorEnabledEffective() bool {
+	return DefaultLogger.ColorEnabledEffective()
+}
+
 // SetPrefix sets the output prefix for the standard logger.
 func SetPrefix(prefix string) {
 	DefaultLogger.SetPrefix(prefix)
@@ -1249,37 +2119,57 @@ func SetPrefix(prefix string) {
 // Print calls Output to print to the standard logger.
 // Arguments are handled in the manner of fmt.Print.
 func Print(v ...interface{}) {
+	if !DefaultLogger.IsEnabled() {
+		return
+	}
 	DefaultLogger.intOutput(2, []byte(fmt.Sprint(v...)), false)
 }
 
 // Printf calls Output to print to the standard logger.
 // Arguments are handled in the manner of fmt.Printf.
 func Printf(format string, v ...interface{}) {
+	if !DefaultLogger.IsEnabled() {
+		return
+	}
+	buf := DefaultLogger.formatArgs(format, v)
+	defer buf.release()
 	ws := getWriterState(DefaultLogger.out)
 	ws.lock()
 	defer ws.unlock()
-	DefaultLogger.intOutput(2, []byte(fmt.Sprintf(DefaultLogger.applyColorTemplates(format), v...)), true)
+	DefaultLogger.intOutput(2, buf.b, true)
 }
 
 func Replace(v ...interface{}) {
+	if !DefaultLogger.IsEnabled() {
+		return
+	}
+	s := []byte(fmt.Sprint(v...))
 	ws := getWriterState(DefaultLogger.out)
 	ws.lock()
 	defer ws.unlock()
 	DefaultLogger.truncateBuf()
-	DefaultLogger.intOutput(2, []byte(fmt.Sprint(v...)), true)
+	DefaultLogger.intOutput(2, s, true)
 }
 
 func Replacef(format string, v ...interface{}) {
+	if !DefaultLogger.IsEnabled() {
+		return
+	}
+	buf := DefaultLogger.formatArgs(format, v)
+	defer buf.release()
 	ws := getWriterState(DefaultLogger.out)
 	ws.lock()
 	defer ws.unlock()
 	DefaultLogger.truncateBuf()
-	DefaultLogger.intOutput(2, []byte(fmt.Sprintf(DefaultLogger.applyColorTemplates(format), v...)), true)
+	DefaultLogger.intOutput(2, buf.b, true)
 }
 
 // Println calls Output to print to the standard logger.
 // Arguments are handled in the manner of fmt.Println.
 func Println(v ...interface{}) {
+	if !DefaultLogger.IsEnabled() {
+		return
+	}
 	DefaultLogger.intOutput(2, []byte(fmt.Sprintln(v...)), false)
 }
 
@@ -1291,9 +2181,10 @@ func Fatal(v ...interface{}) {
 
 // Fatalf is equivalent to Printf() followed by a call to os.Exit(1).
 func Fatalf(format string, v ...interface{}) {
+	buf := DefaultLogger.formatArgs(format, v)
 	ws := getWriterState(DefaultLogger.out)
 	ws.lock()
-	DefaultLogger.intOutput(2, []byte(fmt.Sprintf(DefaultLogger.applyColorTemplates(format), v...)), true)
+	DefaultLogger.intOutput(2, buf.b, true)
 	ws.unlock()
 	osExit()
 }
@@ -1314,9 +2205,11 @@ func Panic(v ...interface{}) {
 
 // Panicf is equivalent to Printf() followed by a call to panic().
 func Panicf(format string, v ...interface{}) {
+	buf := DefaultLogger.formatArgs(format, v)
+	s := string(buf.b)
+	buf.release()
 	ws := getWriterState(DefaultLogger.out)
 	ws.lock()
-	s := fmt.Sprintf(DefaultLogger.applyColorTemplates(format), v...)
 	DefaultLogger.intOutput(2, []byte(s), true)
 	DefaultLogger.flushInt()
 	ws.unlock()
@@ -1348,12 +2241,26 @@ func EnableAutoNewlines()                       { DefaultLogger.SetAutoNewlines(
 func DisableAutoNewlines()                      { DefaultLogger.SetAutoNewlines(false) }
 func SetColorTemplateRegexp(rgx *regexp.Regexp) { DefaultLogger.SetColorTemplateRegexp(rgx) }
 func SetTerminalWidth(width int)                { DefaultLogger.SetTerminalWidth(width) }
+func SetTerminalHeight(height int)              { DefaultLogger.SetTerminalHeight(height) }
 func EnableMultilineMode()                      { DefaultLogger.EnableMultilineMode() }
 func EnableSinglelineMode()                     { DefaultLogger.EnableSinglelineMode() }
 func Colorify(s string) string                  { return DefaultLogger.Colorify(s) }
 
 func AddAnsiColorCode(s string, code ColorCode) {
-	ansiColorCodes[s] = code
+	setColorCode(s, code)
+}
+
+func lookupColorCode(name string) (ColorCode, bool) {
+	ansiColorCodesMutex.RLock()
+	defer ansiColorCodesMutex.RUnlock()
+	code, ok := ansiColorCodes[name]
+	return code, ok
+}
+
+func setColorCode(name string, code ColorCode) {
+	ansiColorCodesMutex.Lock()
+	defer ansiColorCodesMutex.Unlock()
+	ansiColorCodes[name] = code
 }
 
 func osExit() {
@@ -1364,9 +2271,17 @@ func osExit() {
 	// would result in a deadlock when we try to RLock during a flush operation when
 	// we try to call getWriterState()
 	mutexGlobal.RLock()
-	for _, ws := range writers {
+	for _, ws := range uniqueWriterStates() {
 		ws.lock()
 		ws.closeAll()
+		ws.disableStatusBar()
+		ws.setTaskbarProgress(ProgressNone, 0)
+		ws.flushLocked()
+		for _, logger := range ws.sinkLoggers {
+			for _, sink := range logger.flushingSinks() {
+				sink.Flush()
+			}
+		}
 	}
 	os.Exit(1)
 }
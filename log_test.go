@@ -2,8 +2,13 @@ package alog
 
 import (
 	"bytes"
+	"context"
+	"fmt"
 	"os"
+	"os/exec"
+	"path/filepath"
 	"strings"
+	"sync"
 	"testing"
 	"time"
 
@@ -231,7 +236,7 @@ func TestNonLatinRunes(t *testing.T) {
 	writer.SetTerminalWidth(20)
 	// This has a combining diacritic after/in the third character.
 	writer.Print("ನನಗೆ ಹಾನಿ ಆಗದೆ, ನಾನು ಗಜನ್ನು ತಿನಬಹುದು")
-	assert.Equal("我能吞下玻璃而不伤身体。ನನಗೆ...", buf.String())
+	assert.Equal("我能吞下玻璃而不...", buf.String(), "CJK characters take up two columns")
 }
 
 func TestApplyTemplateEarly(t *testing.T) {
@@ -269,19 +274,37 @@ func TestCarriageReturns(t *testing.T) {
 	assert.Equal(" | Progress: \033[31m  0\033[39m percent.", buf.String())
 	buf.Reset()
 	writer2.Printf("\rProgress: @(red:  1) percent.")
-	assert.Equal("\rWorking... | Progress: \033[31m  1\033[39m percent.", buf.String())
+	assert.Equal("\r\033[25C\033[31m1\033[39m", buf.String())
 	buf.Reset()
 	writer2.Printf("\rProgress: @(red:  2) percent.\r")
-	assert.Equal("\rWorking... | Progress: \033[31m  2\033[39m percent.", buf.String())
+	assert.Equal("\r\033[25C\033[31m2\033[39m", buf.String())
 	buf.Reset()
 	writer2.Printf("Progress: @(red: 33) percent.")
-	assert.Equal("\rWorking... | Progress: \033[31m 33\033[39m percent.", buf.String())
+	assert.Equal("\r\033[24C\033[31m33\033[39m", buf.String())
 	buf.Reset()
 	writer2.Printf("\rProgress: @(blue) 6")
-	assert.Equal("\rWorking... | Progress: \033[34m 6\033[39m\033[31m3\033[39m percent.", buf.String())
+	assert.Equal("\r\033[23C\033[34m 6\033[39m", buf.String())
 	buf.Reset()
 }
 
+func TestOverwriteWithMultibyteRunes(t *testing.T) {
+	assert := assert.New(t)
+	var buf bytes.Buffer
+	var writer = New(&buf, "", 0)
+	defer writer.Close()
+	writer.Write([]byte("abc\ré\n"))
+	assert.Equal("ébc\n", buf.String())
+	buf.Reset()
+	writer.HidePartialLines()
+	writer.Write([]byte("abcdef\r☃"))
+	writer.Write([]byte("☃\n"))
+	assert.Equal("☃☃cdef\n", buf.String())
+	buf.Reset()
+	writer.Write([]byte("abc\rd\nef"))
+	writer.Write([]byte("\n"))
+	assert.Equal("dbc\nef\n", buf.String())
+}
+
 func TestReplace(t *testing.T) {
 	assert := assert.New(t)
 	var buf bytes.Buffer
@@ -291,13 +314,13 @@ func TestReplace(t *testing.T) {
 	assert.Equal("Hello Susan.", buf.String())
 	buf.Reset()
 	writer.Replace("Hello Bob.")
-	assert.Equal("\rHello Bob.  ", buf.String())
+	assert.Equal("\r\033[6CBob.  ", buf.String())
 	buf.Reset()
 	writer.Replacef("Hello %s.", "Al")
-	assert.Equal("\rHello Al. ", buf.String())
+	assert.Equal("\r\033[6CAl. ", buf.String())
 	buf.Reset()
 	writer.Replacef("Hello %s", "Ala")
-	assert.Equal("\rHello Ala", buf.String())
+	assert.Equal("\r\033[8Ca", buf.String())
 	buf.Reset()
 	writer.Replace("Hello Alan.")
 	assert.Equal("n.", buf.String())
@@ -315,6 +338,9 @@ func TestMultilineMode(t *testing.T) {
 		buf.Reset()
 		s = strings.Replace(s, lineDown, "{DOWN}", -1)
 		s = strings.Replace(s, lineUp, "{UP}", -1)
+		// Cursor hiding is covered by TestHideCursorDuringRedraw.
+		s = strings.Replace(s, "\033[?25l", "", -1)
+		s = strings.Replace(s, "\033[?25h", "", -1)
 		return s
 	}
 	writer1.EnableMultilineMode()
@@ -324,16 +350,16 @@ func TestMultilineMode(t *testing.T) {
 	writer2.Print("writer2...")
 	assert.Equal("\nwriter2...", readBuf())
 	writer1.Print(" working...")
-	assert.Equal("{UP}\rwriter1... working...", readBuf())
+	assert.Equal("{UP}\r\033[10C working...", readBuf())
 	writer1.Print("  50 percent finished...")
 	assert.Equal("  50 percent finished...", readBuf())
 	writer2.Print(" working... ")
-	assert.Equal("{DOWN}\rwriter2... working... ", readBuf())
+	assert.Equal("{DOWN}\r\033[10C working... ", readBuf())
 	writer2.Print("done.\n")
 	// Need to move up to the previous line, overwrite writer1's text, then only move down a line.
 	// A newline is not necessary since we're only *completing* an existing line and not yet starting
 	// a new line.
-	assert.Equal("{UP}\rwriter2... working... done.                  {DOWN}\rwriter1... working...  50 percent finished...", readBuf())
+	assert.Equal("{UP}\rwriter2... working... done.                  {DOWN}\r\033[6C1... working...  50 percent finished...", readBuf())
 	writer2.Print("working again...")
 	assert.Equal("\nworking again...", readBuf())
 	writer1.Print("\rwriter1... working... 100 percent. done.     \n")
@@ -382,6 +408,20 @@ func TestAutoNewlines(t *testing.T) {
 	buf.Reset()
 }
 
+func TestWriteDoesNotModifyInput(t *testing.T) {
+	assert := assert.New(t)
+	var buf bytes.Buffer
+	var writer = New(&buf, "", 0)
+	defer writer.Close()
+	writer.EnableAutoNewlines()
+	input := make([]byte, 0, 16)
+	input = append(input, "a\tb"...)
+	writer.Write(input)
+	assert.Equal("a        b\n", buf.String())
+	assert.Equal("a\tb", string(input[:cap(input)][:3]), "Write should not append to the caller's buffer")
+	assert.Equal(byte(0), input[:cap(input)][3])
+}
+
 func TestFlagElapsed(t *testing.T) {
 	assert := assert.New(t)
 	var buf bytes.Buffer
@@ -395,6 +435,37 @@ func TestFlagElapsed(t *testing.T) {
 	buf.Reset()
 }
 
+func TestSetClock(t *testing.T) {
+	assert := assert.New(t)
+	var buf bytes.Buffer
+	now := time.Date(2020, 1, 2, 3, 4, 5, 0, time.UTC)
+	var writer = New(&buf, "{isodate} ", Lelapsed)
+	writer.SetClock(func() time.Time { return now })
+	defer writer.Close()
+	writer.Print("Testing... ")
+	assert.Equal("2020-01-02T03:04:05 Testing... ", buf.String())
+	buf.Reset()
+	now = now.Add(1500 * time.Millisecond)
+	writer.Print("done.\n")
+	assert.Equal("\r2020-01-02T03:04:06 (1.50s) Testing... done.\n", buf.String())
+}
+
+func TestSetLocation(t *testing.T) {
+	assert := assert.New(t)
+	var buf bytes.Buffer
+	now := time.Date(2020, 1, 2, 3, 4, 5, 0, time.UTC)
+	var writer = New(&buf, "{isodate} {zone} ", 0)
+	writer.SetClock(func() time.Time { return now })
+	defer writer.Close()
+	writer.SetLocation(time.FixedZone("JST", 9*3600))
+	writer.Print("hello\n")
+	assert.Equal("2020-01-02T12:04:05 JST hello\n", buf.String())
+	buf.Reset()
+	writer.SetFlags(LUTC)
+	writer.Print("hello\n")
+	assert.Equal("2020-01-02T03:04:05 UTC hello\n", buf.String())
+}
+
 func TestFormatDuration(t *testing.T) {
 	assert := assert.New(t)
 	assert.Equal("0.0ms", string(FormatDuration(0*time.Microsecond)))
@@ -428,6 +499,42 @@ func TestFormatDuration(t *testing.T) {
 	assert.Equal("999999h", string(FormatDuration(999999*time.Hour)))
 }
 
+func TestDurationFormat(t *testing.T) {
+	assert := assert.New(t)
+	assert.Equal(" 10ms", DurationFormat{}.Format(10*time.Millisecond))
+	assert.Equal("10ms", DurationFormat{Trim: true}.Format(10*time.Millisecond))
+	assert.Equal("1.2346s", DurationFormat{Width: 6}.Format(1234567*time.Microsecond))
+	assert.Equal("12.35ms", DurationFormat{Width: 6}.Format(12345678*time.Nanosecond))
+	assert.Equal("120µs", DurationFormat{SubMillisecond: true}.Format(120*time.Microsecond))
+	assert.Equal(" 45ns", DurationFormat{SubMillisecond: true}.Format(45*time.Nanosecond))
+	assert.Equal("1.5ms", DurationFormat{SubMillisecond: true}.Format(1500*time.Microsecond))
+	assert.Equal("1:05", DurationFormat{Clock: true}.Format(65*time.Second))
+	assert.Equal("1:02:03", DurationFormat{Clock: true}.Format(3723*time.Second))
+	assert.Equal("   1:05", DurationFormat{Clock: true, Width: 7}.Format(65*time.Second))
+}
+
+func TestParseDuration(t *testing.T) {
+	assert := assert.New(t)
+	for _, d := range []time.Duration{5 * time.Millisecond, 250 * time.Millisecond, 1500 * time.Millisecond, 100 * time.Second, 30 * time.Minute, 20 * time.Hour} {
+		parsed, err := ParseDuration(FormatDuration(d))
+		assert.NoError(err)
+		assert.Equal(d, parsed)
+	}
+	parsed, err := ParseDuration("120µs")
+	assert.NoError(err)
+	assert.Equal(120*time.Microsecond, parsed)
+	parsed, err = ParseDuration("1:02:03")
+	assert.NoError(err)
+	assert.Equal(3723*time.Second, parsed)
+	parsed, err = ParseDuration("1m30s")
+	assert.NoError(err)
+	assert.Equal(90*time.Second, parsed)
+	_, err = ParseDuration("soon")
+	assert.Error(err)
+	_, err = ParseDuration("1:xx")
+	assert.Error(err)
+}
+
 func TestLoggerInception(t *testing.T) {
 	assert := assert.New(t)
 	var buf bytes.Buffer
@@ -471,3 +578,292 @@ func TestLoggerInception(t *testing.T) {
 // TODO test &/or implement:
 // - Set custom ANSI template regexp specifically or globally
 // - Handle \b and \t characters intelligently
+
+func TestMaxPartialLineBytes(t *testing.T) {
+	assert := assert.New(t)
+	var buf bytes.Buffer
+	var writer = New(&buf, "", 0)
+	writer.SetTerminalWidth(80)
+	writer.EnableColor()
+	writer.HidePartialLines()
+	defer writer.Close()
+	writer.SetMaxPartialLineBytes(10)
+	writer.Print("\033[31mabc")
+	writer.Print("defghijk")
+	assert.Equal("\033[31mabcdefghijk\033[39m\n", buf.String())
+	buf.Reset()
+	writer.Print("lm\n")
+	assert.Equal("\033[31mlm\033[39m\n", buf.String())
+}
+
+func TestWriteSplitRune(t *testing.T) {
+	assert := assert.New(t)
+	var buf bytes.Buffer
+	var writer = New(&buf, "", 0)
+	writer.SetTerminalWidth(80)
+	defer writer.Close()
+	snowman := []byte("☃")
+	writer.Write([]byte("a"))
+	writer.Write(snowman[:1])
+	assert.Equal("a", buf.String())
+	writer.Write(snowman[1:2])
+	writer.Write(append(snowman[2:], '\n'))
+	assert.Equal("a☃\n", buf.String())
+	buf.Reset()
+	writer.Write(snowman[:2])
+	writer.Flush()
+	assert.Equal("\xe2\x98\n", buf.String())
+}
+
+func TestDisableTput(t *testing.T) {
+	assert := assert.New(t)
+	DisableTput()
+	defer EnableTput()
+	assert.Equal("\033[1A", tput("cuu", "1"))
+	assert.Equal("\033[1B", tput("cud", "1"))
+}
+
+func TestHideCursorDuringRedraw(t *testing.T) {
+	assert := assert.New(t)
+	var buf bytes.Buffer
+	writer1 := New(&buf, "", 0)
+	writer2 := New(&buf, "", 0)
+	writer1.SetTerminalWidth(80)
+	writer1.EnableMultilineMode()
+	defer writer1.Close()
+	defer writer2.Close()
+	writer1.Print("writer1")
+	writer2.Print("writer2")
+	buf.Reset()
+	writer2.Print("...")
+	assert.Equal("...", buf.String(), "no need to hide the cursor if it doesn't move")
+	buf.Reset()
+	writer1.Print("...")
+	assert.Equal("\033[?25l"+tput("cuu", "1")+"\r\033[7C...\033[?25h", buf.String())
+}
+
+func TestMultilineHeightCap(t *testing.T) {
+	assert := assert.New(t)
+	var buf bytes.Buffer
+	lineUp := tput("cuu", "1")
+	writers := []*Logger{}
+	for i := 0; i < 4; i++ {
+		writers = append(writers, New(&buf, "", 0))
+	}
+	writers[0].SetTerminalWidth(80)
+	writers[0].SetTerminalHeight(3)
+	writers[0].EnableMultilineMode()
+	for i, writer := range writers {
+		writer.Printf("writer%d", i)
+	}
+	s := strings.Replace(buf.String(), lineUp, "{UP}", -1)
+	s = strings.Replace(s, tput("cud", "1"), "{DOWN}", -1)
+	assert.True(strings.HasSuffix(s, "\033[?25l{UP}\r+3 more: writer0 | writer1 | writer2{DOWN}\r\033[6C3\033[?25h"), "%q", s)
+	buf.Reset()
+	for _, writer := range writers {
+		writer.Close()
+	}
+}
+
+func TestStdLoggers(t *testing.T) {
+	assert := assert.New(t)
+	assert.Equal(os.Stdout, Stdout.out)
+	assert.Equal(os.Stderr, Stderr.out)
+	assert.Equal(DefaultLogger.prefix, Stdout.prefix)
+	assert.False(Stdout.colorEnabled.isSet())
+	f, err := os.CreateTemp(t.TempDir(), "")
+	assert.NoError(err)
+	defer f.Close()
+	assert.False(sameTerminal(f, f))
+}
+
+func TestStdLoggersFollowDefaultLogger(t *testing.T) {
+	assert := assert.New(t)
+	var buf bytes.Buffer
+	defer func(prefix string, flag int) {
+		Stdout.SetOutput(os.Stdout)
+		Stdout.ownPrefix = false
+		SetPrefix(prefix)
+		SetFlags(flag)
+	}(Prefix(), Flags())
+	Stdout.SetOutput(&buf)
+	SetPrefix("[app] ")
+	SetFlags(0)
+	Stdout.Printf("hello\n")
+	assert.Equal("[app] hello\n", buf.String())
+	buf.Reset()
+	Stdout.SetPrefix("[out] ")
+	SetPrefix("[other] ")
+	Stdout.Printf("hello\n")
+	assert.Equal("[out] hello\n", buf.String(), "a prefix of its own takes precedence")
+}
+
+func TestSameTerminalSharesWriterState(t *testing.T) {
+	assert := assert.New(t)
+	defer func() { isSharedTerminal = isTerminal }()
+	isSharedTerminal = func(f *os.File) bool { return true }
+	path := filepath.Join(t.TempDir(), "tty")
+	a, err := os.Create(path)
+	assert.NoError(err)
+	defer a.Close()
+	b, err := os.OpenFile(path, os.O_WRONLY, 0)
+	assert.NoError(err)
+	defer b.Close()
+	other, err := os.Create(filepath.Join(t.TempDir(), "other"))
+	assert.NoError(err)
+	defer other.Close()
+	assert.True(sameTerminal(a, b))
+	assert.Same(getWriterState(a), getWriterState(b))
+	assert.NotSame(getWriterState(a), getWriterState(other))
+
+	writerA := New(a, "", 0)
+	writerB := New(b, "", 0)
+	defer writerA.Close()
+	defer writerB.Close()
+	writerA.SetTerminalWidth(80)
+	writerA.ShowPartialLines()
+	writerB.ShowPartialLines()
+	writerA.Print("task a...")
+	writerB.Print("task b...")
+	ws := getWriterState(a)
+	ws.lock()
+	assert.Len(ws.tempLoggers, 2, "both Loggers' partial lines are drawn together")
+	ws.unlock()
+}
+
+func TestAllWriterStatesUnique(t *testing.T) {
+	assert := assert.New(t)
+	var a, b bytes.Buffer
+	ws := getWriterState(&a)
+	mutexGlobal.Lock()
+	writers[&b] = ws
+	mutexGlobal.Unlock()
+	defer func() {
+		mutexGlobal.Lock()
+		delete(writers, &a)
+		delete(writers, &b)
+		mutexGlobal.Unlock()
+	}()
+	count := 0
+	for _, other := range allWriterStates() {
+		if other == ws {
+			count++
+		}
+	}
+	assert.Equal(1, count)
+	// Each is locked once, so these don't deadlock.
+	suspendAll()
+	continueAll()
+	redrawAfterResize()
+}
+
+func TestFatalWithSharedWriterState(t *testing.T) {
+	assert := assert.New(t)
+	if os.Getenv("ALOG_TEST_FATAL") == "1" {
+		// Share one WriterState between stdout and stderr, as on a terminal.
+		ws := getWriterState(os.Stdout)
+		mutexGlobal.Lock()
+		writers[os.Stderr] = ws
+		mutexGlobal.Unlock()
+		New(os.Stderr, "", 0).Print("partial")
+		New(os.Stdout, "", 0).Fatal("fatal")
+		return
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	cmd := exec.CommandContext(ctx, os.Args[0], "-test.run=^TestFatalWithSharedWriterState$")
+	cmd.Env = append(os.Environ(), "ALOG_TEST_FATAL=1")
+	out, err := cmd.CombinedOutput()
+	assert.NoError(ctx.Err(), "Fatal should not deadlock on a shared WriterState")
+	var exitErr *exec.ExitError
+	if assert.ErrorAs(err, &exitErr) {
+		assert.Equal(1, exitErr.ExitCode())
+	}
+	assert.Contains(string(out), "fatal\n")
+	assert.Regexp(`partial *\n`, string(out))
+}
+
+func TestAccessors(t *testing.T) {
+	assert := assert.New(t)
+	var buf bytes.Buffer
+	var writer = New(&buf, "", 0)
+	defer writer.Close()
+	assert.Equal(&buf, writer.Writer())
+	assert.False(writer.IsTerminal())
+	writer.DisableColor()
+	assert.False(writer.ColorEnabledEffective())
+	writer.EnableColor()
+	assert.True(writer.ColorEnabledEffective())
+}
+
+func TestSetPrefixFunc(t *testing.T) {
+	assert := assert.New(t)
+	var buf bytes.Buffer
+	var writer = New(&buf, "static: ", 0)
+	defer writer.Close()
+	writer.DisableColor()
+	requestID := "abc"
+	writer.SetPrefixFunc(func(e *Entry) string {
+		return fmt.Sprintf("[%s %s] ", requestID, e.Level)
+	})
+	writer.Println("one")
+	requestID = "def"
+	writer.Warn("two\n")
+	writer.SetPrefixFunc(nil)
+	writer.Println("three")
+	assert.Equal("[abc info] one\n[def warn] two\nstatic: three\n", buf.String())
+}
+
+func TestCountAndRateTemplates(t *testing.T) {
+	assert := assert.New(t)
+	var buf bytes.Buffer
+	var writer = New(&buf, "{count} {rate} ", 0)
+	defer writer.Close()
+	writer.DisableColor()
+	writer.ShowPartialLines()
+	now := time.Date(2020, 1, 2, 3, 4, 5, 0, time.UTC)
+	writer.SetClock(func() time.Time { return now })
+	for i := 1; i <= 4; i++ {
+		writer.Replacef("item %d", i)
+		now = now.Add(500 * time.Millisecond)
+	}
+	buf.Reset()
+	writer.Print("\n")
+	assert.Equal("\r4 2.0/s item 4\n", buf.String())
+}
+
+type loggingStringer struct{ l *Logger }
+
+func (s loggingStringer) String() string {
+	s.l.Printf("formatting\n")
+	return "value"
+}
+
+func TestConcurrentPrintf(t *testing.T) {
+	assert := assert.New(t)
+	var buf bytes.Buffer
+	var writer = New(&buf, "", Lshortfile)
+	defer writer.Close()
+	var wg sync.WaitGroup
+	for g := 0; g < 4; g++ {
+		wg.Add(1)
+		go func(g int) {
+			defer wg.Done()
+			for i := 0; i < 50; i++ {
+				writer.Printf("goroutine %d line %d\n", g, i)
+			}
+		}(g)
+	}
+	wg.Wait()
+	lines := strings.Split(strings.TrimSuffix(buf.String(), "\n"), "\n")
+	assert.Len(lines, 200)
+	for _, line := range lines {
+		assert.Regexp(`^log_test\.go:\d+: goroutine \d line \d+$`, line)
+	}
+	buf.Reset()
+	writer.Printf("%v\n", loggingStringer{writer})
+	assert.Regexp(`formatting\n.*: value\n$`, buf.String(), "arguments are formatted before taking the lock")
+	buf.Reset()
+	assert.PanicsWithValue("value\n", func() { writer.Panicf("%v\n", loggingStringer{writer}) })
+	assert.Regexp(`formatting\n.*: value\n$`, buf.String(), "Panicf formats its arguments before taking the lock")
+}
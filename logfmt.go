@@ -0,0 +1,134 @@
+package alog
+
+import (
+	"io"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// A LogfmtSink is an EntrySink that writes each completed line to an
+// io.Writer in logfmt, as understood by Heroku-style collectors:
+//
+//	time=2024-01-02T03:04:05Z level=warn msg="disk almost full" trace_id=4bf92f35
+//
+// The Entry's Fields follow the message.
+type LogfmtSink struct {
+	mutex sync.Mutex
+	out   io.Writer
+	// FieldOrder lists fields to write first, in this order; the others
+	// follow in the order they were added. Set it before adding the sink.
+	FieldOrder []string
+}
+
+// NewLogfmtSink returns a LogfmtSink writing to out.
+func NewLogfmtSink(out io.Writer) *LogfmtSink {
+	return &LogfmtSink{out: out}
+}
+
+// WriteEntry implements EntrySink.
+func (s *LogfmtSink) WriteEntry(e *Entry) error {
+	line := appendLogfmtField(nil, "time", e.Time.Format(time.RFC3339Nano))
+	line = appendLogfmtField(line, "level", e.Level.String())
+	if prefix := e.PlainPrefix(); prefix != "" {
+		line = appendLogfmtField(line, "prefix", strings.TrimSpace(prefix))
+	}
+	line = appendLogfmtField(line, "msg", e.PlainMessage())
+	for _, field := range orderFields(e.Fields, s.FieldOrder) {
+		line = appendLogfmtField(line, field.Key, field.Value)
+	}
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	_, err := s.out.Write(append(line, byteNewline))
+	return err
+}
+
+func appendLogfmtField(line []byte, key, value string) []byte {
+	if len(line) > 0 {
+		line = append(line, ' ')
+	}
+	line = append(line, key...)
+	line = append(line, '=')
+	return append(line, quoteFieldValue(value)...)
+}
+
+// orderFields returns fields with those named in order first, in that order,
+// followed by the rest in their original order.
+func orderFields(fields []Field, order []string) []Field {
+	if len(order) == 0 {
+		return fields
+	}
+	ordered := make([]Field, 0, len(fields))
+	for _, key := range order {
+		for _, field := range fields {
+			if field.Key == key {
+				ordered = append(ordered, field)
+			}
+		}
+	}
+	for _, field := range fields {
+		if !containsKey(order, field.Key) {
+			ordered = append(ordered, field)
+		}
+	}
+	return ordered
+}
+
+// parseLogfmtRecord parses a line of logfmt. Every part of the line must be
+// a key=value pair, so that ordinary text isn't mistaken for logfmt.
+func parseLogfmtRecord(line []byte, o *IngestOptions) (*ingestRecord, bool) {
+	record := &ingestRecord{}
+	s := string(line)
+	found := false
+	for {
+		s = strings.TrimLeft(s, " \t")
+		if s == "" {
+			break
+		}
+		index := strings.IndexAny(s, "= \t\"")
+		if index <= 0 || s[index] != '=' {
+			return nil, false
+		}
+		key := s[:index]
+		s = s[index+1:]
+		var value string
+		if strings.HasPrefix(s, `"`) {
+			end := quotedLength(s)
+			if end == -1 {
+				return nil, false
+			}
+			unquoted, err := strconv.Unquote(s[:end])
+			if err != nil {
+				return nil, false
+			}
+			value, s = unquoted, s[end:]
+			if s != "" && s[0] != ' ' && s[0] != '\t' {
+				return nil, false
+			}
+		} else {
+			end := strings.IndexAny(s, " \t")
+			if end == -1 {
+				end = len(s)
+			}
+			value, s = s[:end], s[end:]
+		}
+		record.add(key, value, true, o)
+		found = true
+	}
+	return record, found
+}
+
+// quotedLength returns the length of the double-quoted string s starts with,
+// quotes included, or -1 if it isn't terminated.
+func quotedLength(s string) int {
+	for i := 1; i < len(s); i++ {
+		switch s[i] {
+		case '\\':
+			i++
+		case '"':
+			return i + 1
+		}
+	}
+	return -1
+}
@@ -0,0 +1,51 @@
+package alog
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLogfmtSink(t *testing.T) {
+	assert := assert.New(t)
+	var buf, out bytes.Buffer
+	writer := New(&buf, "", 0)
+	defer writer.Close()
+	writer.SetClock(func() time.Time { return time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC) })
+	sink := NewLogfmtSink(&out)
+	writer.AddSink(sink)
+	writer.Warnf("disk almost full\n")
+	assert.Equal(`time=2024-01-02T03:04:05Z level=warn msg="disk almost full"`+"\n", out.String())
+	out.Reset()
+	sink.FieldOrder = []string{"user"}
+	sink.WriteEntry(&Entry{
+		Time:    time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC),
+		Message: `say "hi"`,
+		Fields:  []Field{{"a", "1"}, {"user", "bob"}, {"b", ""}},
+	})
+	assert.Equal(`time=2024-01-02T03:04:05Z level=info msg="say \"hi\"" user=bob a=1 b=""`+"\n", out.String())
+}
+
+func TestIngestLogfmt(t *testing.T) {
+	assert := assert.New(t)
+	var buf bytes.Buffer
+	writer := New(&buf, "", 0)
+	defer writer.Close()
+	input := `time=2024-01-02T03:04:05Z level=error msg="request failed" path=/api err="connection reset" attempt=3
+not logfmt at all
+key=value trailing"quote
+`
+	opts := &IngestOptions{FieldOrder: []string{"err"}, FieldStyles: map[string]string{"err": "red"}}
+	assert.NoError(writer.Ingest(strings.NewReader(input), opts))
+	assert.Equal(`request failed err="connection reset" path=/api attempt=3
+not logfmt at all
+key=value trailing"quote
+`, string(uncolorize(buf.Bytes())))
+	assert.Contains(buf.String(), string(styleEscapes("red"))+`"connection reset"`)
+	buf.Reset()
+	assert.NoError(writer.Ingest(strings.NewReader(`{"msg":"json"}`+"\n"), &IngestOptions{Format: IngestLogfmt}))
+	assert.Equal(`{"msg":"json"}`+"\n", buf.String())
+}
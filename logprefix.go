@@ -0,0 +1,69 @@
+package alog
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+	"sync"
+)
+
+var (
+	// kubectl logs --prefix: "[pod/web-7d9f/app] "
+	kubectlPrefixRegexp = regexp.MustCompile(`^\[([^\]\s/]+/[^\]\s]+)\] `)
+	// docker compose logs: "web-1  | " (or "web_1  | " before Compose v2)
+	composePrefixRegexp = regexp.MustCompile(`^([A-Za-z0-9][\w.-]*?) *\| `)
+	// --timestamps of docker and kubectl: RFC 3339 with up to nanoseconds
+	wrapperTimestampRegexp = regexp.MustCompile(`^\d{4}-\d\d-\d\dT\d\d:\d\d:\d\d(?:\.\d+)?(?:Z|[+-]\d\d:\d\d) `)
+)
+
+// ParseLogPrefix splits a line of output from docker compose logs or kubectl
+// logs into the name of the source that wrote it (the service, or
+// pod/name/container, as given by kubectl's --prefix), the timestamp added
+// by --timestamps, and the rest of the line. ok is false if the line has
+// neither prefix nor timestamp. ANSI escapes are removed from the line first.
+func ParseLogPrefix(line string) (source, timestamp, rest string, ok bool) {
+	rest = string(uncolorize([]byte(line)))
+	if match := kubectlPrefixRegexp.FindStringSubmatch(rest); match != nil {
+		source, rest = match[1], rest[len(match[0]):]
+	} else if match := composePrefixRegexp.FindStringSubmatch(rest); match != nil {
+		source, rest = match[1], rest[len(match[0]):]
+	}
+	if match := wrapperTimestampRegexp.FindString(rest); match != "" {
+		timestamp, rest = strings.TrimSpace(match), rest[len(match):]
+	}
+	if source == "" && timestamp == "" {
+		return "", "", line, false
+	}
+	return source, timestamp, rest, true
+}
+
+// NewLogPrefixFilter returns a LineFilter for output of docker compose logs or
+// kubectl logs, e.g. when shown with Follow. It drops the timestamps they
+// add, since the Logger adds its own, and rewrites their source prefixes with
+// each source in a color of its own, padded to the longest name seen so far.
+func NewLogPrefixFilter() LineFilter {
+	var mutex sync.Mutex
+	colors := map[string]string{}
+	width := 0
+	return func(line []byte) ([]byte, bool) {
+		source, _, rest, ok := ParseLogPrefix(string(line))
+		if !ok {
+			return line, true
+		}
+		if source == "" {
+			return []byte(rest), true
+		}
+		mutex.Lock()
+		color, ok := colors[source]
+		if !ok {
+			color = muxColors[len(colors)%len(muxColors)]
+			colors[source] = color
+		}
+		if n := stringLen([]byte(source)); n > width {
+			width = n
+		}
+		padding := strings.Repeat(" ", width-stringLen([]byte(source)))
+		mutex.Unlock()
+		return []byte(fmt.Sprintf("%s%s%s |\033[0m %s", styleEscapes(color), source, padding, rest)), true
+	}
+}
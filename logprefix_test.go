@@ -0,0 +1,64 @@
+package alog
+
+import (
+	"sort"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseLogPrefix(t *testing.T) {
+	assert := assert.New(t)
+	for _, test := range []struct{ line, source, timestamp, rest string }{
+		{"web-1  | listening on :80", "web-1", "", "listening on :80"},
+		{"db_1 | \033[1mready\033[0m", "db_1", "", "ready"},
+		{"[pod/api-7d9f/app] started", "pod/api-7d9f/app", "", "started"},
+		{"[pod/api-7d9f/app] 2024-01-02T03:04:05.123456789Z started", "pod/api-7d9f/app", "2024-01-02T03:04:05.123456789Z", "started"},
+		{"worker-2  | 2024-01-02T03:04:05+01:00 busy", "worker-2", "2024-01-02T03:04:05+01:00", "busy"},
+		{"2024-01-02T03:04:05Z plain docker", "", "2024-01-02T03:04:05Z", "plain docker"},
+	} {
+		source, timestamp, rest, ok := ParseLogPrefix(test.line)
+		assert.True(ok, test.line)
+		assert.Equal(test.source, source, test.line)
+		assert.Equal(test.timestamp, timestamp, test.line)
+		assert.Equal(test.rest, rest, test.line)
+	}
+	for _, line := range []string{"[not a pod] x", "plain", "a || b"} {
+		_, _, rest, ok := ParseLogPrefix(line)
+		assert.False(ok, line)
+		assert.Equal(line, rest)
+	}
+}
+
+func TestLogPrefixFilter(t *testing.T) {
+	assert := assert.New(t)
+	filter := NewLogPrefixFilter()
+	line, _ := filter([]byte("db | ready"))
+	assert.Equal("db | ready", string(uncolorize(line)))
+	line, _ = filter([]byte("web-1  | 2024-01-02T03:04:05Z up"))
+	assert.Equal("web-1 | up", string(uncolorize(line)))
+	line, _ = filter([]byte("db | still ready"))
+	assert.Equal("db    | still ready", string(uncolorize(line)))
+	line, _ = filter([]byte("no prefix"))
+	assert.Equal("no prefix", string(line))
+}
+
+func TestMuxPrefixParsing(t *testing.T) {
+	assert := assert.New(t)
+	SetClock(func() time.Time { return time.Date(2020, 1, 2, 3, 4, 5, 0, time.UTC) })
+	defer SetClock(nil)
+	var out syncBuffer
+	mux := NewMux(&out)
+	mux.SetPrefixParsing(true)
+	mux.AddReader("compose", strings.NewReader("web-1  | up\ndb-1   | 2020-01-02T03:04:00Z ready\nbare line\n"))
+	assert.NoError(mux.Wait())
+	output := strings.Split(strings.TrimSuffix(string(uncolorize([]byte(out.String()))), "\n"), "\n")
+	sort.Strings(output)
+	assert.Equal([]string{
+		"03:04:05 compose | bare line",
+		"03:04:05 db-1    | ready",
+		"03:04:05 web-1   | up",
+	}, output)
+}
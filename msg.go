@@ -0,0 +1,61 @@
+package alog
+
+import "bytes"
+
+// Msg writes msg, followed by a newline, to the logger. Unlike Printf, it does
+// not apply color templates or formatting verbs; msg is written literally.
+//
+// For a Logger used as New returned it, with no setters called since, Msg
+// takes a fast path when nothing else is in play -- no partial lines pending
+// on the writer, no ANSI escapes, tabs, carriage returns or embedded newlines
+// in msg, no prefix templates, and no caller info or elapsed flags. It formats
+// the line directly into the Logger's scratch buffer and hands it to the
+// writer without allocating (once the writer's buffers have warmed up).
+// Otherwise it falls back to the regular output path.
+func (l *Logger) Msg(msg string) {
+	if !l.IsEnabled() {
+		return
+	}
+	ws := getWriterState(l.out)
+	ws.lock()
+	defer ws.unlock()
+	if LevelInfo < l.minLevel {
+		return
+	}
+	if !l.canUseFastPath(ws, msg) {
+		l.intOutput(2, append([]byte(msg), byteNewline), true)
+		return
+	}
+	l.updateNow()
+	l.tmp = l.tmp[:0]
+	l.tmp = append(l.tmp, l.prefixFormatted...)
+	l.formatFlagsHeader(&l.tmp)
+	l.tmp = append(l.tmp, msg...)
+	l.countLine(l.tmp)
+	l.tmp = append(l.tmp, byteNewline)
+	ws.write(l.tmp)
+}
+
+func (l *Logger) canUseFastPath(ws *WriterState, msg string) bool {
+	if l.customized || l.isClosed || len(l.buf) > 0 || l.flag&(Lshortfile|Llongfile|Lelapsed) != 0 {
+		return false
+	}
+	if ws.ci != NoCI || ws.multiline || !ws.cursorIsAtBegin || len(ws.tempLoggers) > 0 || len(ws.lastTemp.get(0)) > 0 {
+		return false
+	}
+	if bytes.IndexByte(l.prefixFormatted, '{') != -1 || bytes.IndexByte(l.prefixFormatted, '\033') != -1 {
+		return false
+	}
+	for i := 0; i < len(msg); i++ {
+		switch msg[i] {
+		case '\n', '\r', '\t', '\033':
+			return false
+		}
+	}
+	return true
+}
+
+// Msg writes msg, followed by a newline, to the standard logger.
+func Msg(msg string) {
+	DefaultLogger.Msg(msg)
+}
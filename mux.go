@@ -0,0 +1,241 @@
+package alog
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os/exec"
+	"regexp"
+	"strings"
+	"sync"
+)
+
+// muxColors are assigned to the sources of a Mux in turn.
+var muxColors = []string{"cyan", "yellow", "green", "magenta", "blue", "red"}
+
+// A Mux merges lines from several sources (readers, channels, child
+// processes) into one writer, like docker-compose logs does for containers.
+// Each line is written as soon as it arrives, whole, behind a prefix with the
+// time and the name of its source in a color of its own. Lines are styled by
+// their level as guessed from their text (see SetSeverityDetection).
+type Mux struct {
+	out       io.Writer
+	mutex     sync.Mutex
+	loggers   []*Logger
+	names     []string
+	nameWidth int
+	wg        sync.WaitGroup
+	err       error
+
+	severityDetection bool
+	severityRules     []severityRule
+	parsePrefixes     bool
+}
+
+// NewMux creates a Mux writing to out.
+func NewMux(out io.Writer) *Mux {
+	return &Mux{out: out, severityDetection: true}
+}
+
+// SetSeverityDetection sets whether the level of each line is guessed from its
+// text, as by Logger.SetSeverityDetection. On by default.
+func (m *Mux) SetSeverityDetection(flag bool) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	m.severityDetection = flag
+	for _, logger := range m.loggers {
+		logger.SetSeverityDetection(flag)
+	}
+}
+
+// AddSeverityRule adds a rule for guessing the level of lines from all
+// sources, as by Logger.AddSeverityRule.
+func (m *Mux) AddSeverityRule(pattern *regexp.Regexp, level Level) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	m.severityRules = append(m.severityRules, severityRule{pattern, level})
+	for _, logger := range m.loggers {
+		logger.AddSeverityRule(pattern, level)
+	}
+}
+
+// SetPrefixParsing sets whether lines are checked for the prefixes added by
+// docker compose logs and kubectl logs (see ParseLogPrefix), so that the
+// output of those can be added as a single source. Each line with such a
+// prefix is shown as coming from a source named after the service or pod
+// it's from instead, and the timestamp added by --timestamps is dropped. Off
+// by default.
+func (m *Mux) SetPrefixParsing(flag bool) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	m.parsePrefixes = flag
+}
+
+// addSource creates the Logger for a new source.
+func (m *Mux) addSource(name string) *Logger {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	m.wg.Add(1)
+	return m.newLogger(name)
+}
+
+// println writes a line from the source logger, or from the source named in
+// the line's prefix if prefix parsing is on.
+func (m *Mux) println(logger *Logger, line string) {
+	m.mutex.Lock()
+	if m.parsePrefixes {
+		if source, _, rest, ok := ParseLogPrefix(line); ok {
+			line = rest
+			if source != "" {
+				logger = m.sourceLogger(source)
+			}
+		}
+	}
+	m.mutex.Unlock()
+	logger.Println(line)
+}
+
+// sourceLogger returns the Logger of the named source, creating it if there
+// isn't one. Must be called with m.mutex held.
+func (m *Mux) sourceLogger(name string) *Logger {
+	for i, existing := range m.names {
+		if existing == name {
+			return m.loggers[i]
+		}
+	}
+	return m.newLogger(name)
+}
+
+// newLogger creates the Logger for a source, widening the name column of the
+// other sources' prefixes if needed. Must be called with m.mutex held.
+func (m *Mux) newLogger(name string) *Logger {
+	color := muxColors[len(m.loggers)%len(muxColors)]
+	logger := New(m.out, "", 0)
+	logger.SetPartialLinesEnabled(false)
+	// Lines from sources are written as is.
+	logger.SetColorTemplateEnabled(false)
+	logger.SetSeverityDetection(m.severityDetection)
+	for _, rule := range m.severityRules {
+		logger.AddSeverityRule(rule.pattern, rule.level)
+	}
+	m.loggers = append(m.loggers, logger)
+	m.names = append(m.names, name)
+	if stringLen([]byte(name)) > m.nameWidth {
+		m.nameWidth = stringLen([]byte(name))
+		for i, logger := range m.loggers[:len(m.loggers)-1] {
+			logger.SetPrefix(m.prefix(m.names[i], muxColors[i%len(muxColors)]))
+		}
+	}
+	logger.SetPrefix(m.prefix(name, color))
+	return logger
+}
+
+func (m *Mux) prefix(name, color string) string {
+	padding := strings.Repeat(" ", m.nameWidth-stringLen([]byte(name)))
+	return fmt.Sprintf("%s{time}\033[0m %s%s%s |\033[0m ", styleEscapes("dim"), styleEscapes(color), name, padding)
+}
+
+// AddReader adds a source reading lines from r until EOF.
+func (m *Mux) AddReader(name string, r io.Reader) {
+	logger := m.addSource(name)
+	go func() {
+		defer m.wg.Done()
+		m.copyLines(name, logger, r)
+	}()
+}
+
+// AddChan adds a source receiving lines from lines until it is closed.
+func (m *Mux) AddChan(name string, lines <-chan string) {
+	logger := m.addSource(name)
+	go func() {
+		defer m.wg.Done()
+		for line := range lines {
+			m.println(logger, line)
+		}
+	}()
+}
+
+// AddCommand starts cmd and adds its stdout and stderr as a source. When it
+// exits unsuccessfully, the error is logged under its name, and returned by
+// Wait.
+func (m *Mux) AddCommand(name string, cmd *exec.Cmd) error {
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		return err
+	}
+	stderr, err := cmd.StderrPipe()
+	if err != nil {
+		return err
+	}
+	if err := cmd.Start(); err != nil {
+		return err
+	}
+	logger := m.addSource(name)
+	go func() {
+		defer m.wg.Done()
+		var wg sync.WaitGroup
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			m.copyLines(name, logger, stderr)
+		}()
+		m.copyLines(name, logger, stdout)
+		wg.Wait()
+		if err := cmd.Wait(); err != nil {
+			logger.Errorf("%s: %v\n", name, err)
+			m.mutex.Lock()
+			if m.err == nil {
+				m.err = err
+			}
+			m.mutex.Unlock()
+		}
+	}()
+	return nil
+}
+
+// maxMuxLineLength is the length at which long lines from sources are split.
+var maxMuxLineLength = 1 << 20
+
+// copyLines writes the lines read from r until EOF. Lines longer than
+// maxMuxLineLength are split, so that r is always drained; a read error is
+// reported under the source's name. The line buffer only grows as long lines
+// require.
+func (m *Mux) copyLines(name string, logger *Logger, r io.Reader) {
+	reader := bufio.NewReader(r)
+	var line []byte
+	split := false
+	for {
+		chunk, err := reader.ReadSlice('\n')
+		line = append(line, chunk...)
+		for len(line) > maxMuxLineLength {
+			m.println(logger, string(line[:maxMuxLineLength]))
+			line = line[:copy(line, line[maxMuxLineLength:])]
+			split = true
+		}
+		if err == bufio.ErrBufferFull {
+			continue
+		}
+		text := strings.TrimSuffix(strings.TrimSuffix(string(line), "\n"), "\r")
+		// A newline right after a split is the end of the split line.
+		if len(line) > 0 && !(split && text == "") {
+			m.println(logger, text)
+		}
+		line = line[:0]
+		split = false
+		if err != nil {
+			if err != io.EOF {
+				logger.Errorf("%s: %v\n", name, err)
+			}
+			return
+		}
+	}
+}
+
+// Wait waits for all sources to finish and returns the error of the first
+// command that failed, if any.
+func (m *Mux) Wait() error {
+	m.wg.Wait()
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	return m.err
+}
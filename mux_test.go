@@ -0,0 +1,83 @@
+package alog
+
+import (
+	"errors"
+	"io"
+	"os/exec"
+	"sort"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMux(t *testing.T) {
+	assert := assert.New(t)
+	SetClock(func() time.Time { return time.Date(2020, 1, 2, 3, 4, 5, 0, time.UTC) })
+	defer SetClock(nil)
+	var out syncBuffer
+	mux := NewMux(&out)
+	mux.AddReader("db", strings.NewReader("ready\n"))
+	lines := make(chan string)
+	mux.AddChan("worker", lines)
+	lines <- "started"
+	close(lines)
+	assert.NoError(mux.AddCommand("sh", exec.Command("sh", "-c", "echo hi; exit 3")))
+	assert.Error(mux.Wait())
+	output := strings.Split(strings.TrimSuffix(string(uncolorize([]byte(out.String()))), "\n"), "\n")
+	sort.Strings(output)
+	assert.Equal([]string{
+		"03:04:05 db     | ready",
+		"03:04:05 sh     | hi",
+		"03:04:05 sh     | sh: exit status 3",
+		"03:04:05 worker | started",
+	}, output)
+}
+
+func TestMuxLongLines(t *testing.T) {
+	assert := assert.New(t)
+	defer func(prev int) { maxMuxLineLength = prev }(maxMuxLineLength)
+	maxMuxLineLength = 10
+	var out syncBuffer
+	mux := NewMux(&out)
+	long := strings.Repeat("x", maxMuxLineLength+5)
+	mux.AddReader("reader", strings.NewReader(long+"\nnext\n"))
+	// A source with lines several times the reader's buffer is drained too.
+	huge := strings.Repeat("y", 3*maxMuxLineLength+4096*2)
+	mux.AddReader("huge", strings.NewReader(huge+"\nafter\n"))
+	assert.NoError(mux.Wait())
+	var reader, other []string
+	for _, line := range strings.Split(strings.TrimSuffix(string(uncolorize([]byte(out.String()))), "\n"), "\n") {
+		if i := strings.Index(line, " | "); i >= 0 {
+			if strings.Contains(line[:i], "reader") {
+				reader = append(reader, line[i+3:])
+			} else {
+				other = append(other, line[i+3:])
+			}
+		}
+	}
+	assert.Equal([]string{long[:maxMuxLineLength], "xxxxx", "next"}, reader)
+	if assert.NotEmpty(other) {
+		assert.Equal(huge, strings.Join(other[:len(other)-1], ""))
+		for _, line := range other {
+			assert.LessOrEqual(len(line), maxMuxLineLength)
+		}
+		assert.Equal("after", other[len(other)-1])
+	}
+}
+
+type errReader struct{}
+
+func (errReader) Read(p []byte) (int, error) { return 0, errors.New("read failed") }
+
+func TestMuxReadError(t *testing.T) {
+	assert := assert.New(t)
+	var out syncBuffer
+	mux := NewMux(&out)
+	mux.AddReader("src", io.MultiReader(strings.NewReader("partial\n"), errReader{}))
+	assert.NoError(mux.Wait())
+	output := string(uncolorize([]byte(out.String())))
+	assert.Contains(output, "src | partial\n")
+	assert.Contains(output, "src | src: read failed\n")
+}
@@ -0,0 +1,255 @@
+package alog
+
+import (
+	"bytes"
+	"encoding/json"
+	"net"
+	"sync"
+	"time"
+)
+
+// A NetFormat selects how a NetWriter encodes lines.
+type NetFormat int
+
+const (
+	// NetFormatPlain sends each line as ANSI-stripped text followed by a newline.
+	NetFormatPlain NetFormat = iota
+	// NetFormatJSON sends each line as a JSON object followed by a newline.
+	NetFormatJSON
+)
+
+// NetWriterOptions configures a NetWriter. The zero value is usable.
+type NetWriterOptions struct {
+	Format      NetFormat
+	MaxBuffered int           // lines kept while disconnected; the oldest are dropped beyond this (default 10000)
+	MinBackoff  time.Duration // delay before the first reconnection attempt (default 100ms)
+	MaxBackoff  time.Duration // upper bound on the delay between attempts (default 30s)
+	DialTimeout time.Duration // default 5s
+	// WriteTimeout bounds each write to the collector; a write that takes
+	// longer is treated as a failed connection (default 10s).
+	WriteTimeout time.Duration
+}
+
+// A NetWriter ships log lines to a collector over TCP, UDP or a Unix socket.
+// Lines are buffered locally and sent from a background goroutine, which
+// reconnects with exponential backoff whenever the connection fails, so
+// logging never blocks on the network.
+//
+// A NetWriter is usually added to a Logger as an EntrySink, so that lines are
+// shipped in addition to being rendered locally:
+//
+//	nw := alog.NewNetWriter("tcp", "collector:5170", nil)
+//	defer nw.Close()
+//	alog.AddSink(nw)
+//
+// It is also an io.Writer, for use as the output of a Logger of its own; each
+// completed line written to it is shipped as a message, as it would be left on
+// a terminal: temporary lines that were redrawn and escape sequences are not
+// sent.
+type NetWriter struct {
+	network string
+	addr    string
+	opts    NetWriterOptions
+
+	mutex   sync.Mutex
+	cond    *sync.Cond
+	lines   [][]byte // encoded lines waiting to be sent
+	partial []byte   // incomplete line passed to Write
+	dropped int
+	closed  bool
+	closing chan struct{} // closed by Close, to cut short a backoff
+	done    chan struct{}
+
+	attempted bool // whether run made an attempt since Close was called
+}
+
+// NewNetWriter returns a NetWriter that sends to addr on the named network
+// (see net.Dial). opts may be nil. The connection is established in the
+// background.
+func NewNetWriter(network, addr string, opts *NetWriterOptions) *NetWriter {
+	w := &NetWriter{network: network, addr: addr, closing: make(chan struct{}), done: make(chan struct{})}
+	if opts != nil {
+		w.opts = *opts
+	}
+	if w.opts.MaxBuffered <= 0 {
+		w.opts.MaxBuffered = 10000
+	}
+	if w.opts.MinBackoff <= 0 {
+		w.opts.MinBackoff = 100 * time.Millisecond
+	}
+	if w.opts.MaxBackoff < w.opts.MinBackoff {
+		w.opts.MaxBackoff = 30 * time.Second
+	}
+	if w.opts.DialTimeout <= 0 {
+		w.opts.DialTimeout = 5 * time.Second
+	}
+	if w.opts.WriteTimeout <= 0 {
+		w.opts.WriteTimeout = 10 * time.Second
+	}
+	w.cond = sync.NewCond(&w.mutex)
+	go w.run()
+	return w
+}
+
+type netEntry struct {
+	Time    time.Time `json:"time"`
+	Level   string    `json:"level"`
+	Prefix  string    `json:"prefix,omitempty"`
+	Message string    `json:"message"`
+}
+
+// WriteEntry queues a completed line to be sent. It implements EntrySink.
+func (w *NetWriter) WriteEntry(e *Entry) error {
+	var line []byte
+	if w.opts.Format == NetFormatJSON {
+		line, _ = json.Marshal(netEntry{Time: e.Time, Level: e.Level.String(), Prefix: e.PlainPrefix(), Message: e.PlainMessage()})
+	} else {
+		line = append([]byte(e.PlainPrefix()), e.PlainMessage()...)
+	}
+	w.enqueue(append(line, byteNewline))
+	return nil
+}
+
+// Write queues every complete line in p to be sent; an incomplete trailing
+// line is held until the rest of it arrives. Anything that was overwritten by
+// a carriage return is dropped, along with escape sequences.
+func (w *NetWriter) Write(p []byte) (int, error) {
+	w.mutex.Lock()
+	w.partial = append(w.partial, p...)
+	var lines [][]byte
+	for {
+		index := bytes.IndexByte(w.partial, '\n')
+		if index == -1 {
+			break
+		}
+		line := w.partial[:index]
+		if cr := bytes.LastIndexByte(line, '\r'); cr != -1 {
+			line = line[cr+1:]
+		}
+		lines = append(lines, ansiEscapeRegexp.ReplaceAll(line, bytesEmpty))
+		w.partial = w.partial[index+1:]
+	}
+	w.mutex.Unlock()
+	for _, line := range lines {
+		w.WriteEntry(&Entry{Time: time.Now(), Message: string(line)})
+	}
+	return len(p), nil
+}
+
+func (w *NetWriter) enqueue(line []byte) {
+	w.mutex.Lock()
+	defer w.mutex.Unlock()
+	if w.closed {
+		return
+	}
+	if len(w.lines) >= w.opts.MaxBuffered {
+		w.lines = w.lines[1:]
+		w.dropped++
+	}
+	w.lines = append(w.lines, line)
+	w.cond.Signal()
+}
+
+// Dropped returns the number of lines discarded so far because the buffer was
+// full while the collector was unreachable.
+func (w *NetWriter) Dropped() int {
+	w.mutex.Lock()
+	defer w.mutex.Unlock()
+	return w.dropped
+}
+
+// Close stops the NetWriter after making one last attempt to send any
+// buffered lines.
+func (w *NetWriter) Close() error {
+	w.mutex.Lock()
+	if !w.closed {
+		w.closed = true
+		close(w.closing)
+	}
+	w.cond.Signal()
+	w.mutex.Unlock()
+	<-w.done
+	return nil
+}
+
+// next waits for buffered lines and returns them, or nil once closed and empty.
+func (w *NetWriter) next() [][]byte {
+	w.mutex.Lock()
+	defer w.mutex.Unlock()
+	for len(w.lines) == 0 && !w.closed {
+		w.cond.Wait()
+	}
+	lines := w.lines
+	w.lines = nil
+	return lines
+}
+
+// requeue puts unsent lines back at the front of the buffer.
+func (w *NetWriter) requeue(lines [][]byte) {
+	w.mutex.Lock()
+	defer w.mutex.Unlock()
+	w.lines = append(lines, w.lines...)
+	if excess := len(w.lines) - w.opts.MaxBuffered; excess > 0 {
+		w.lines = w.lines[excess:]
+		w.dropped += excess
+	}
+}
+
+func (w *NetWriter) isClosed() bool {
+	w.mutex.Lock()
+	defer w.mutex.Unlock()
+	return w.closed
+}
+
+// shouldStop reports whether run should give up: once closed, we only make one
+// more attempt after a failure rather than retrying forever.
+func (w *NetWriter) shouldStop(conn net.Conn) bool {
+	return conn == nil && w.isClosed() && w.attempted
+}
+
+func (w *NetWriter) run() {
+	defer close(w.done)
+	var conn net.Conn
+	backoff := w.opts.MinBackoff
+	for !w.shouldStop(conn) {
+		lines := w.next()
+		if lines == nil {
+			break
+		}
+		if conn == nil {
+			w.attempted = w.isClosed()
+			var err error
+			conn, err = net.DialTimeout(w.network, w.addr, w.opts.DialTimeout)
+			if err != nil {
+				conn = nil
+				w.requeue(lines)
+				if w.isClosed() {
+					break
+				}
+				select {
+				case <-time.After(backoff):
+				case <-w.closing:
+				}
+				if backoff *= 2; backoff > w.opts.MaxBackoff {
+					backoff = w.opts.MaxBackoff
+				}
+				continue
+			}
+			backoff = w.opts.MinBackoff
+		}
+		for i, line := range lines {
+			conn.SetWriteDeadline(time.Now().Add(w.opts.WriteTimeout))
+			if n, err := conn.Write(line); err != nil {
+				conn.Close()
+				conn = nil
+				// Only resend what didn't make it.
+				lines[i] = line[n:]
+				w.requeue(lines[i:])
+				break
+			}
+		}
+	}
+	if conn != nil {
+		conn.Close()
+	}
+}
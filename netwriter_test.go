@@ -0,0 +1,104 @@
+package alog
+
+import (
+	"bufio"
+	"bytes"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNetWriter(t *testing.T) {
+	assert := assert.New(t)
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Skip("can't listen on loopback:", err)
+	}
+	defer listener.Close()
+	received := make(chan string, 10)
+	go func() {
+		conn, err := listener.Accept()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		scanner := bufio.NewScanner(conn)
+		for scanner.Scan() {
+			received <- scanner.Text()
+		}
+	}()
+	nw := NewNetWriter("tcp", listener.Addr().String(), &NetWriterOptions{Format: NetFormatJSON})
+	var buf bytes.Buffer
+	writer := New(&buf, "$$ ", 0)
+	writer.AddSink(nw)
+	writer.Errorf("broken\n")
+	writer.Close()
+	nw.Close()
+	assert.Equal("$$ \033[31mbroken\033[39m\n", buf.String(), "lines are still rendered locally")
+	assert.Regexp(`^\{"time":"[^"]+","level":"error","prefix":"\$\$ ","message":"broken"\}$`, <-received)
+}
+
+func TestMsgWithSink(t *testing.T) {
+	assert := assert.New(t)
+	var buf bytes.Buffer
+	var recorder entryRecorder
+	writer := New(&buf, "", 0)
+	defer writer.Close()
+	writer.AddSink(&recorder)
+	writer.Msg("hello")
+	assert.Equal("hello\n", buf.String())
+	if assert.Len(recorder.entries, 1) {
+		assert.Equal("hello", recorder.entries[0].PlainMessage())
+	}
+}
+
+func TestNetWriterCloseDuringBackoff(t *testing.T) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Skip("can't listen on loopback:", err)
+	}
+	// Nothing listens on the address once closed, so every dial fails.
+	addr := listener.Addr().String()
+	listener.Close()
+	nw := NewNetWriter("tcp", addr, &NetWriterOptions{MinBackoff: time.Hour})
+	nw.Write([]byte("lost\n"))
+	time.Sleep(10 * time.Millisecond)
+	closed := make(chan struct{})
+	go func() {
+		nw.Close()
+		close(closed)
+	}()
+	select {
+	case <-closed:
+	case <-time.After(5 * time.Second):
+		t.Fatal("Close waited for the backoff to expire")
+	}
+}
+
+func TestNetWriterStripsRedraws(t *testing.T) {
+	assert := assert.New(t)
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Skip("can't listen on loopback:", err)
+	}
+	defer listener.Close()
+	received := make(chan string, 10)
+	go func() {
+		conn, err := listener.Accept()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		scanner := bufio.NewScanner(conn)
+		for scanner.Scan() {
+			received <- scanner.Text()
+		}
+	}()
+	nw := NewNetWriter("tcp", listener.Addr().String(), nil)
+	nw.Write([]byte("working...\r\033[K\033[2Ado"))
+	nw.Write([]byte("ne \033[31mred\033[39m\033]0;title\007\n"))
+	nw.Close()
+	assert.Equal("done red", <-received)
+}
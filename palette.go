@@ -0,0 +1,111 @@
+package alog
+
+import (
+	"fmt"
+	"os"
+	"strings"
+)
+
+// A Palette maps semantic color names, as used in color templates like
+// "@(error:...)" and by the built-in widgets, to the colors used for them.
+type Palette map[string]ColorCode
+
+// Palettes are the built-in palettes, by name.
+var Palettes = map[string]Palette{
+	"default": {
+		"error":   ColorRed,
+		"success": ColorGreen,
+		"warn":    ColorYellow,
+		"dim":     ColorBright | ColorBlack,
+	},
+	// Red and green look alike with the most common color vision
+	// deficiencies (deuteranopia and protanopia), so successes are blue and
+	// errors a bright magenta instead.
+	"deuteranopia": {
+		"error":   ColorBright | ColorMagenta,
+		"success": ColorBlue,
+		"warn":    ColorYellow,
+		"dim":     ColorBright | ColorBlack,
+	},
+	// With tritanopia, blue and yellow are the ones to avoid.
+	"tritanopia": {
+		"error":   ColorRed,
+		"success": ColorGreen,
+		"warn":    ColorBright | ColorMagenta,
+		"dim":     ColorBright | ColorBlack,
+	},
+}
+
+// SetPalette applies p, remapping each of its names to its color; names not
+// in p are left alone. Like AddAnsiColorCode, this affects all Loggers and
+// should be done before logging starts, e.g. at startup.
+func SetPalette(p Palette) {
+	for name, code := range p {
+		setColorCode(name, code)
+	}
+}
+
+// SetPaletteByName applies the built-in palette with the given name.
+func SetPaletteByName(name string) error {
+	p, ok := Palettes[name]
+	if !ok {
+		return fmt.Errorf("alog: unknown palette %q", name)
+	}
+	SetPalette(p)
+	return nil
+}
+
+// RemapStyle remaps the color name to style, a comma-separated list of
+// existing color names such as "bright,blue", e.g. to follow a user's
+// preference. Like SetPalette, it affects all Loggers.
+func RemapStyle(name, style string) error {
+	code, err := parseStyle(style)
+	if err != nil {
+		return err
+	}
+	setColorCode(name, code)
+	return nil
+}
+
+// SetPaletteFromEnv applies the user's preferences from the environment: the
+// palette named by ALOG_PALETTE, if set, and then the remappings in
+// ALOG_STYLES, which look like "error=bright,magenta;success=blue".
+func SetPaletteFromEnv() error {
+	if name := os.Getenv("ALOG_PALETTE"); name != "" {
+		if err := SetPaletteByName(name); err != nil {
+			return err
+		}
+	}
+	for _, entry := range strings.Split(os.Getenv("ALOG_STYLES"), ";") {
+		if strings.TrimSpace(entry) == "" {
+			continue
+		}
+		parts := strings.SplitN(entry, "=", 2)
+		if len(parts) != 2 {
+			return fmt.Errorf("alog: invalid style %q in ALOG_STYLES", entry)
+		}
+		if err := RemapStyle(strings.TrimSpace(parts[0]), parts[1]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// parseStyle combines a comma-separated list of color names into a single
+// ColorCode: attributes such as bright accumulate, and the last color wins.
+func parseStyle(style string) (ColorCode, error) {
+	const attributes = ColorResetAll | ColorBright | ColorDim
+	var code ColorCode
+	for _, name := range strings.Split(style, ",") {
+		c, ok := lookupColorCode(strings.TrimSpace(name))
+		if !ok {
+			return 0, fmt.Errorf("alog: unknown color %q", strings.TrimSpace(name))
+		}
+		if color := c &^ attributes; color != ColorNone {
+			code &= attributes
+			code |= color
+		}
+		code |= c & attributes
+	}
+	return code, nil
+}
@@ -0,0 +1,46 @@
+package alog
+
+import (
+	"io"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPalettes(t *testing.T) {
+	assert := assert.New(t)
+	defer SetPalette(Palettes["default"])
+	assert.NoError(SetPaletteByName("deuteranopia"))
+	assert.Equal("\033[1m\033[35mfailed\033[0m", styled("error", "failed"))
+	assert.Error(SetPaletteByName("sepia"))
+
+	assert.NoError(RemapStyle("success", "bright,cyan"))
+	assert.Equal("\033[1m\033[36mok\033[0m", styled("success", "ok"))
+	assert.Error(RemapStyle("success", "bright,chartreuse"))
+
+	t.Setenv("ALOG_PALETTE", "default")
+	t.Setenv("ALOG_STYLES", "warn=blue; error = bright,red")
+	assert.NoError(SetPaletteFromEnv())
+	for name, want := range map[string]ColorCode{"warn": ColorBlue, "error": ColorBright | ColorRed, "success": ColorGreen} {
+		code, _ := lookupColorCode(name)
+		assert.Equal(want, code, name)
+	}
+}
+
+func TestSetPaletteWhileLogging(t *testing.T) {
+	defer SetPalette(Palettes["default"])
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		for i := 0; i < 100; i++ {
+			RemapStyle("warn", "bright,yellow")
+		}
+	}()
+	writer := New(io.Discard, "", 0)
+	for i := 0; i < 100; i++ {
+		writer.Printf("@(warn:careful)\n")
+	}
+	wg.Wait()
+}
@@ -0,0 +1,71 @@
+package alog
+
+// Pause hides partial lines and holds back all further output to this Logger's
+// writer, for when the application hands the terminal to something else for a
+// while, e.g. an $EDITOR or an interactive prompt. Completed lines are kept
+// rather than written, and partial lines stop being redrawn, until Resume is
+// called. Pausing a writer that is already paused does nothing.
+func (l *Logger) Pause() {
+	ws := getWriterState(l.out)
+	ws.lock()
+	defer ws.unlock()
+	if !ws.paused {
+		ws.pause()
+	}
+}
+
+// Resume writes out the lines held back since Pause, then redraws the partial
+// lines. The terminal is assumed to have been left with the cursor at the
+// beginning of a line.
+func (l *Logger) Resume() {
+	ws := getWriterState(l.out)
+	ws.lock()
+	defer ws.unlock()
+	if ws.paused {
+		ws.resume()
+		updateTempOutput(l.out)
+	}
+}
+
+// IsPaused reports whether output to this Logger's writer is paused.
+func (l *Logger) IsPaused() bool {
+	ws := getWriterState(l.out)
+	ws.lock()
+	defer ws.unlock()
+	return ws.paused
+}
+
+// pause clears partial lines, writes out everything so far and starts holding
+// back output. Must be called with the writer lock held.
+func (w *WriterState) pause() {
+	w.clearTempLines()
+	w.flushLocked()
+	w.lastTemp.reset()
+	w.cursorLineIndex = 0
+	w.cursorIsAtBegin = true
+	w.cursorIsInline = false
+	w.paused = true
+}
+
+// writeThrough writes p out right away, even while output is held back. Must
+// be called with the writer lock held.
+func (w *WriterState) writeThrough(p []byte) {
+	w.enqueuePending()
+	if w.recorder != nil {
+		w.recorder.record(p)
+	}
+	w.queue.push(p)
+	w.queue.flush(w.out)
+}
+
+// resume queues the output held back since pause. Must be called with the
+// writer lock held.
+func (w *WriterState) resume() {
+	w.paused = false
+	w.pending = append(w.held, w.pending...)
+	w.held = nil
+}
+
+func Pause()         { DefaultLogger.Pause() }
+func Resume()        { DefaultLogger.Resume() }
+func IsPaused() bool { return DefaultLogger.IsPaused() }
@@ -0,0 +1,31 @@
+package alog
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPauseResume(t *testing.T) {
+	assert := assert.New(t)
+	var buf bytes.Buffer
+	var writer = New(&buf, "", 0)
+	writer.SetTerminalWidth(80)
+	writer.ShowPartialLines()
+	defer writer.Close()
+	writer.Print("working...")
+	buf.Reset()
+	writer.Pause()
+	assert.True(writer.IsPaused())
+	assert.Equal("\r          \r", buf.String())
+	buf.Reset()
+	writer.Print(" still")
+	writer.Println()
+	writer.Println("done")
+	writer.Print("next")
+	assert.Equal("", buf.String())
+	writer.Resume()
+	assert.False(writer.IsPaused())
+	assert.Equal("working... still\ndone\nnext", buf.String())
+}
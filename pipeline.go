@@ -0,0 +1,83 @@
+package alog
+
+import (
+	"fmt"
+	"strings"
+)
+
+// A Pipeline runs a sequence of named steps, showing which one is running
+// and how long it has taken, and then how each one went, in a consistent
+// style. It stops at the first step that fails.
+type Pipeline struct {
+	logger *Logger
+	steps  []pipelineStep
+}
+
+type pipelineStep struct {
+	name string
+	fn   func(l *Logger) error
+}
+
+// NewPipeline creates an empty Pipeline writing to the Logger's writer.
+func (l *Logger) NewPipeline() *Pipeline {
+	return &Pipeline{logger: l}
+}
+
+func NewPipeline() *Pipeline { return DefaultLogger.NewPipeline() }
+
+// Step adds a step to the Pipeline. fn is given a Logger for any output of
+// its own, which is indented below the step's title.
+func (p *Pipeline) Step(name string, fn func(l *Logger) error) {
+	p.steps = append(p.steps, pipelineStep{name, fn})
+}
+
+// Run runs the steps in order until one returns an error, which is then
+// returned. Each step's title is printed before it runs, so that its output
+// follows it even where partial lines aren't shown, and how it went after.
+// The remaining steps are listed as skipped.
+func (p *Pipeline) Run() error {
+	l := p.logger
+	stopAnimating := l.animate()
+	defer stopAnimating()
+	indent := l.Prefix() + "    "
+	flag := l.Flags()
+	for i, step := range p.steps {
+		l.Println(fmt.Sprintf("[%d/%d] %s", i+1, len(p.steps), step.name))
+		status := New(l.out, indent, flag)
+		status.colorEnabled.copyFrom(&l.colorEnabled)
+		status.clock.set(l.clock.get())
+		status.SetTempRenderer(func(state LineState, width int) []byte {
+			elapsed := state.Now.Sub(state.Start)
+			return []byte(l.spinnerFrame(elapsed) + " " + strings.TrimSpace(FormatDuration(elapsed)))
+		})
+		status.Print("running")
+		output := New(l.out, indent, flag)
+		output.colorEnabled.copyFrom(&l.colorEnabled)
+		output.clock.set(l.clock.get())
+
+		start := l.currentTime()
+		err := step.fn(output)
+		duration := strings.TrimSpace(FormatDuration(l.currentTime().Sub(start)))
+		output.Close()
+		status.SetTempRenderer(nil)
+		status.Replace()
+		if err == nil {
+			status.Printf("%s (%s)\n", styled("success", "done"), duration)
+			status.Close()
+			continue
+		}
+		status.Errorf("failed: %v (%s)\n", err, duration)
+		status.Close()
+		for j, skipped := range p.steps[i+1:] {
+			l.Println(styled("dim", fmt.Sprintf("[%d/%d] %s skipped", i+j+2, len(p.steps), skipped.name)))
+		}
+		return err
+	}
+	return nil
+}
+
+// styled wraps s in the escapes for style, a comma-separated list of color
+// template names.
+func styled(style, s string) string {
+	return string(styleEscapes(style)) + s + string(ansiBytesResetAll)
+}
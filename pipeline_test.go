@@ -0,0 +1,35 @@
+package alog
+
+import (
+	"bytes"
+	"errors"
+	"regexp"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPipeline(t *testing.T) {
+	assert := assert.New(t)
+	var buf bytes.Buffer
+	var writer = New(&buf, "", 0)
+	defer writer.Close()
+	writer.DisableColor()
+	p := writer.NewPipeline()
+	p.Step("fetch", func(l *Logger) error {
+		l.Println("got it")
+		return nil
+	})
+	p.Step("build", func(l *Logger) error { return errors.New("boom") })
+	p.Step("test", func(l *Logger) error { return nil })
+	assert.EqualError(p.Run(), "boom")
+	assert.Less(strings.Index(buf.String(), "[1/3] fetch\n"), strings.Index(buf.String(), "got it"), "titles are written before the output")
+	var lines []string
+	for _, line := range strings.Split(buf.String(), "\n") {
+		// Keep only what was left on screen.
+		lines = append(lines, strings.TrimRight(line[strings.LastIndex(line, "\r")+1:], " "))
+	}
+	output := regexp.MustCompile("\\(.*?\\)").ReplaceAllString(strings.Join(lines, "\n"), "(<dur>)")
+	assert.Equal("[1/3] fetch\n    got it\n    done (<dur>)\n[2/3] build\n    failed: boom (<dur>)\n[3/3] test skipped\n", output)
+}
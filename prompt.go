@@ -0,0 +1,72 @@
+package alog
+
+import (
+	"bufio"
+	"io"
+	"os"
+	"strings"
+)
+
+// promptInput is where Prompt reads answers from. It's shared so that input
+// buffered by one Prompt isn't lost to the next.
+var promptInput = bufio.NewReader(os.Stdin)
+
+// clearTempLines blanks out any partial lines on screen and leaves the cursor
+// at the beginning of the first of them. Must be called with the writer lock
+// held.
+func (w *WriterState) clearTempLines() {
+	for i := w.lastTemp.len() - 1; i >= 0; i-- {
+		setTempLineOutput(w.out, i, bytesEmpty)
+	}
+	moveCursorToLine(w.out, 0)
+	if !w.cursorIsAtBegin {
+		w.write(bytesCarriageReturn)
+	}
+}
+
+// Prompt displays msg, formatted like a line from this Logger, and reads a line
+// of input from stdin, which it returns without the trailing newline. While
+// waiting for input, partial lines are hidden and other output to this
+// Logger's writer is held back, as by Pause, so that neither garbles the
+// prompt; both resume once the input has been read. Other goroutines may keep
+// logging in the meantime.
+func (l *Logger) Prompt(msg string) (string, error) {
+	ws := getWriterState(l.out)
+	ws.lock()
+	wasPaused := ws.paused
+	if !wasPaused {
+		ws.pause()
+	}
+	l.updateNow()
+	ws.writeThrough(l.getFormattedLine([]byte(l.applyColorTemplates(msg))))
+	ws.unlock()
+
+	answer, err := promptInput.ReadString('\n')
+	if err == io.EOF && answer != "" {
+		err = nil
+	}
+
+	ws.lock()
+	defer ws.unlock()
+	if !strings.HasSuffix(answer, "\n") {
+		// The user's newline wasn't echoed, so provide our own.
+		ws.writeThrough(bytesNewline)
+	}
+	// The terminal has taken care of moving the cursor to a new line.
+	if !wasPaused {
+		ws.resume()
+		updateTempOutput(l.out)
+	}
+	return strings.TrimRight(answer, "\r\n"), err
+}
+
+// Confirm asks a yes/no question using Prompt, returning true if the answer
+// starts with "y" or "Y". Anything else, including a failure to read an
+// answer, counts as no.
+func (l *Logger) Confirm(msg string) bool {
+	answer, err := l.Prompt(msg + " [y/N] ")
+	return err == nil && strings.HasPrefix(strings.ToLower(strings.TrimSpace(answer)), "y")
+}
+
+func Prompt(msg string) (string, error) { return DefaultLogger.Prompt(msg) }
+func Confirm(msg string) bool           { return DefaultLogger.Confirm(msg) }
@@ -0,0 +1,62 @@
+package alog
+
+import (
+	"bufio"
+	"bytes"
+	"io"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPrompt(t *testing.T) {
+	assert := assert.New(t)
+	defer func(input *bufio.Reader) { promptInput = input }(promptInput)
+	promptInput = bufio.NewReader(strings.NewReader("Ada\ny\n"))
+	var buf bytes.Buffer
+	var writer = New(&buf, "", 0)
+	writer.SetTerminalWidth(80)
+	writer.ShowPartialLines()
+	defer writer.Close()
+	writer.Print("working...")
+	buf.Reset()
+	answer, err := writer.Prompt("Name? ")
+	assert.NoError(err)
+	assert.Equal("Ada", answer)
+	assert.Equal("\r          \rName? working...", buf.String())
+	assert.True(writer.Confirm("Sure?"))
+	assert.False(writer.Confirm("Really?"), "no input left")
+}
+
+func TestPromptDoesNotBlockLogging(t *testing.T) {
+	assert := assert.New(t)
+	defer func(input *bufio.Reader) { promptInput = input }(promptInput)
+	input, answer := io.Pipe()
+	promptInput = bufio.NewReader(input)
+	var buf syncBuffer
+	var writer = New(&buf, "", 0)
+	writer.HidePartialLines()
+	defer writer.Close()
+	done := make(chan string)
+	go func() {
+		answer, _ := writer.Prompt("Name? ")
+		done <- answer
+	}()
+	assert.Eventually(func() bool { return buf.String() == "Name? " }, 5*time.Second, time.Millisecond)
+	logged := make(chan struct{})
+	go func() {
+		writer.Print("meanwhile\n")
+		close(logged)
+	}()
+	select {
+	case <-logged:
+	case <-time.After(5 * time.Second):
+		t.Fatal("logging blocked while waiting for input")
+	}
+	assert.Equal("Name? ", buf.String(), "output is held back")
+	answer.Write([]byte("Ada\n"))
+	assert.Equal("Ada", <-done)
+	assert.Equal("Name? meanwhile\n", buf.String())
+}
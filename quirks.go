@@ -0,0 +1,114 @@
+package alog
+
+import (
+	"bytes"
+	"io"
+	"os"
+	"strings"
+)
+
+// TerminalQuirks are workarounds for terminals that tear when large redraws
+// (of several partial lines, or the status bar) arrive in one burst, as
+// happens under terminal multiplexers such as tmux and screen.
+type TerminalQuirks int
+
+const (
+	// SynchronizedOutput brackets each redraw in synchronized update escapes
+	// (DEC private mode 2026), so that it's shown all at once. Terminals that
+	// don't support them ignore them.
+	SynchronizedOutput TerminalQuirks = 1 << iota
+	// Passthrough wraps the synchronized update escapes in the multiplexer's
+	// DCS passthrough sequence, so that they reach the terminal outside it.
+	// tmux only passes them on with its allow-passthrough option turned on.
+	Passthrough
+	// ChunkedWrites splits output into writes of at most QuirkChunkSize
+	// bytes, breaking at the end of a line or before an escape where
+	// possible.
+	ChunkedWrites
+)
+
+// QuirkChunkSize is the largest write made with the ChunkedWrites quirk.
+const QuirkChunkSize = 1024
+
+// SetTerminalQuirks sets the workarounds used for the terminal behind this
+// Logger's writer. By default, when the writer is a terminal running under
+// tmux, SynchronizedOutput and ChunkedWrites are used, and under screen,
+// ChunkedWrites; otherwise none are.
+func (l *Logger) SetTerminalQuirks(quirks TerminalQuirks) {
+	ws := getWriterState(l.out)
+	ws.lock()
+	defer ws.unlock()
+	ws.setQuirks(quirks)
+}
+
+func SetTerminalQuirks(quirks TerminalQuirks) { DefaultLogger.SetTerminalQuirks(quirks) }
+
+func (w *WriterState) setQuirks(quirks TerminalQuirks) {
+	w.quirks = quirks
+	w.queue.mutex.Lock()
+	if quirks&ChunkedWrites != 0 {
+		w.queue.maxWrite = QuirkChunkSize
+	} else {
+		w.queue.maxWrite = 0
+	}
+	w.queue.mutex.Unlock()
+}
+
+// detectMultiplexer returns "tmux" or "screen" when running under one of
+// them, and "" otherwise.
+func detectMultiplexer() string {
+	term := os.Getenv("TERM")
+	switch {
+	case os.Getenv("TMUX") != "" || strings.HasPrefix(term, "tmux"):
+		return "tmux"
+	case os.Getenv("STY") != "" || strings.HasPrefix(term, "screen"):
+		return "screen"
+	}
+	return ""
+}
+
+func defaultTerminalQuirks(w io.Writer) TerminalQuirks {
+	if f, ok := w.(*os.File); !ok || !isTerminal(f) {
+		return 0
+	}
+	switch detectMultiplexer() {
+	case "tmux":
+		return SynchronizedOutput | ChunkedWrites
+	case "screen":
+		return ChunkedWrites
+	}
+	return 0
+}
+
+var (
+	bytesBeginSync = []byte("\033[?2026h")
+	bytesEndSync   = []byte("\033[?2026l")
+)
+
+// syncEscape returns esc, wrapped for passthrough if that quirk is set.
+func (w *WriterState) syncEscape(esc []byte) []byte {
+	if w.quirks&Passthrough == 0 {
+		return esc
+	}
+	if detectMultiplexer() == "tmux" {
+		// tmux wants the escapes inside doubled.
+		esc = bytes.ReplaceAll(esc, []byte("\033"), []byte("\033\033"))
+		return append(append([]byte("\033Ptmux;"), esc...), "\033\\"...)
+	}
+	return append(append([]byte("\033P"), esc...), "\033\\"...)
+}
+
+// splitWrite returns the first piece of p to write when writes are limited to
+// max bytes, or all of p if there's no limit.
+func splitWrite(p []byte, max int) []byte {
+	if max <= 0 || len(p) <= max {
+		return p
+	}
+	if i := bytes.LastIndexByte(p[:max], '\n'); i >= 0 {
+		return p[:i+1]
+	}
+	if i := bytes.LastIndexByte(p[:max], '\033'); i > 0 {
+		return p[:i]
+	}
+	return p[:max]
+}
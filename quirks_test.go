@@ -0,0 +1,52 @@
+package alog
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type recordingWriter struct {
+	writes []string
+}
+
+func (w *recordingWriter) Write(p []byte) (int, error) {
+	w.writes = append(w.writes, string(p))
+	return len(p), nil
+}
+
+func TestTerminalQuirks(t *testing.T) {
+	assert := assert.New(t)
+	var buf bytes.Buffer
+	writer := New(&buf, "", 0)
+	writer.SetTerminalWidth(80)
+	writer.SetTerminalHeight(10)
+	writer.ShowPartialLines()
+	defer writer.Close()
+	writer.SetTerminalQuirks(SynchronizedOutput)
+	writer.EnableStatusBar(1)
+	buf.Reset()
+	writer.Print("building")
+	assert.Equal("\033[?2026h\033[?25l\0337\033[10;1H\033[2Kbuilding\0338\033[?25h\033[?2026l", buf.String())
+	buf.Reset()
+	t.Setenv("TMUX", "/tmp/tmux-0/default,1,0")
+	writer.SetTerminalQuirks(SynchronizedOutput | Passthrough)
+	writer.Print("...")
+	assert.Equal("\033Ptmux;\033\033[?2026h\033\\\033[?25l\0337\033[10;1H\033[2Kbuilding...\0338\033[?25h\033Ptmux;\033\033[?2026l\033\\", buf.String())
+}
+
+func TestChunkedWrites(t *testing.T) {
+	assert := assert.New(t)
+	var w recordingWriter
+	writer := New(&w, "", 0)
+	defer writer.Close()
+	writer.SetTerminalQuirks(ChunkedWrites)
+	line := string(bytes.Repeat([]byte("x"), 600)) + "\n"
+	writer.Print(line + line)
+	assert.Equal([]string{line, line}, w.writes)
+
+	assert.Equal("ab\n", string(splitWrite([]byte("ab\ncd"), 4)))
+	assert.Equal("ab", string(splitWrite([]byte("ab\033[1mcd"), 4)))
+	assert.Equal("abcd", string(splitWrite([]byte("abcdef"), 4)))
+}
@@ -0,0 +1,32 @@
+package alog
+
+import "io"
+
+// RawWriter returns a Writer that writes straight to this Logger's output,
+// for when the application wants to control the terminal itself for a moment,
+// e.g. to draw its own UI with escape sequences. Partial lines are cleared
+// before each write, and since alog can no longer know where the cursor is
+// afterwards, they are redrawn from scratch, on a line of their own, with the
+// next output. Writes are ordered with the Logger's other output.
+func (l *Logger) RawWriter() io.Writer {
+	return rawWriter{getWriterState(l.out)}
+}
+
+func RawWriter() io.Writer { return DefaultLogger.RawWriter() }
+
+type rawWriter struct {
+	ws *WriterState
+}
+
+func (w rawWriter) Write(p []byte) (int, error) {
+	ws := w.ws
+	ws.lock()
+	defer ws.unlock()
+	ws.clearTempLines()
+	ws.write(p)
+	ws.lastTemp.reset()
+	ws.cursorLineIndex = 0
+	ws.cursorIsAtBegin = false
+	ws.cursorIsInline = false
+	return len(p), nil
+}
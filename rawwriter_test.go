@@ -0,0 +1,25 @@
+package alog
+
+import (
+	"bytes"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRawWriter(t *testing.T) {
+	assert := assert.New(t)
+	var buf bytes.Buffer
+	var writer = New(&buf, "", 0)
+	writer.SetTerminalWidth(80)
+	writer.ShowPartialLines()
+	defer writer.Close()
+	writer.Print("working")
+	buf.Reset()
+	fmt.Fprint(writer.RawWriter(), "\033[2J\033[Hmenu")
+	assert.Equal("\r       \r\033[2J\033[Hmenu", buf.String())
+	buf.Reset()
+	writer.Print("...")
+	assert.Equal("\rworking...", buf.String())
+}
@@ -0,0 +1,112 @@
+package alog
+
+import (
+	"encoding/json"
+	"io"
+	"os"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// A castRecorder writes everything sent to a writer, along with its timing,
+// as an asciinema v2 stream. Events are written from a goroutine of its own,
+// so that a slow destination doesn't hold up logging.
+type castRecorder struct {
+	w     io.Writer
+	start time.Time
+
+	mutex   sync.Mutex
+	cond    *sync.Cond
+	events  []byte // encoded events waiting to be written
+	err     error
+	stopped bool
+	done    chan struct{}
+}
+
+func newCastRecorder(w io.Writer, width, height int) *castRecorder {
+	r := &castRecorder{w: w, start: time.Now(), done: make(chan struct{})}
+	r.cond = sync.NewCond(&r.mutex)
+	header, _ := json.Marshal(map[string]interface{}{
+		"version":   2,
+		"width":     width,
+		"height":    height,
+		"timestamp": r.start.Unix(),
+		"env":       map[string]string{"TERM": os.Getenv("TERM")},
+	})
+	r.events = append(header, byteNewline)
+	go r.run()
+	return r
+}
+
+// record queues an output event for p. Recording stops at the first error.
+func (r *castRecorder) record(p []byte) {
+	data, _ := json.Marshal(string(p))
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	if r.err != nil || r.stopped {
+		return
+	}
+	r.events = append(r.events, '[')
+	r.events = strconv.AppendFloat(r.events, time.Since(r.start).Seconds(), 'f', 6, 64)
+	r.events = append(r.events, `, "o", `...)
+	r.events = append(r.events, data...)
+	r.events = append(r.events, ']', byteNewline)
+	r.cond.Signal()
+}
+
+func (r *castRecorder) run() {
+	defer close(r.done)
+	var events []byte
+	for {
+		r.mutex.Lock()
+		for len(r.events) == 0 && !r.stopped {
+			r.cond.Wait()
+		}
+		if len(r.events) == 0 {
+			r.mutex.Unlock()
+			return
+		}
+		events, r.events = r.events, events[:0]
+		r.mutex.Unlock()
+		if _, err := r.w.Write(events); err != nil {
+			r.mutex.Lock()
+			r.err = err
+			r.events = nil
+			r.mutex.Unlock()
+			return
+		}
+	}
+}
+
+// stop waits for the events recorded so far to be written, and ends the
+// recording.
+func (r *castRecorder) stop() {
+	r.mutex.Lock()
+	r.stopped = true
+	r.cond.Signal()
+	r.mutex.Unlock()
+	<-r.done
+}
+
+// RecordTo records every byte subsequently written to the Logger's output
+// (by this or any other Logger sharing it), along with when it was written,
+// to w as an asciinema v2 stream (https://docs.asciinema.org/manual/asciicast/v2/).
+// The recording can then be replayed with `asciinema play` or embedded in
+// documentation. It is written to w in the background. Passing nil stops
+// recording, once everything recorded has been written to w.
+func (l *Logger) RecordTo(w io.Writer) {
+	ws := getWriterState(l.out)
+	ws.lock()
+	prev := ws.recorder
+	ws.recorder = nil
+	if w != nil {
+		ws.recorder = newCastRecorder(w, getTermWidth(l.out), getTermHeight(l.out))
+	}
+	ws.unlock()
+	if prev != nil {
+		prev.stop()
+	}
+}
+
+func RecordTo(w io.Writer) { DefaultLogger.RecordTo(w) }
@@ -0,0 +1,68 @@
+package alog
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRecordTo(t *testing.T) {
+	assert := assert.New(t)
+	var buf, cast bytes.Buffer
+	var writer = New(&buf, "", 0)
+	writer.SetTerminalWidth(100)
+	writer.ShowPartialLines()
+	defer writer.Close()
+	writer.Print("before\n")
+	writer.RecordTo(&cast)
+	writer.Print("hello")
+	writer.Print(" world\n")
+	writer.RecordTo(nil)
+	writer.Print("after\n")
+
+	scanner := bufio.NewScanner(&cast)
+	assert.True(scanner.Scan())
+	var header struct {
+		Version int
+		Width   int
+	}
+	assert.NoError(json.Unmarshal(scanner.Bytes(), &header))
+	assert.Equal(2, header.Version)
+	assert.Equal(100, header.Width)
+	var output string
+	for scanner.Scan() {
+		var event []interface{}
+		assert.NoError(json.Unmarshal(scanner.Bytes(), &event))
+		assert.Len(event, 3)
+		assert.Equal("o", event[1])
+		output += event[2].(string)
+	}
+	assert.Equal("hello world\n", output)
+	assert.Equal("before\nhello world\nafter\n", buf.String())
+}
+
+func TestRecordToSlowWriter(t *testing.T) {
+	assert := assert.New(t)
+	var buf bytes.Buffer
+	var writer = New(&buf, "", 0)
+	defer writer.Close()
+	cast := &gatedWriter{gate: make(chan struct{}), started: make(chan struct{}, 1)}
+	writer.RecordTo(cast)
+	logged := make(chan struct{})
+	go func() {
+		writer.Print("hello\n")
+		close(logged)
+	}()
+	select {
+	case <-logged:
+	case <-time.After(5 * time.Second):
+		t.Fatal("logging waited for the recording to be written")
+	}
+	close(cast.gate)
+	writer.RecordTo(nil)
+	assert.Contains(cast.buf.String(), `"o", "hello\n"]`)
+}
@@ -0,0 +1,83 @@
+package alog
+
+import (
+	"fmt"
+	"net/http"
+)
+
+// A PanicAction says what RecoverAndLog does after logging a panic.
+type PanicAction int
+
+const (
+	// ContinueAfterPanic returns normally, as with a plain recover.
+	ContinueAfterPanic PanicAction = iota
+	// Repanic panics again with the same value, after logging.
+	Repanic
+	// ExitAfterPanic flushes all Loggers and exits with status 1, as Fatal does.
+	ExitAfterPanic
+)
+
+// RecoverAndLog recovers from a panic, if there is one, and prints the panic
+// value in the error style along with the stack of the panicking goroutine,
+// with standard library frames dimmed. It must be deferred directly, e.g.
+// defer alog.RecoverAndLog(l). l may be nil to use DefaultLogger. By default
+// execution then continues as after any recover; action can say otherwise.
+func RecoverAndLog(l *Logger, action ...PanicAction) {
+	r := recover()
+	if r == nil {
+		return
+	}
+	if l == nil {
+		l = DefaultLogger
+	}
+	l.logPanic(r)
+	if len(action) > 0 {
+		switch action[0] {
+		case Repanic:
+			panic(r)
+		case ExitAfterPanic:
+			osExit()
+		}
+	}
+}
+
+// RecoverHandler wraps next with a handler that recovers from panics in it,
+// logging them as RecoverAndLog does and responding with a 500 Internal
+// Server Error. http.ErrAbortHandler is passed through, so that the server
+// aborts the response as intended.
+func RecoverHandler(l *Logger, next http.Handler) http.Handler {
+	if l == nil {
+		l = DefaultLogger
+	}
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		defer func() {
+			v := recover()
+			if v == nil {
+				return
+			}
+			if v == http.ErrAbortHandler {
+				panic(v)
+			}
+			l.logPanic(v)
+			http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+		}()
+		next.ServeHTTP(w, r)
+	})
+}
+
+// logPanic prints the panic value v and the stack of the panicking goroutine.
+func (l *Logger) logPanic(v interface{}) {
+	ws := getWriterState(l.out)
+	ws.lock()
+	defer ws.unlock()
+	l.levelOutput(LevelError, 2, []byte(fmt.Sprintf("panic: %v\n", v)))
+	lines := callerStack()
+	for i := 0; i < len(lines); i++ {
+		if i == 0 || i+1 >= len(lines) || !isStdlibFrame(lines[i]) {
+			l.intOutput(2, []byte(lines[i]+"\n"), true)
+			continue
+		}
+		l.intOutput(2, []byte(styled("dim", lines[i])+"\n"+styled("dim", lines[i+1])+"\n"), true)
+		i++
+	}
+}
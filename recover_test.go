@@ -0,0 +1,53 @@
+package alog
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRecoverAndLog(t *testing.T) {
+	assert := assert.New(t)
+	var buf bytes.Buffer
+	var writer = New(&buf, "", 0)
+	defer writer.Close()
+	writer.DisableColor()
+	func() {
+		defer RecoverAndLog(writer)
+		panic("oops")
+	}()
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	assert.Equal("panic: oops", lines[0])
+	assert.True(strings.HasPrefix(lines[1], "goroutine "))
+	assert.Contains(lines[2], "TestRecoverAndLog")
+	assert.NotContains(buf.String(), "ansi-log.RecoverAndLog(")
+
+	assert.PanicsWithValue("again", func() {
+		defer RecoverAndLog(writer, Repanic)
+		panic("again")
+	})
+}
+
+func TestRecoverHandler(t *testing.T) {
+	assert := assert.New(t)
+	var buf bytes.Buffer
+	var writer = New(&buf, "", 0)
+	defer writer.Close()
+	writer.DisableColor()
+	handler := RecoverHandler(writer, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		panic("handler broke")
+	}))
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest("GET", "/", nil))
+	assert.Equal(http.StatusInternalServerError, rec.Code)
+	assert.True(strings.HasPrefix(buf.String(), "panic: handler broke\n"))
+	assert.Panics(func() {
+		RecoverHandler(writer, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			panic(http.ErrAbortHandler)
+		})).ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/", nil))
+	})
+}
@@ -0,0 +1,88 @@
+package alog
+
+import "time"
+
+// SetRefreshInterval makes the partial lines of this Logger's writer be
+// redrawn every interval, even when nothing new is written, so that elapsed
+// times in them keep advancing while tasks are quiet. Zero (the default)
+// turns periodic redrawing off, except while something else needs it, such
+// as the spinner of RunCommand; they are then redrawn as often as the most
+// demanding of them asks.
+func (l *Logger) SetRefreshInterval(interval time.Duration) {
+	ws := getWriterState(l.out)
+	ws.lock()
+	defer ws.unlock()
+	ws.refreshInterval = interval
+	ws.updateRefresh()
+}
+
+func SetRefreshInterval(interval time.Duration) { DefaultLogger.SetRefreshInterval(interval) }
+
+// addRefreshUser asks for partial lines to be redrawn at least every interval
+// until a matching call to removeRefreshUser. Must be called with the writer
+// lock held.
+func (w *WriterState) addRefreshUser(interval time.Duration) {
+	if w.refreshUsers == nil {
+		w.refreshUsers = map[time.Duration]int{}
+	}
+	w.refreshUsers[interval]++
+	w.updateRefresh()
+}
+
+// removeRefreshUser must be called with the writer lock held.
+func (w *WriterState) removeRefreshUser(interval time.Duration) {
+	if w.refreshUsers[interval]--; w.refreshUsers[interval] <= 0 {
+		delete(w.refreshUsers, interval)
+	}
+	w.updateRefresh()
+}
+
+// updateRefresh (re)starts or stops the ticker to redraw as often as the
+// shortest interval anyone asked for. Must be called with the writer lock
+// held.
+func (w *WriterState) updateRefresh() {
+	interval := w.refreshInterval
+	for user := range w.refreshUsers {
+		if interval <= 0 || user < interval {
+			interval = user
+		}
+	}
+	if interval == w.refreshRunning {
+		return
+	}
+	if w.refreshStop != nil {
+		close(w.refreshStop)
+		w.refreshStop = nil
+	}
+	w.refreshRunning = interval
+	if interval > 0 {
+		w.refreshStop = make(chan struct{})
+		go w.refreshEvery(interval, w.refreshStop)
+	}
+}
+
+func (w *WriterState) refreshEvery(interval time.Duration, stop chan struct{}) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-stop:
+			return
+		case <-ticker.C:
+			w.refresh()
+		}
+	}
+}
+
+// refresh redraws the partial lines as of the current time.
+func (w *WriterState) refresh() {
+	w.lock()
+	defer w.unlock()
+	if len(w.tempLoggers) == 0 {
+		return
+	}
+	for _, logger := range w.tempLoggers {
+		logger.updateNow()
+	}
+	updateTempOutput(w.out)
+}
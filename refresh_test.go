@@ -0,0 +1,50 @@
+package alog
+
+import (
+	"bytes"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRefresh(t *testing.T) {
+	assert := assert.New(t)
+	var buf bytes.Buffer
+	now := time.Date(2020, 1, 2, 3, 4, 5, 0, time.UTC)
+	writer := New(&buf, "{elapsed} ", 0)
+	writer.SetClock(func() time.Time { return now })
+	writer.SetTerminalWidth(80)
+	writer.ShowPartialLines()
+	defer writer.Close()
+	writer.Print("working...")
+	buf.Reset()
+	now = now.Add(2 * time.Second)
+	getWriterState(&buf).refresh()
+	assert.Equal("\r2.00s working...", buf.String())
+}
+
+func TestRefreshUsers(t *testing.T) {
+	assert := assert.New(t)
+	var buf bytes.Buffer
+	writer := New(&buf, "", 0)
+	defer writer.Close()
+	ws := getWriterState(&buf)
+	running := func() time.Duration {
+		ws.lock()
+		defer ws.unlock()
+		return ws.refreshRunning
+	}
+	stop1 := writer.animate()
+	stop2 := writer.animate()
+	assert.Equal(100*time.Millisecond, running())
+	stop1()
+	stop1()
+	assert.Equal(100*time.Millisecond, running(), "still animated by the other user")
+	writer.SetRefreshInterval(time.Hour)
+	assert.Equal(100*time.Millisecond, running(), "the shortest interval wins")
+	stop2()
+	assert.Equal(time.Hour, running())
+	writer.SetRefreshInterval(0)
+	assert.Equal(time.Duration(0), running())
+}
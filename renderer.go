@@ -0,0 +1,45 @@
+package alog
+
+import "time"
+
+// LineState describes a partial line, for use by a temp line renderer.
+type LineState struct {
+	Text  string    // the text written so far, after redaction; may contain color escapes
+	Start time.Time // when the line became visible as a partial line
+	Now   time.Time
+	Level Level
+}
+
+// SetTempRenderer sets a function that renders this Logger's partial line
+// whenever it is displayed, in place of the text written so far (and the
+// prefix), so that it can show computed summaries such as a percentage or
+// rate instead. width is the number of columns available to it; the result
+// is truncated if it's any longer. Completed lines are written as usual. A
+// nil renderer restores the default.
+func (l *Logger) SetTempRenderer(render func(state LineState, width int) []byte) {
+	ws := getWriterState(l.out)
+	ws.lock()
+	defer ws.unlock()
+	l.customized = true
+	l.tempRenderer = render
+	updateTempOutput(l.out)
+}
+
+func SetTempRenderer(render func(state LineState, width int) []byte) {
+	DefaultLogger.SetTempRenderer(render)
+}
+
+// renderTempLine renders the partial line using the Logger's renderer. Must
+// be called with the writer lock held.
+func (l *Logger) renderTempLine(width int) []byte {
+	buf := l.tempRenderer(LineState{
+		Text:  string(l.redact(l.buf)),
+		Start: l.lineStartTime,
+		Now:   l.now,
+		Level: l.lineLevel,
+	}, width)
+	if !l.isColorEnabled() {
+		buf = uncolorize(buf)
+	}
+	return buf
+}
@@ -0,0 +1,33 @@
+package alog
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSetTempRenderer(t *testing.T) {
+	assert := assert.New(t)
+	var buf bytes.Buffer
+	writer1 := New(&buf, "", 0)
+	writer2 := New(&buf, "", 0)
+	writer1.SetTerminalWidth(41)
+	writer1.ShowPartialLines()
+	var widths []int
+	writer1.SetTempRenderer(func(state LineState, width int) []byte {
+		widths = append(widths, width)
+		return []byte(fmt.Sprintf("downloaded %d files", strings.Count(state.Text, ".")))
+	})
+	writer1.Print("...")
+	assert.Equal("downloaded 3 files", buf.String())
+	writer2.Print("other")
+	assert.Equal([]int{40, 18}, widths)
+	buf.Reset()
+	writer1.Print("..\n")
+	assert.True(strings.HasPrefix(buf.String(), "\r..... "), "the completed line is written as usual: %q", buf.String())
+	writer1.Close()
+	writer2.Close()
+}
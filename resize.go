@@ -0,0 +1,193 @@
+package alog
+
+import (
+	"io"
+	"os"
+	"sync"
+)
+
+// TerminalWidth returns the width, in columns, that Loggers writing to w lay
+// out their output for: the width set with SetTerminalWidth or BindPTY if
+// any, otherwise $COLUMNS if set, otherwise that of the terminal.
+func TerminalWidth(w io.Writer) int {
+	ws := getWriterState(w)
+	ws.lock()
+	defer ws.unlock()
+	return getTermWidth(w)
+}
+
+// TerminalHeight returns the height, in rows, that Loggers writing to w
+// assume: the height set with SetTerminalHeight or BindPTY if any, otherwise
+// $LINES if set, otherwise that of the terminal.
+func TerminalHeight(w io.Writer) int {
+	ws := getWriterState(w)
+	ws.lock()
+	defer ws.unlock()
+	return getTermHeight(w)
+}
+
+var widthProber struct {
+	sync.RWMutex
+	fn func(w io.Writer) (int, bool)
+}
+
+// SetWidthProber sets a function used to find out the width of the terminal
+// behind a writer, for writers that aren't the process's own terminal (e.g. an
+// SSH channel or a web terminal). If fn returns false, or no prober is set, the
+// width of the terminal on stderr is assumed. A width set with
+// SetTerminalWidth or $COLUMNS takes precedence. Pass nil to remove the
+// prober.
+func SetWidthProber(fn func(w io.Writer) (int, bool)) {
+	widthProber.Lock()
+	defer widthProber.Unlock()
+	widthProber.fn = fn
+}
+
+// probeWidth asks the width prober, if any, for the width of w.
+func probeWidth(w io.Writer) (int, bool) {
+	widthProber.RLock()
+	fn := widthProber.fn
+	widthProber.RUnlock()
+	if sw, ok := w.(*sessionWriter); ok {
+		w = sw.out
+	}
+	if fn == nil {
+		return 0, false
+	}
+	width, ok := fn(w)
+	return width, ok && width > 0
+}
+
+var resizeCallbacks struct {
+	sync.Mutex
+	funcs   []*func(w, h int)
+	started bool
+}
+
+// OnResize registers fn to be called, from a separate goroutine, with the new
+// width and height whenever the terminal is resized. Calling the returned
+// function unregisters it.
+func OnResize(fn func(w, h int)) (unregister func()) {
+	entry := &fn
+	resizeCallbacks.Lock()
+	resizeCallbacks.funcs = append(resizeCallbacks.funcs, entry)
+	resizeCallbacks.Unlock()
+	watchResize()
+	return func() {
+		resizeCallbacks.Lock()
+		defer resizeCallbacks.Unlock()
+		for i, other := range resizeCallbacks.funcs {
+			if other == entry {
+				resizeCallbacks.funcs = append(resizeCallbacks.funcs[:i:i], resizeCallbacks.funcs[i+1:]...)
+				return
+			}
+		}
+	}
+}
+
+// watchResize starts listening for terminal resizes, if we aren't already.
+func watchResize() {
+	resizeCallbacks.Lock()
+	defer resizeCallbacks.Unlock()
+	if !resizeCallbacks.started {
+		resizeCallbacks.started = true
+		go func() {
+			for range resizeSignals() {
+				notifyResize()
+			}
+		}()
+	}
+}
+
+func notifyResize() {
+	redrawAfterResize()
+	width, height := TerminalWidth(os.Stderr), TerminalHeight(os.Stderr)
+	resizeCallbacks.Lock()
+	funcs := resizeCallbacks.funcs
+	resizeCallbacks.Unlock()
+	for _, fn := range funcs {
+		(*fn)(width, height)
+	}
+}
+
+// redrawAfterResize redraws partial lines in multiline mode from scratch
+// for every writer.
+func redrawAfterResize() {
+	for _, ws := range allWriterStates() {
+		ws.lock()
+		ws.redrawAfterResize()
+		ws.unlock()
+	}
+}
+
+// redrawAfterResize redraws partial lines in multiline mode from scratch. A
+// resize may have reflowed or scrolled them, so our idea of where the cursor
+// is relative to them can't be trusted; clearing them is a best effort.
+func (ws *WriterState) redrawAfterResize() {
+	if ws.multiline && ws.lastTemp.len() > 1 {
+		ws.clearTempLines()
+		ws.cursorLineIndex = 0
+		ws.lastTemp.reset()
+		ws.cursorIsAtBegin = true
+		ws.cursorIsInline = false
+	}
+	updateTempOutput(ws.out)
+}
+
+// A WindowSize is the size of a remote terminal, in columns and rows.
+type WindowSize struct {
+	Width  int
+	Height int
+}
+
+// BindPTY lays out output to w, typically the channel of an SSH session or
+// the master side of a pty, for the size of the remote terminal, taking each
+// new size from ptyReqs as the client resizes its window. The size of the
+// remote terminal takes precedence over $COLUMNS and $LINES. Output to w is
+// kept separate from other writers, so each session gets its own partial
+// lines. BindPTY returns immediately; sizes are applied in the background
+// until ptyReqs is closed or stop is called. stop also completes any partial
+// lines written to w and releases its state; Loggers writing to w must not
+// be used afterwards.
+func BindPTY(w io.Writer, ptyReqs <-chan WindowSize) (stop func()) {
+	ws := getWriterState(w)
+	done := make(chan struct{})
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		for {
+			select {
+			case size, ok := <-ptyReqs:
+				if !ok {
+					return
+				}
+				ws.resize(size)
+			case <-done:
+				return
+			}
+		}
+	}()
+	var once sync.Once
+	return func() {
+		once.Do(func() {
+			close(done)
+			wg.Wait()
+			ws.lock()
+			ws.closeAll()
+			ws.unlock()
+			mutexGlobal.Lock()
+			delete(writers, w)
+			mutexGlobal.Unlock()
+		})
+	}
+}
+
+// resize sets the size of the terminal behind the writer and redraws.
+func (ws *WriterState) resize(size WindowSize) {
+	ws.lock()
+	defer ws.unlock()
+	ws.termWidth = size.Width
+	ws.termHeight = size.Height
+	ws.redrawAfterResize()
+}
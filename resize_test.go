@@ -0,0 +1,75 @@
+package alog
+
+import (
+	"bytes"
+	"io"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTerminalWidth(t *testing.T) {
+	assert := assert.New(t)
+	var buf bytes.Buffer
+	var writer = New(&buf, "", 0)
+	defer writer.Close()
+	writer.SetTerminalWidth(42)
+	assert.Equal(42, TerminalWidth(&buf))
+}
+
+func TestOnResize(t *testing.T) {
+	assert := assert.New(t)
+	resized := make(chan int, 1)
+	unregister := OnResize(func(w, h int) { resized <- w })
+	defer unregister()
+	notifyResize()
+	assert.Equal(TerminalWidth(os.Stderr), <-resized)
+	unregister()
+	notifyResize()
+	assert.Empty(resized, "unregistered callbacks aren't called")
+}
+
+func TestSetWidthProber(t *testing.T) {
+	assert := assert.New(t)
+	t.Setenv("COLUMNS", "")
+	var buf, other bytes.Buffer
+	SetWidthProber(func(w io.Writer) (int, bool) {
+		return 57, w == &buf
+	})
+	defer SetWidthProber(nil)
+	assert.Equal(57, TerminalWidth(&buf))
+	assert.NotEqual(57, TerminalWidth(&other))
+	var writer = New(&buf, "", 0)
+	defer writer.Close()
+	writer.SetTerminalWidth(42)
+	assert.Equal(42, TerminalWidth(&buf))
+}
+
+func TestBindPTY(t *testing.T) {
+	assert := assert.New(t)
+	t.Setenv("COLUMNS", "")
+	t.Setenv("LINES", "")
+	var buf bytes.Buffer
+	sizes := make(chan WindowSize)
+	stop := BindPTY(&buf, sizes)
+	sizes <- WindowSize{Width: 100, Height: 30}
+	sizes <- WindowSize{Width: 120, Height: 40}
+	t.Setenv("COLUMNS", "90")
+	t.Setenv("LINES", "20")
+	assert.Eventually(func() bool {
+		return TerminalWidth(&buf) == 120 && TerminalHeight(&buf) == 40
+	}, time.Second, time.Millisecond, "the remote size beats our environment")
+	stop()
+	stop()
+	mutexGlobal.RLock()
+	_, ok := writers[&buf]
+	mutexGlobal.RUnlock()
+	assert.False(ok, "stop releases the writer's state")
+	select {
+	case sizes <- WindowSize{Width: 1, Height: 1}:
+		t.Error("BindPTY kept running after stop")
+	case <-time.After(10 * time.Millisecond):
+	}
+}
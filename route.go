@@ -0,0 +1,94 @@
+package alog
+
+import (
+	"io"
+	"sort"
+)
+
+// A route sends completed lines of at least a given level to another writer.
+type route struct {
+	minLevel Level
+	out      io.Writer
+}
+
+// A routedLine is a formatted line waiting to be written to another writer.
+type routedLine struct {
+	out  io.Writer
+	line []byte
+}
+
+// Route sends completed lines at minLevel or above to out instead of the
+// Logger's own writer, e.g. Route(LevelError, os.Stderr) to keep diagnostics
+// on stderr while everything else goes to stdout. When several routes apply
+// to a line, the one with the highest minLevel wins. Partial lines are still
+// shown on the Logger's own writer until they are completed. A nil out
+// removes the route for minLevel.
+func (l *Logger) Route(minLevel Level, out io.Writer) {
+	ws := getWriterState(l.out)
+	ws.lock()
+	defer ws.unlock()
+	l.customized = true
+	var routes []route
+	for _, r := range l.routes {
+		if r.minLevel != minLevel {
+			routes = append(routes, r)
+		}
+	}
+	if out != nil {
+		routes = append(routes, route{minLevel, out})
+	}
+	sort.Slice(routes, func(i, j int) bool { return routes[i].minLevel > routes[j].minLevel })
+	l.routes = routes
+}
+
+func Route(minLevel Level, out io.Writer) { DefaultLogger.Route(minLevel, out) }
+
+// ClearRoutes removes all routes, sending all lines to the Logger's writer.
+func (l *Logger) ClearRoutes() {
+	ws := getWriterState(l.out)
+	ws.lock()
+	defer ws.unlock()
+	l.customized = true
+	l.routes = nil
+}
+
+func ClearRoutes() { DefaultLogger.ClearRoutes() }
+
+// routeFor returns the writer that completed lines at level go to, or nil if
+// no route applies.
+func (l *Logger) routeFor(level Level) io.Writer {
+	for _, r := range l.routes {
+		if level >= r.minLevel {
+			return r.out
+		}
+	}
+	return nil
+}
+
+// routeLine queues a completed line to be written to out, formatted as it
+// would be on the Logger's own writer in CI mode. Must be called with the
+// writer lock held.
+func (l *Logger) routeLine(out io.Writer, buf []byte) {
+	ws := getWriterState(l.out)
+	if annotation := l.ciAnnotation(buf); annotation != nil {
+		ws.routed = append(ws.routed, routedLine{out, annotation})
+		return
+	}
+	ws.routed = append(ws.routed, routedLine{out, append([]byte(nil), buf...)})
+	if marker := l.ciMarker(); marker != nil {
+		ws.routed = append(ws.routed, routedLine{out, marker})
+	}
+}
+
+// deliverRoutedLines writes lines routed from another writer. They are
+// delivered once that writer's lock is released, so that two writers routing
+// to each other can't deadlock.
+func deliverRoutedLines(routed []routedLine) {
+	for _, r := range routed {
+		ws := getWriterState(r.out)
+		ws.lock()
+		writeLine(r.out, r.line)
+		updateTempOutput(r.out)
+		ws.unlock()
+	}
+}
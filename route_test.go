@@ -0,0 +1,50 @@
+package alog
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRoute(t *testing.T) {
+	assert := assert.New(t)
+	var stdout, stderr, warnings bytes.Buffer
+	var writer = New(&stdout, "", 0)
+	defer writer.Close()
+	writer.DisableColor()
+	writer.Route(LevelError, &stderr)
+	writer.Println("info")
+	writer.Warn("careful\n")
+	writer.Error("bad\n")
+	assert.Equal("info\ncareful\n", stdout.String())
+	assert.Equal("bad\n", stderr.String())
+
+	writer.Route(LevelWarn, &warnings)
+	writer.Warn("again\n")
+	writer.Error("worse\n")
+	assert.Equal("again\n", warnings.String())
+	assert.Equal("bad\nworse\n", stderr.String())
+
+	writer.ClearRoutes()
+	writer.Error("here\n")
+	assert.Equal("info\ncareful\nhere\n", stdout.String())
+}
+
+func TestRouteKeepsPartialLines(t *testing.T) {
+	assert := assert.New(t)
+	var stdout, stderr bytes.Buffer
+	var writer = New(&stdout, "", 0)
+	defer writer.Close()
+	writer.DisableColor()
+	writer.Route(LevelError, &stderr)
+	var other = New(&stderr, "", 0)
+	defer other.Close()
+	other.SetTerminalWidth(80)
+	other.ShowPartialLines()
+	other.DisableColor()
+	other.Print("working...")
+	stderr.Reset()
+	writer.Error("boom\n")
+	assert.Equal("\rboom      \nworking...", stderr.String())
+}
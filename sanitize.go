@@ -0,0 +1,73 @@
+package alog
+
+import (
+	"fmt"
+	"unicode/utf8"
+)
+
+// SetSanitizeEnabled turns on replacement of non-printable characters in
+// output with visible placeholders, so that binary data piped through a Logger
+// can't wreck the terminal. Control characters are shown in caret notation
+// (e.g. ESC as "^["), and bytes that aren't valid UTF-8 as "\xNN". Newlines,
+// carriage returns, tabs and color escapes are left alone.
+func (l *Logger) SetSanitizeEnabled(flag bool) {
+	ws := getWriterState(l.out)
+	ws.lock()
+	defer ws.unlock()
+	l.customized = true
+	l.sanitizeEnabled = flag
+}
+func (l *Logger) EnableSanitize()  { l.SetSanitizeEnabled(true) }
+func (l *Logger) DisableSanitize() { l.SetSanitizeEnabled(false) }
+
+func SetSanitizeEnabled(flag bool) { DefaultLogger.SetSanitizeEnabled(flag) }
+func EnableSanitize()              { DefaultLogger.EnableSanitize() }
+func DisableSanitize()             { DefaultLogger.DisableSanitize() }
+
+func needsSanitizing(r rune, size int) bool {
+	if r == utf8.RuneError && size <= 1 {
+		return true
+	}
+	switch r {
+	case '\n', '\r', '\t':
+		return false
+	}
+	return r < ' ' || (r >= 0x7f && r < 0xa0)
+}
+
+// sanitize returns s with non-printable characters replaced, or s itself if
+// there were none.
+func sanitize(s []byte) []byte {
+	var out []byte
+	last := 0
+	for i := 0; i < len(s); {
+		if s[i] == '\033' {
+			if loc := ansiColorPrefixRegexp.FindIndex(s[i:]); loc != nil {
+				i += loc[1]
+				continue
+			}
+		}
+		r, size := utf8.DecodeRune(s[i:])
+		if !needsSanitizing(r, size) {
+			i += size
+			continue
+		}
+		out = append(out, s[last:i]...)
+		switch {
+		case r == utf8.RuneError:
+			out = append(out, fmt.Sprintf(`\x%02x`, s[i])...)
+		case r < ' ':
+			out = append(out, '^', byte(r)+'@')
+		case r == 0x7f:
+			out = append(out, '^', '?')
+		default:
+			out = append(out, fmt.Sprintf(`\u%04x`, r)...)
+		}
+		i += size
+		last = i
+	}
+	if out == nil {
+		return s
+	}
+	return append(out, s[last:]...)
+}
@@ -0,0 +1,24 @@
+package alog
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSanitize(t *testing.T) {
+	assert := assert.New(t)
+	assert.Equal("plain\ttext\n", string(sanitize([]byte("plain\ttext\n"))))
+	assert.Equal("^[]0;title^G \033[31mred\033[39m ^@^? \\xff\\xfe é", string(sanitize([]byte("\033]0;title\a \033[31mred\033[39m \x00\x7f \xff\xfe é"))))
+}
+
+func TestSanitizeEnabled(t *testing.T) {
+	assert := assert.New(t)
+	var buf bytes.Buffer
+	var writer = New(&buf, "", 0)
+	defer writer.Close()
+	writer.EnableSanitize()
+	writer.Print("bin\x1b[2J\x08ary\n")
+	assert.Equal("bin^[[2J^Hary\n", buf.String())
+}
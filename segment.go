@@ -0,0 +1,101 @@
+package alog
+
+import "fmt"
+
+// A StatusSegment is a named piece of status text, such as "3/10 done", displayed
+// along with the partial lines of a Logger's writer (as one of the joined
+// segments in singleline mode, or a line of its own in multiline mode) but
+// updated independently of anything written to a Logger. Segments are
+// displayed after partial lines, in the order they were created unless
+// SetOrder is used. All methods are safe for concurrent use.
+type StatusSegment struct {
+	l      *Logger
+	name   string
+	text   []byte
+	hidden bool
+	order  int
+	weight float64
+	render func() []byte // if set, computes the text whenever it's displayed
+}
+
+// Segment returns the segment with the given name for this Logger's writer,
+// creating it (empty, and so not displayed) if necessary.
+func (l *Logger) Segment(name string) *StatusSegment {
+	ws := getWriterState(l.out)
+	ws.lock()
+	defer ws.unlock()
+	for _, segment := range ws.namedSegments {
+		if segment.name == name {
+			return segment
+		}
+	}
+	segment := &StatusSegment{l: l, name: name}
+	ws.namedSegments = append(ws.namedSegments, segment)
+	return segment
+}
+
+func Segment(name string) *StatusSegment { return DefaultLogger.Segment(name) }
+
+// update applies fn to the segment, with the writer lock held, and redraws.
+func (s *StatusSegment) update(fn func()) {
+	ws := getWriterState(s.l.out)
+	ws.lock()
+	defer ws.unlock()
+	fn()
+	updateTempOutput(s.l.out)
+}
+
+// Name returns the name of the segment.
+func (s *StatusSegment) Name() string { return s.name }
+
+// Set replaces the text of the segment, which may contain color templates.
+func (s *StatusSegment) Set(text string) {
+	s.update(func() { s.text = []byte(s.l.applyColorTemplates(text)) })
+}
+
+// Setf replaces the text of the segment, formatted as by fmt.Sprintf.
+func (s *StatusSegment) Setf(format string, v ...interface{}) {
+	s.Set(fmt.Sprintf(format, v...))
+}
+
+// Hide stops displaying the segment, without forgetting its text.
+func (s *StatusSegment) Hide() { s.update(func() { s.hidden = true }) }
+
+// Show displays the segment again after Hide.
+func (s *StatusSegment) Show() { s.update(func() { s.hidden = false }) }
+
+// SetOrder sets the order of the segment relative to other segments and
+// partial lines in singleline mode, as with Logger.SetSegmentOrder.
+func (s *StatusSegment) SetOrder(order int) { s.update(func() { s.order = order }) }
+
+// SetWeight sets the weight of the segment, as with Logger.SetSegmentWeight.
+func (s *StatusSegment) SetWeight(weight float64) { s.update(func() { s.weight = weight }) }
+
+// Remove removes the segment from display for good; Segment will create a new
+// one if called again with the same name.
+func (s *StatusSegment) Remove() {
+	s.update(func() {
+		ws := getWriterState(s.l.out)
+		for i, segment := range ws.namedSegments {
+			if segment == s {
+				ws.namedSegments = append(ws.namedSegments[:i], ws.namedSegments[i+1:]...)
+				break
+			}
+		}
+	})
+}
+
+func (s *StatusSegment) tempSegment() tempSegment {
+	text := s.text
+	if s.render != nil {
+		text = s.render()
+	}
+	if !s.l.isColorEnabled() {
+		text = uncolorize(text)
+	}
+	weight := s.weight
+	if weight <= 0 {
+		weight = 1
+	}
+	return tempSegment{buf: text, weight: weight, order: s.order}
+}
@@ -0,0 +1,34 @@
+package alog
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNamedSegment(t *testing.T) {
+	assert := assert.New(t)
+	var buf bytes.Buffer
+	writer := New(&buf, "", 0)
+	writer.SetTerminalWidth(80)
+	writer.ShowPartialLines()
+	defer writer.Close()
+	progress := writer.Segment("progress")
+	assert.Same(progress, writer.Segment("progress"))
+	progress.Setf("%d/%d done", 3, 10)
+	assert.Equal("3/10 done", buf.String())
+	buf.Reset()
+	writer.Print("uploading")
+	assert.Equal("\ruploading | 3/10 done", buf.String())
+	buf.Reset()
+	progress.Hide()
+	assert.Equal("\r\033[9C            ", buf.String())
+	buf.Reset()
+	progress.Show()
+	writer.Print("... ok\n")
+	assert.Equal("\r\033[9C | 3/10 done\ruploading... ok      \n3/10 done", buf.String())
+	buf.Reset()
+	progress.Remove()
+	assert.Equal("\r         ", buf.String())
+}
@@ -0,0 +1,63 @@
+package alog
+
+// A tempSegment is one partial line as displayed: a line of its own in
+// multiline mode, or one of the segments joined together otherwise.
+type tempSegment struct {
+	buf    []byte
+	weight float64
+	order  int
+}
+
+// SetSegmentWeight sets how strongly this Logger's partial line holds on to
+// its space in singleline mode when the segments don't all fit. Segments are
+// shortened in proportion to their length divided by their weight, so a
+// segment of weight 2 keeps about twice as much as one of weight 1 (the
+// default).
+func (l *Logger) SetSegmentWeight(weight float64) {
+	ws := getWriterState(l.out)
+	ws.lock()
+	defer ws.unlock()
+	l.customized = true
+	l.segmentWeight = weight
+	updateTempOutput(l.out)
+}
+
+// SetSegmentOrder sets where this Logger's partial line appears in
+// singleline mode: segments are displayed in ascending order, and in the
+// order they started among those with the same order (zero, by default).
+func (l *Logger) SetSegmentOrder(order int) {
+	ws := getWriterState(l.out)
+	ws.lock()
+	defer ws.unlock()
+	l.customized = true
+	l.segmentOrder = order
+	updateTempOutput(l.out)
+}
+
+// SetSegmentSeparator sets the separator placed between partial lines in
+// singleline mode for this Logger's writer. The default is " | ".
+func (l *Logger) SetSegmentSeparator(sep string) {
+	ws := getWriterState(l.out)
+	ws.lock()
+	defer ws.unlock()
+	ws.segmentSep = []byte(sep)
+	updateTempOutput(l.out)
+}
+
+func SetSegmentWeight(weight float64) { DefaultLogger.SetSegmentWeight(weight) }
+func SetSegmentOrder(order int)       { DefaultLogger.SetSegmentOrder(order) }
+func SetSegmentSeparator(sep string)  { DefaultLogger.SetSegmentSeparator(sep) }
+
+func (l *Logger) getSegmentWeight() float64 {
+	if l.segmentWeight > 0 {
+		return l.segmentWeight
+	}
+	return 1
+}
+
+func (w *WriterState) getSegmentSeparator() []byte {
+	if w.segmentSep != nil {
+		return w.segmentSep
+	}
+	return tempLineSep
+}
@@ -0,0 +1,42 @@
+package alog
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSegmentOrderAndSeparator(t *testing.T) {
+	assert := assert.New(t)
+	var buf bytes.Buffer
+	writer1 := New(&buf, "", 0)
+	writer2 := New(&buf, "", 0)
+	writer1.SetTerminalWidth(80)
+	writer1.ShowPartialLines()
+	writer1.Print("first")
+	writer2.Print("second")
+	buf.Reset()
+	writer2.SetSegmentOrder(-1)
+	assert.Equal("\rsecond | first", buf.String())
+	buf.Reset()
+	writer1.SetSegmentSeparator(" / ")
+	assert.Equal("\r\033[7C/", buf.String())
+	writer1.Close()
+	writer2.Close()
+}
+
+func TestSegmentWeight(t *testing.T) {
+	assert := assert.New(t)
+	var buf bytes.Buffer
+	writer1 := New(&buf, "", 0)
+	writer2 := New(&buf, "", 0)
+	writer1.SetTerminalWidth(41)
+	writer1.ShowPartialLines()
+	writer1.SetSegmentWeight(3)
+	writer1.Print("important important")
+	writer2.Print("chatter chatter chatter")
+	assert.True(bytes.HasSuffix(buf.Bytes(), []byte("important important | chatter chatter...")), "%q", buf.String())
+	writer1.Close()
+	writer2.Close()
+}
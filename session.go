@@ -0,0 +1,133 @@
+package alog
+
+import (
+	"io"
+	"sync"
+)
+
+// A Session is an independent terminal, e.g. one per client of an SSH
+// server. Loggers created from a Session share partial lines, width and
+// other per-writer settings with each other but not with Loggers of other
+// Sessions, even when they write to the same io.Writer.
+type Session struct {
+	out       *sessionWriter
+	closeOnce sync.Once
+	closed    chan struct{}
+	ptyWG     sync.WaitGroup
+}
+
+// sessionWriter gives each Session a writer of its own, so that it gets its
+// own WriterState.
+type sessionWriter struct {
+	out   io.Writer
+	mutex *sessionWriteLock
+}
+
+func (w *sessionWriter) Write(p []byte) (int, error) {
+	w.mutex.Lock()
+	defer w.mutex.Unlock()
+	return w.out.Write(p)
+}
+
+// A sessionWriteLock serializes the writes of all Sessions sharing an
+// io.Writer, since each writes out its own queue.
+type sessionWriteLock struct {
+	sync.Mutex
+	users int
+}
+
+var sessionWriteLocks struct {
+	sync.Mutex
+	locks map[io.Writer]*sessionWriteLock
+}
+
+func acquireSessionWriteLock(w io.Writer) *sessionWriteLock {
+	sessionWriteLocks.Lock()
+	defer sessionWriteLocks.Unlock()
+	if sessionWriteLocks.locks == nil {
+		sessionWriteLocks.locks = map[io.Writer]*sessionWriteLock{}
+	}
+	lock, ok := sessionWriteLocks.locks[w]
+	if !ok {
+		lock = &sessionWriteLock{}
+		sessionWriteLocks.locks[w] = lock
+	}
+	lock.users++
+	return lock
+}
+
+func releaseSessionWriteLock(w io.Writer) {
+	sessionWriteLocks.Lock()
+	defer sessionWriteLocks.Unlock()
+	lock, ok := sessionWriteLocks.locks[w]
+	if !ok {
+		return
+	}
+	if lock.users--; lock.users == 0 {
+		delete(sessionWriteLocks.locks, w)
+	}
+}
+
+// NewSession creates a Session writing to w.
+func NewSession(w io.Writer) *Session {
+	return &Session{
+		out:    &sessionWriter{out: w, mutex: acquireSessionWriteLock(w)},
+		closed: make(chan struct{}),
+	}
+}
+
+// New creates a Logger writing to the Session, as New does for an io.Writer.
+func (s *Session) New(prefix string, flag int) *Logger {
+	return New(s.out, prefix, flag)
+}
+
+// Writer returns the Session's writer. Anything written to it directly is
+// written out as is, without regard for partial lines.
+func (s *Session) Writer() io.Writer {
+	return s.out
+}
+
+// Resize sets the size of the Session's terminal and redraws partial lines
+// for it.
+func (s *Session) Resize(size WindowSize) {
+	getWriterState(s.out).resize(size)
+}
+
+// BindPTY resizes the Session with each size received from ptyReqs, until
+// either is closed. See the package-level BindPTY.
+func (s *Session) BindPTY(ptyReqs <-chan WindowSize) {
+	s.ptyWG.Add(1)
+	go func() {
+		defer s.ptyWG.Done()
+		for {
+			select {
+			case size, ok := <-ptyReqs:
+				if !ok {
+					return
+				}
+				s.Resize(size)
+			case <-s.closed:
+				return
+			}
+		}
+	}()
+}
+
+// Close completes any partial lines of the Session's Loggers, stops
+// BindPTY and releases its state. The Session's Loggers must not be used
+// afterwards. Closing a Session again does nothing.
+func (s *Session) Close() error {
+	s.closeOnce.Do(func() {
+		close(s.closed)
+		s.ptyWG.Wait()
+		ws := getWriterState(s.out)
+		ws.lock()
+		ws.closeAll()
+		ws.unlock()
+		mutexGlobal.Lock()
+		delete(writers, io.Writer(s.out))
+		mutexGlobal.Unlock()
+		releaseSessionWriteLock(s.out.out)
+	})
+	return nil
+}
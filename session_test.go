@@ -0,0 +1,99 @@
+package alog
+
+import (
+	"bytes"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSession(t *testing.T) {
+	assert := assert.New(t)
+	t.Setenv("COLUMNS", "")
+	var buf bytes.Buffer
+	s1 := NewSession(&buf)
+	s2 := NewSession(&buf)
+	s1.Resize(WindowSize{Width: 50, Height: 20})
+	s2.Resize(WindowSize{Width: 70, Height: 20})
+	assert.Equal(50, TerminalWidth(s1.Writer()))
+	assert.Equal(70, TerminalWidth(s2.Writer()))
+
+	l1 := s1.New("", 0)
+	l2 := s2.New("", 0)
+	l1.ShowPartialLines()
+	l2.ShowPartialLines()
+	l1.Print("one")
+	l2.Print("two")
+	assert.Len(getWriterState(s1.Writer()).tempLoggers, 1)
+	assert.Len(getWriterState(s2.Writer()).tempLoggers, 1)
+	buf.Reset()
+	s1.Close()
+	assert.Equal("\n", buf.String())
+	mutexGlobal.RLock()
+	_, ok := writers[s1.Writer()]
+	mutexGlobal.RUnlock()
+	assert.False(ok)
+	s2.Close()
+}
+
+// overlapDetector records whether two Writes ever overlapped.
+type overlapDetector struct {
+	active  atomic.Int32
+	overlap atomic.Bool
+}
+
+func (w *overlapDetector) Write(p []byte) (int, error) {
+	if w.active.Add(1) > 1 {
+		w.overlap.Store(true)
+	}
+	time.Sleep(time.Microsecond)
+	w.active.Add(-1)
+	return len(p), nil
+}
+
+func TestSessionsSerializeWrites(t *testing.T) {
+	assert := assert.New(t)
+	var out overlapDetector
+	var wg sync.WaitGroup
+	for i := 0; i < 4; i++ {
+		s := NewSession(&out)
+		defer s.Close()
+		l := s.New("", 0)
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 50; j++ {
+				l.Printf("line %d\n", j)
+			}
+		}()
+	}
+	wg.Wait()
+	assert.False(out.overlap.Load(), "Sessions on the same writer wrote at the same time")
+}
+
+func TestSessionCloseTwice(t *testing.T) {
+	assert := assert.New(t)
+	var buf bytes.Buffer
+	s1 := NewSession(&buf)
+	s2 := NewSession(&buf)
+	defer s2.Close()
+	ptyReqs := make(chan WindowSize)
+	s1.BindPTY(ptyReqs)
+	ptyReqs <- WindowSize{Width: 50, Height: 20}
+	assert.NoError(s1.Close())
+	assert.NoError(s1.Close())
+	sessionWriteLocks.Lock()
+	lock := sessionWriteLocks.locks[&buf]
+	sessionWriteLocks.Unlock()
+	if assert.NotNil(lock, "s2 still holds the lock") {
+		assert.Equal(1, lock.users)
+	}
+	select {
+	case ptyReqs <- WindowSize{Width: 70, Height: 20}:
+		t.Error("BindPTY kept running after Close")
+	default:
+	}
+}
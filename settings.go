@@ -0,0 +1,89 @@
+package alog
+
+import (
+	"regexp"
+	"sync/atomic"
+	"time"
+)
+
+// Settings that Loggers fall back on DefaultLogger for are read while holding
+// the lock of their own writer, not DefaultLogger's, so they're kept in
+// atomics to let them be changed while other Loggers are in use.
+
+// A triState is a setting that is on, off, or unset, in which case
+// DefaultLogger's applies.
+type triState struct {
+	v atomic.Int32
+}
+
+const (
+	triStateUnset int32 = iota
+	triStateOff
+	triStateOn
+)
+
+func (t *triState) set(flag bool) {
+	if flag {
+		t.v.Store(triStateOn)
+	} else {
+		t.v.Store(triStateOff)
+	}
+}
+
+func (t *triState) isSet() bool { return t.v.Load() != triStateUnset }
+
+// copyFrom makes t the same as other.
+func (t *triState) copyFrom(other *triState) { t.v.Store(other.v.Load()) }
+
+func isTrueDefaulted(flag *triState, fallback *triState) bool {
+	if v := flag.v.Load(); v != triStateUnset {
+		return v == triStateOn
+	}
+	return fallback.v.Load() == triStateOn
+}
+
+// A clockSetting holds a Logger's clock, if it has one.
+type clockSetting struct {
+	v atomic.Pointer[func() time.Time]
+}
+
+func (c *clockSetting) set(clock func() time.Time) {
+	if clock == nil {
+		c.v.Store(nil)
+	} else {
+		c.v.Store(&clock)
+	}
+}
+
+func (c *clockSetting) get() func() time.Time {
+	if clock := c.v.Load(); clock != nil {
+		return *clock
+	}
+	return nil
+}
+
+// A regexpSetting holds a Logger's color template regexp, if it has one.
+type regexpSetting struct {
+	v atomic.Pointer[regexp.Regexp]
+}
+
+func (r *regexpSetting) set(rgx *regexp.Regexp) { r.v.Store(rgx) }
+func (r *regexpSetting) get() *regexp.Regexp    { return r.v.Load() }
+
+// An intSetting is a number that is unset until set, in which case
+// DefaultLogger's applies.
+type intSetting struct {
+	v atomic.Pointer[int]
+}
+
+func (s *intSetting) set(n int) { s.v.Store(&n) }
+
+func intDefaulted(setting *intSetting, fallback *intSetting) int {
+	if n := setting.v.Load(); n != nil {
+		return *n
+	}
+	if n := fallback.v.Load(); n != nil {
+		return *n
+	}
+	return 0
+}
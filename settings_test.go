@@ -0,0 +1,33 @@
+package alog
+
+import (
+	"bytes"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestConcurrentDefaultSettings(t *testing.T) {
+	assert := assert.New(t)
+	var buf bytes.Buffer
+	writer := New(&buf, "", 0)
+	defer writer.Close()
+	defer DefaultLogger.SetColorEnabled(DefaultLogger.isColorEnabled())
+	defer DefaultLogger.SetClock(nil)
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		for i := 0; i < 100; i++ {
+			DefaultLogger.SetColorEnabled(i%2 == 0)
+			DefaultLogger.SetClock(time.Now)
+		}
+	}()
+	for i := 0; i < 100; i++ {
+		writer.Printf("@(red:%d)\n", i)
+	}
+	wg.Wait()
+	assert.Contains(buf.String(), "99")
+}
@@ -0,0 +1,104 @@
+package alog
+
+import (
+	"regexp"
+	"strconv"
+)
+
+// A severityRule gives lines matching a pattern a level.
+type severityRule struct {
+	pattern *regexp.Regexp
+	level   Level
+}
+
+var (
+	// syslog's <PRI> header, as written by e.g. systemd's journal export.
+	syslogPriorityRegexp = regexp.MustCompile(`^<(\d{1,3})>`)
+	// klog/glog headers: Lmmdd hh:mm:ss.uuuuuu ...
+	klogHeaderRegexp = regexp.MustCompile(`^([IWEF])\d{4} \d\d:\d\d:\d\d`)
+	errorWordRegexp  = regexp.MustCompile(`\b(?:ERROR|ERR|FATAL|PANIC|CRITICAL|CRIT|SEVERE|EMERG|ALERT)\b|(?i:\blevel[=:]\s*"?(?:error|err|fatal|panic|critical|crit)\b|^\W*(?:error|fatal|panic)[]:])`)
+	warnWordRegexp   = regexp.MustCompile(`\b(?:WARN|WARNING)\b|(?i:\blevel[=:]\s*"?(?:warn|warning)\b|^\W*(?:warn|warning)[]:])`)
+)
+
+// SetSeverityDetection sets whether the Logger guesses the level of each
+// completed line from its text, and styles it accordingly, for output of
+// other programs passed through it (see Mux, Follow and Command). Rules added
+// with AddSeverityRule are tried first; then syslog priorities, klog headers,
+// and words like ERROR, WARN and level=error are recognized. Lines only
+// ever get a higher level this way, never a lower one. Off by default.
+func (l *Logger) SetSeverityDetection(flag bool) {
+	ws := getWriterState(l.out)
+	ws.lock()
+	defer ws.unlock()
+	l.customized = true
+	l.severityDetection = flag
+}
+
+// AddSeverityRule makes lines matching pattern get level when severity
+// detection is on. Rules are tried in the order they were added.
+func (l *Logger) AddSeverityRule(pattern *regexp.Regexp, level Level) {
+	ws := getWriterState(l.out)
+	ws.lock()
+	defer ws.unlock()
+	l.customized = true
+	l.severityRules = append(l.severityRules, severityRule{pattern, level})
+}
+
+func SetSeverityDetection(flag bool) { DefaultLogger.SetSeverityDetection(flag) }
+func AddSeverityRule(pattern *regexp.Regexp, level Level) {
+	DefaultLogger.AddSeverityRule(pattern, level)
+}
+
+// detectSeverity raises the level of a completed line to the one its text
+// suggests, if severity detection is on, and returns it styled for that
+// level. Must be called with the writer lock held.
+func (l *Logger) detectSeverity(line []byte) []byte {
+	if !l.severityDetection {
+		return line
+	}
+	level, ok := l.guessLevel(uncolorize(line))
+	if !ok || level <= l.lineLevel {
+		return line
+	}
+	l.lineLevel = level
+	return styleForLevel(level, line)
+}
+
+func (l *Logger) guessLevel(line []byte) (Level, bool) {
+	for _, rule := range l.severityRules {
+		if rule.pattern.Match(line) {
+			return rule.level, true
+		}
+	}
+	return guessLevel(line)
+}
+
+// guessLevel recognizes the severity of a line of foreign log output.
+func guessLevel(line []byte) (Level, bool) {
+	if match := syslogPriorityRegexp.FindSubmatch(line); match != nil {
+		priority, _ := strconv.Atoi(string(match[1]))
+		switch severity := priority % 8; {
+		case severity <= 3:
+			return LevelError, true
+		case severity == 4:
+			return LevelWarn, true
+		}
+		return LevelInfo, true
+	}
+	if match := klogHeaderRegexp.FindSubmatch(line); match != nil {
+		switch match[1][0] {
+		case 'E', 'F':
+			return LevelError, true
+		case 'W':
+			return LevelWarn, true
+		}
+		return LevelInfo, true
+	}
+	if errorWordRegexp.Match(line) {
+		return LevelError, true
+	}
+	if warnWordRegexp.Match(line) {
+		return LevelWarn, true
+	}
+	return LevelInfo, false
+}
@@ -0,0 +1,53 @@
+package alog
+
+import (
+	"bytes"
+	"regexp"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGuessLevel(t *testing.T) {
+	assert := assert.New(t)
+	for line, expected := range map[string]Level{
+		"<3>disk failure":                      LevelError,
+		"<12>something odd":                    LevelWarn,
+		"<14>all good":                         LevelInfo,
+		"E0102 03:04:05.000000 1 main.go:1] x": LevelError,
+		"W0102 03:04:05.000000 1 main.go:1] x": LevelWarn,
+		"I0102 03:04:05.000000 1 main.go:1] x": LevelInfo,
+		"2024-01-02 [ERROR] connection lost":   LevelError,
+		"12:00:00 WARN retrying":               LevelWarn,
+		`ts=1 level=warning msg="slow"`:        LevelWarn,
+		"error: no such file":                  LevelError,
+	} {
+		level, ok := guessLevel([]byte(line))
+		assert.True(ok, line)
+		assert.Equal(expected, level, line)
+	}
+	for _, line := range []string{"no errors found", "Error handling is fine", "warnings: 0"} {
+		_, ok := guessLevel([]byte(line))
+		assert.False(ok, line)
+	}
+}
+
+func TestSeverityDetection(t *testing.T) {
+	assert := assert.New(t)
+	var buf bytes.Buffer
+	writer := New(&buf, "", 0)
+	defer writer.Close()
+	writer.Print("ERROR: plain\n")
+	assert.Equal("ERROR: plain\n", buf.String(), "off by default")
+	buf.Reset()
+	writer.SetSeverityDetection(true)
+	writer.AddSeverityRule(regexp.MustCompile(`^Traceback`), LevelError)
+	writer.Write([]byte("Traceback (most recent call last):\nWARN low disk\nfine\n"))
+	assert.Equal(strings.Join([]string{
+		string(styleForLevel(LevelError, []byte("Traceback (most recent call last):"))),
+		string(styleForLevel(LevelWarn, []byte("WARN low disk"))),
+		"fine",
+		"",
+	}, "\n"), buf.String())
+}
@@ -0,0 +1,23 @@
+//go:build unix
+
+package alog
+
+import (
+	"errors"
+	"os/signal"
+	"syscall"
+)
+
+// IgnoreSIGPIPE stops the process from being killed by SIGPIPE when stdout or
+// stderr is a pipe whose reader has gone away (e.g. `mytool | head`). Writes
+// then fail with EPIPE instead, which alog handles by quietly discarding
+// further output to that writer (see SetHidePartialLinesOnBrokenPipe and
+// OnWriteError), so the program can decide for itself whether to carry on.
+// This changes process-wide signal handling, so it is opt-in.
+func IgnoreSIGPIPE() {
+	signal.Ignore(syscall.SIGPIPE)
+}
+
+func isEPIPE(err error) bool {
+	return errors.Is(err, syscall.EPIPE)
+}
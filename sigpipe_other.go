@@ -0,0 +1,10 @@
+//go:build !unix
+
+package alog
+
+// IgnoreSIGPIPE does nothing on platforms without SIGPIPE.
+func IgnoreSIGPIPE() {}
+
+func isEPIPE(err error) bool {
+	return false
+}
@@ -0,0 +1,167 @@
+package alog
+
+import (
+	"context"
+	"time"
+)
+
+// An Entry describes a completed line, as passed to an EntrySink.
+type Entry struct {
+	Time    time.Time // when the line was completed
+	Start   time.Time // when the line was first displayed as a partial line; equal to Time if it never was
+	Level   Level
+	Prefix  string // the formatted header, which may contain ANSI escapes
+	Message string // the line itself, which may contain ANSI escapes
+	// Context is the context the line was printed with (see PrintContext),
+	// or nil, and Fields are the annotations extracted from it.
+	Context context.Context
+	Fields  []Field
+}
+
+// PlainPrefix returns e.Prefix with ANSI escapes removed.
+func (e *Entry) PlainPrefix() string { return string(uncolorize([]byte(e.Prefix))) }
+
+// PlainMessage returns e.Message with ANSI escapes removed.
+func (e *Entry) PlainMessage() string { return string(uncolorize([]byte(e.Message))) }
+
+// An EntrySink receives every completed line written by the Loggers it is
+// added to. WriteEntry is called with the Logger's writer lock held, so it
+// must be quick and must not log to the same writer; sinks that do I/O
+// should buffer and do it in the background. The Entry is only valid for the
+// duration of the call.
+type EntrySink interface {
+	WriteEntry(e *Entry) error
+}
+
+// AddSink adds a sink that will receive every line completed by this Logger
+// from now on, in addition to it being written to the Logger's output.
+func (l *Logger) AddSink(sink EntrySink) {
+	ws := getWriterState(l.out)
+	ws.lock()
+	defer ws.unlock()
+	l.customized = true
+	l.addSinkLocked(sink)
+}
+
+// addSinkLocked adds sink. Must be called with the writer lock held.
+func (l *Logger) addSinkLocked(sink EntrySink) {
+	if len(l.sinks) == 0 {
+		ws := getWriterState(l.out)
+		ws.sinkLoggers = append(ws.sinkLoggers, l)
+	}
+	l.sinks = append(l.sinks, sink)
+}
+
+// RemoveSink removes a sink previously added with AddSink.
+func (l *Logger) RemoveSink(sink EntrySink) {
+	ws := getWriterState(l.out)
+	ws.lock()
+	defer ws.unlock()
+	l.customized = true
+	l.removeSinkLocked(sink)
+}
+
+// removeSinkLocked removes sink. Must be called with the writer lock held.
+func (l *Logger) removeSinkLocked(sink EntrySink) {
+	for i, s := range l.sinks {
+		if s == sink {
+			l.sinks = append(l.sinks[:i:i], l.sinks[i+1:]...)
+			break
+		}
+	}
+	if len(l.sinks) == 0 {
+		ws := getWriterState(l.out)
+		for i, logger := range ws.sinkLoggers {
+			if logger == l {
+				ws.sinkLoggers = append(ws.sinkLoggers[:i], ws.sinkLoggers[i+1:]...)
+				break
+			}
+		}
+	}
+}
+
+func AddSink(sink EntrySink)    { DefaultLogger.AddSink(sink) }
+func RemoveSink(sink EntrySink) { DefaultLogger.RemoveSink(sink) }
+
+// A FlushingSink is an EntrySink that buffers what it's given. Flush is
+// called when the Logger is closed, and before the program exits through Fatal
+// and the like, so that the last lines aren't lost.
+type FlushingSink interface {
+	EntrySink
+	Flush() error
+}
+
+// flushingSinks returns the Logger's sinks that buffer. Must be called with the
+// writer lock held.
+func (l *Logger) flushingSinks() []FlushingSink {
+	var sinks []FlushingSink
+	for _, sink := range l.sinks {
+		if flushing, ok := sink.(FlushingSink); ok {
+			sinks = append(sinks, flushing)
+		}
+	}
+	return sinks
+}
+
+// A PartialLineSink is an EntrySink that also sees partial lines: after each
+// update to a Logger's partial line, WritePartialEntry is called with an
+// Entry for the line so far. The same rules apply as for WriteEntry.
+type PartialLineSink interface {
+	EntrySink
+	WritePartialEntry(e *Entry) error
+}
+
+// emitPartialEntry passes the partial line to the Logger's sinks that want
+// it. Must be called with the writer lock held.
+func (l *Logger) emitPartialEntry() {
+	var entry *Entry
+	for _, sink := range l.sinks {
+		partialSink, ok := sink.(PartialLineSink)
+		if !ok {
+			continue
+		}
+		if entry == nil {
+			line := l.redact(l.buf)
+			var header []byte
+			l.formatHeader(&header, line)
+			entry = &Entry{
+				Time:    l.now,
+				Start:   l.lineStartTime,
+				Level:   l.lineLevel,
+				Prefix:  string(header),
+				Message: string(line),
+				Context: l.lineCtx,
+				Fields:  l.lineFields,
+			}
+		}
+		partialSink.WritePartialEntry(entry)
+	}
+}
+
+// emitEntry passes a completed line to the Logger's sinks. Must be called with
+// the writer lock held, before the line's level is reset.
+func (l *Logger) emitEntry(line []byte, wasTempLine bool) {
+	if len(l.sinks) == 0 {
+		return
+	}
+	var header []byte
+	l.formatHeader(&header, line)
+	entry := Entry{
+		Time:    l.now,
+		Start:   l.now,
+		Level:   l.lineLevel,
+		Prefix:  string(header),
+		Message: string(line),
+		Context: l.lineCtx,
+		Fields:  l.lineFields,
+	}
+	if wasTempLine && !l.lineStartTime.IsZero() {
+		entry.Start = l.lineStartTime
+	}
+	for _, sink := range l.sinks {
+		if l.reprintingHistory && sink == EntrySink(l.history) {
+			continue
+		}
+		sink.WriteEntry(&entry)
+	}
+}
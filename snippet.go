@@ -0,0 +1,85 @@
+package alog
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+)
+
+// EnableSourceSnippets makes errors print the line of source they were
+// logged from, with contextLines lines on either side and a caret under the
+// start of the statement, like a compiler diagnostic. This applies to lines
+// logged at LevelError, including by Bail and Assert, when Lshortfile or
+// Llongfile is set and the source file can be read.
+func (l *Logger) EnableSourceSnippets(contextLines int) {
+	ws := getWriterState(l.out)
+	ws.lock()
+	defer ws.unlock()
+	l.customized = true
+	l.snippetsEnabled = true
+	l.snippetContext = contextLines
+}
+
+// DisableSourceSnippets stops errors from printing source snippets.
+func (l *Logger) DisableSourceSnippets() {
+	ws := getWriterState(l.out)
+	ws.lock()
+	defer ws.unlock()
+	l.customized = true
+	l.snippetsEnabled = false
+}
+
+func EnableSourceSnippets(contextLines int) { DefaultLogger.EnableSourceSnippets(contextLines) }
+func DisableSourceSnippets()                { DefaultLogger.DisableSourceSnippets() }
+
+// writeSourceSnippet writes the snippet for the caller of the current call.
+// Must be called with the writer lock held.
+func (l *Logger) writeSourceSnippet() {
+	src, err := os.ReadFile(l.callerPath)
+	if err != nil {
+		return
+	}
+	ws := getWriterState(l.out)
+	for _, line := range sourceSnippet(src, l.callerLine, l.snippetContext) {
+		if !l.isColorEnabled() {
+			line = uncolorize(line)
+		}
+		if out := l.routeFor(l.lineLevel); out != nil && out != l.out {
+			ws.routed = append(ws.routed, routedLine{out, line})
+		} else {
+			l.writeCompletedLine(line)
+		}
+	}
+}
+
+// sourceSnippet formats the lines of src around the 1-based lineNumber.
+func sourceSnippet(src []byte, lineNumber, contextLines int) [][]byte {
+	lines := bytes.Split(src, bytesNewline)
+	if lineNumber < 1 || lineNumber > len(lines) {
+		return nil
+	}
+	first, last := lineNumber-contextLines, lineNumber+contextLines
+	if first < 1 {
+		first = 1
+	}
+	if last > len(lines) {
+		last = len(lines)
+	}
+	numberWidth := len(fmt.Sprint(last))
+	var snippet [][]byte
+	for n := first; n <= last; n++ {
+		text := bytes.Replace(bytes.TrimRight(lines[n-1], " \t\r"), bytesTab, bytesTabSpaces, -1)
+		marker := " "
+		if n == lineNumber {
+			marker = styled("error", ">")
+		}
+		gutter := styled("dim", fmt.Sprintf("%*d |", numberWidth, n))
+		snippet = append(snippet, []byte(fmt.Sprintf("%s %s %s", marker, gutter, text)))
+		if n == lineNumber {
+			indent := len(text) - len(bytes.TrimLeft(text, " "))
+			caret := fmt.Sprintf("  %*s %s%s", numberWidth, "", styled("dim", "|"), " "+string(bytes.Repeat(bytesSpace, indent))+styled("error", "^"))
+			snippet = append(snippet, []byte(caret))
+		}
+	}
+	return snippet
+}
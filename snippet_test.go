@@ -0,0 +1,43 @@
+package alog
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSourceSnippet(t *testing.T) {
+	assert := assert.New(t)
+	src := []byte("package x\n\nfunc f() {\n\treturn g()\n}\n")
+	var lines []string
+	for _, line := range sourceSnippet(src, 4, 1) {
+		lines = append(lines, string(uncolorize(line)))
+	}
+	assert.Equal([]string{
+		"  3 | func f() {",
+		"> 4 |         return g()",
+		"    |         ^",
+		"  5 | }",
+	}, lines)
+	assert.Nil(sourceSnippet(src, 10, 1))
+}
+
+func TestEnableSourceSnippets(t *testing.T) {
+	assert := assert.New(t)
+	var buf bytes.Buffer
+	var writer = New(&buf, "", Lshortfile)
+	defer writer.Close()
+	writer.DisableColor()
+	writer.EnableSourceSnippets(0)
+	writer.Println("fine")
+	assert.Equal(1, strings.Count(buf.String(), "\n"))
+	buf.Reset()
+	writer.Errorf("broken\n") // the snippet shows this line
+	lines := strings.Split(buf.String(), "\n")
+	assert.Regexp(`^snippet_test.go:\d+: broken$`, lines[0])
+	assert.Regexp(`^> \d+ \| +writer.Errorf\("broken\\n"\) // the snippet shows this line$`, lines[1])
+	assert.Equal(fmt.Sprintf("  %s |         ^", strings.Repeat(" ", len(strings.Fields(lines[1])[1]))), lines[2])
+}
@@ -0,0 +1,144 @@
+package alog
+
+import (
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"reflect"
+	"runtime"
+	"strings"
+)
+
+// packagePath is the import path of this package, for recognizing its frames
+// in stack traces.
+var packagePath = reflect.TypeOf((*Logger)(nil)).Elem().PkgPath()
+
+// callerStack returns the current goroutine's stack, as formatted by
+// runtime.Stack and split into lines, without the frames of this package's
+// functions (such as Bail) at the top.
+func callerStack() []string {
+	size := 4096
+	for {
+		buf := make([]byte, size)
+		bytesWritten := runtime.Stack(buf, false)
+		if bytesWritten == size {
+			size *= 2
+			continue
+		}
+		lines := strings.Split(strings.TrimSpace(string(buf[:bytesWritten])), "\n")
+		// lines[0] is the goroutine header; frames take two lines each.
+		i := 1
+		for i+1 < len(lines) && isPackageFrame(lines[i], lines[i+1]) {
+			i += 2
+		}
+		// When called while panicking, skip the panic call itself too.
+		if i+1 < len(lines) && strings.HasPrefix(lines[i], "panic(") {
+			i += 2
+		}
+		return append(lines[:1], lines[i:]...)
+	}
+}
+
+// isPackageFrame reports whether the frame with the given function and file
+// lines is in this package, not counting its tests.
+func isPackageFrame(funcLine, fileLine string) bool {
+	return strings.HasPrefix(funcLine, packagePath+".") && !strings.Contains(fileLine, "_test.go:")
+}
+
+// isStdlibFrame reports whether the frame with the given function line is in
+// the standard library, going by whether its import path starts with a
+// domain name.
+func isStdlibFrame(funcLine string) bool {
+	first := strings.TrimPrefix(funcLine, "created by ")
+	if i := strings.IndexByte(first, '/'); i >= 0 {
+		first = first[:i]
+	} else if i := strings.IndexByte(first, '.'); i >= 0 {
+		first = first[:i]
+	}
+	return first != "main" && !strings.Contains(first, ".")
+}
+
+// PrintStack prints the stack of the calling goroutine, one frame per line,
+// with the package-qualified function names colorized and the file paths
+// shortened: relative to the working directory (normally the module root)
+// when they are inside it, and to the package's import path otherwise. Frames
+// of this package are left out, and so are the first skip frames after that,
+// so that helpers can leave themselves out too.
+func (l *Logger) PrintStack(skip int) {
+	if !l.IsEnabled() {
+		return
+	}
+	frames := stackFrames(skip)
+	ws := getWriterState(l.out)
+	ws.lock()
+	defer ws.unlock()
+	for _, frame := range frames {
+		l.intOutput(2, []byte(formatFrame(frame)+"\n"), true)
+	}
+}
+
+func PrintStack(skip int) { DefaultLogger.PrintStack(skip) }
+
+// stackFrames returns the frames of the calling goroutine, without those of
+// this package and then the first skip others.
+func stackFrames(skip int) []runtime.Frame {
+	pcs := make([]uintptr, 64)
+	for {
+		n := runtime.Callers(1, pcs)
+		if n < len(pcs) {
+			pcs = pcs[:n]
+			break
+		}
+		pcs = make([]uintptr, 2*len(pcs))
+	}
+	var frames []runtime.Frame
+	iter := runtime.CallersFrames(pcs)
+	for {
+		frame, more := iter.Next()
+		if !strings.HasPrefix(frame.Function, packagePath+".") || strings.HasSuffix(frame.File, "_test.go") {
+			if skip > 0 {
+				skip--
+			} else {
+				frames = append(frames, frame)
+			}
+		}
+		if !more {
+			return frames
+		}
+	}
+}
+
+// formatFrame formats a frame as its colorized function name and shortened
+// file path and line.
+func formatFrame(frame runtime.Frame) string {
+	pkg, name := splitFuncName(frame.Function)
+	var s string
+	if pkg != "" {
+		s = styled("dim", pkg+".")
+	}
+	return s + styled("cyan", name) + " " + styled("dim", fmt.Sprintf("%s:%d", shortenPath(pkg, frame.File), frame.Line))
+}
+
+// splitFuncName splits a function name as reported by the runtime, e.g.
+// "net/http.(*conn).serve", into its import path and the rest.
+func splitFuncName(function string) (pkg, name string) {
+	slash := strings.LastIndexByte(function, '/') + 1
+	dot := strings.IndexByte(function[slash:], '.')
+	if dot < 0 {
+		return "", function
+	}
+	return function[:slash+dot], function[slash+dot+1:]
+}
+
+func shortenPath(pkg, file string) string {
+	if wd, err := os.Getwd(); err == nil {
+		if rel, err := filepath.Rel(wd, filepath.FromSlash(file)); err == nil && !strings.HasPrefix(rel, "..") {
+			return filepath.ToSlash(rel)
+		}
+	}
+	if pkg != "" && pkg != "main" {
+		return pkg + "/" + path.Base(file)
+	}
+	return file
+}
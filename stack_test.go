@@ -0,0 +1,33 @@
+package alog
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPrintStack(t *testing.T) {
+	assert := assert.New(t)
+	var buf bytes.Buffer
+	var writer = New(&buf, "", 0)
+	defer writer.Close()
+	writer.DisableColor()
+	helper := func() { writer.PrintStack(1) }
+	helper()
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	assert.Regexp(`^github.com/duppercloud/ansi-log.TestPrintStack stack_test.go:\d+$`, lines[0])
+	assert.Regexp(`^testing.tRunner testing/testing.go:\d+$`, lines[1])
+	assert.NotContains(buf.String(), "PrintStack stack.go")
+}
+
+func TestSplitFuncName(t *testing.T) {
+	assert := assert.New(t)
+	pkg, name := splitFuncName("net/http.(*conn).serve")
+	assert.Equal("net/http", pkg)
+	assert.Equal("(*conn).serve", name)
+	pkg, name = splitFuncName("main.main")
+	assert.Equal("main", pkg)
+	assert.Equal("main", name)
+}
@@ -0,0 +1,63 @@
+package alog
+
+import (
+	"strings"
+	"time"
+)
+
+// SetStallWarning marks this Logger's partial line, once nothing has been
+// written to it for d, with "stalled" and how long it's been quiet, in the
+// given style (a comma-separated list of color template names such as
+// "yellow"), to help spot hung tasks. Partial lines are redrawn at least every
+// second to keep the warnings current. A duration of zero turns the warning
+// off.
+func (l *Logger) SetStallWarning(d time.Duration, style string) {
+	ws := getWriterState(l.out)
+	ws.lock()
+	defer ws.unlock()
+	l.customized = true
+	if d > 0 && !l.stallRefresh {
+		ws.addRefreshUser(time.Second)
+	} else if d <= 0 {
+		l.stopStallRefresh(ws)
+	}
+	l.stallRefresh = d > 0
+	l.stallAfter = d
+	l.stallStyle = styleEscapes(style)
+}
+
+func SetStallWarning(d time.Duration, style string) { DefaultLogger.SetStallWarning(d, style) }
+
+// stopStallRefresh stops redrawing partial lines on this Logger's behalf, so
+// that closing it doesn't leave the refresh ticker running. Must be called
+// with the writer lock held.
+func (l *Logger) stopStallRefresh(ws *WriterState) {
+	if l.stallRefresh {
+		ws.removeRefreshUser(time.Second)
+		l.stallRefresh = false
+	}
+}
+
+// appendStallWarning adds the stall warning to buf, this Logger's formatted
+// partial line, if it's due. Must be called with the writer lock held.
+func (l *Logger) appendStallWarning(buf []byte) []byte {
+	if l.stallAfter <= 0 || l.lastOutputTime.IsZero() {
+		return buf
+	}
+	quiet := l.now.Sub(l.lastOutputTime)
+	if quiet < l.stallAfter {
+		return buf
+	}
+	buf = append(buf, getActiveAnsiCodes(buf).getResetBytes()...)
+	buf = append(buf, ' ')
+	buf = append(buf, l.stallStyle...)
+	buf = append(buf, "stalled "...)
+	buf = append(buf, strings.TrimSpace(FormatDuration(quiet))...)
+	if len(l.stallStyle) > 0 {
+		buf = append(buf, ansiBytesResetAll...)
+	}
+	if !l.isColorEnabled() {
+		buf = uncolorize(buf)
+	}
+	return buf
+}
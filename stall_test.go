@@ -0,0 +1,54 @@
+package alog
+
+import (
+	"bytes"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSetStallWarning(t *testing.T) {
+	assert := assert.New(t)
+	var buf bytes.Buffer
+	now := time.Date(2020, 1, 2, 3, 4, 5, 0, time.UTC)
+	writer := New(&buf, "", 0)
+	writer.SetClock(func() time.Time { return now })
+	writer.SetTerminalWidth(80)
+	writer.DisableColor()
+	writer.ShowPartialLines()
+	writer.SetRefreshInterval(time.Hour)
+	writer.SetStallWarning(time.Minute, "yellow")
+	defer writer.Close()
+	writer.Print("compiling...")
+	now = now.Add(30 * time.Second)
+	getWriterState(&buf).refresh()
+	assert.Equal("compiling...", buf.String())
+	now = now.Add(90 * time.Second)
+	getWriterState(&buf).refresh()
+	assert.Equal("compiling... stalled 120s", buf.String())
+	buf.Reset()
+	writer.Print(" ok")
+	assert.Equal("\r\033[13Cok          ", buf.String())
+	writer.SetRefreshInterval(0)
+	ws := getWriterState(&buf)
+	ws.lock()
+	assert.Equal(time.Second, ws.refreshRunning, "the stall warning still needs redrawing")
+	ws.unlock()
+	writer.SetStallWarning(0, "")
+	ws.lock()
+	assert.Equal(time.Duration(0), ws.refreshRunning)
+	ws.unlock()
+}
+
+func TestStallWarningClose(t *testing.T) {
+	assert := assert.New(t)
+	var buf bytes.Buffer
+	writer := New(&buf, "", 0)
+	writer.SetStallWarning(time.Minute, "")
+	writer.Close()
+	ws := getWriterState(&buf)
+	ws.lock()
+	assert.Equal(time.Duration(0), ws.refreshRunning, "closing the Logger stops the refresh ticker")
+	ws.unlock()
+}
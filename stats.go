@@ -0,0 +1,43 @@
+package alog
+
+// Stats holds counters describing what a Logger has written so far. Only
+// completed lines are counted; partial (temp) lines are not.
+type Stats struct {
+	Lines  uint64 // completed lines written
+	Bytes  uint64 // bytes of completed lines written, including newlines
+	Warns  uint64 // lines written at LevelWarn
+	Errors uint64 // lines written at LevelError
+}
+
+// countLine records a completed line in the Logger's stats and resets the line
+// state for the next line. Must be called with the writer lock held.
+func (l *Logger) countLine(formatted []byte) {
+	l.stats.Lines++
+	l.stats.Bytes += uint64(len(formatted)) + 1
+	switch l.lineLevel {
+	case LevelWarn:
+		l.stats.Warns++
+	case LevelError:
+		l.stats.Errors++
+	}
+	l.resetLineState()
+}
+
+// Stats returns a snapshot of the Logger's counters.
+func (l *Logger) Stats() Stats {
+	ws := getWriterState(l.out)
+	ws.lock()
+	defer ws.unlock()
+	return l.stats
+}
+
+// ResetStats zeroes the Logger's counters.
+func (l *Logger) ResetStats() {
+	ws := getWriterState(l.out)
+	ws.lock()
+	defer ws.unlock()
+	l.stats = Stats{}
+}
+
+// GetStats returns a snapshot of the standard logger's counters.
+func GetStats() Stats { return DefaultLogger.Stats() }
@@ -0,0 +1,28 @@
+package alog
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestStats(t *testing.T) {
+	assert := assert.New(t)
+	var buf bytes.Buffer
+	var writer = New(&buf, "", 0)
+	defer writer.Close()
+	writer.Print("one\ntwo\n")
+	writer.Warnf("careful: %d\n", 3)
+	writer.Print("partial ")
+	writer.Errorf("failed")
+	writer.Print("\n")
+	assert.Equal("one\ntwo\n\033[33mcareful: 3\033[39m\npartial \033[31mfailed\033[39m\n", buf.String())
+	stats := writer.Stats()
+	assert.Equal(uint64(4), stats.Lines)
+	assert.Equal(uint64(buf.Len()), stats.Bytes)
+	assert.Equal(uint64(1), stats.Warns)
+	assert.Equal(uint64(1), stats.Errors, "a line is counted at the highest level that contributed to it")
+	writer.ResetStats()
+	assert.Equal(Stats{}, writer.Stats())
+}
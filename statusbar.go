@@ -0,0 +1,109 @@
+package alog
+
+import (
+	"bytes"
+	"fmt"
+)
+
+const maxStatusBarLines = 3
+
+// EnableStatusBar splits the terminal of this Logger's writer into a
+// scrolling region, where completed lines are written, and a status bar of
+// the given number of lines (1 to 3) pinned to the bottom, where partial lines
+// and named segments are displayed. If there are more of those than lines, the
+// last line shows the rest joined together as in singleline mode. Call
+// DisableStatusBar before exiting to give the whole terminal back; Fatal and
+// friends do so automatically. It does nothing on platforms without cursor
+// movement (js/wasm).
+func (l *Logger) EnableStatusBar(lines int) {
+	if lines < 1 {
+		lines = 1
+	} else if lines > maxStatusBarLines {
+		lines = maxStatusBarLines
+	}
+	if !cursorMovementSupported {
+		return
+	}
+	ws := getWriterState(l.out)
+	ws.lock()
+	defer ws.unlock()
+	ws.flushAll()
+	ws.clearTempLines()
+	ws.lastTemp.reset()
+	ws.statusBarLines = lines
+	watchResize()
+	updateTempOutput(l.out)
+}
+
+// DisableStatusBar removes the status bar and restores normal scrolling.
+func (l *Logger) DisableStatusBar() {
+	ws := getWriterState(l.out)
+	ws.lock()
+	defer ws.unlock()
+	ws.disableStatusBar()
+	updateTempOutput(l.out)
+}
+
+func EnableStatusBar(lines int) { DefaultLogger.EnableStatusBar(lines) }
+func DisableStatusBar()         { DefaultLogger.DisableStatusBar() }
+
+// setScrollRegion makes room for the status bar at the bottom of the
+// terminal and confines scrolling to the rows above it. Must be called with
+// the writer lock held.
+func (w *WriterState) setScrollRegion(height int) {
+	var buf bytes.Buffer
+	// Scroll the existing contents up if necessary so that the cursor isn't
+	// left inside the status bar.
+	buf.Write(bytes.Repeat(bytesNewline, w.statusBarLines))
+	fmt.Fprintf(&buf, "\033[%dA", w.statusBarLines)
+	// Setting the scroll region homes the cursor, so save and restore it.
+	fmt.Fprintf(&buf, "\0337\033[1;%dr\0338", height-w.statusBarLines)
+	w.write(buf.Bytes())
+	w.statusBarHeight = height
+}
+
+func (w *WriterState) disableStatusBar() {
+	if w.statusBarLines == 0 {
+		return
+	}
+	var buf bytes.Buffer
+	buf.WriteString("\0337\033[r")
+	for i := 0; i < w.statusBarLines; i++ {
+		fmt.Fprintf(&buf, "\033[%d;1H\033[2K", w.statusBarHeight-i)
+	}
+	buf.WriteString("\0338")
+	w.write(buf.Bytes())
+	w.statusBarLines = 0
+	w.statusBarHeight = 0
+}
+
+// drawStatusBar renders segments into the status bar.
+func (w *WriterState) drawStatusBar(segments []tempSegment, maxWidth int, sep []byte) {
+	height := getTermHeight(w.out)
+	if height != w.statusBarHeight {
+		w.setScrollRegion(height)
+	}
+	lines := make([][]byte, w.statusBarLines)
+	for i, segment := range segments {
+		if i < len(lines)-1 {
+			lines[i] = segment.buf
+			continue
+		}
+		last := &lines[len(lines)-1]
+		if len(*last) > 0 {
+			*last = append(*last, sep...)
+		}
+		*last = append(*last, segment.buf...)
+		*last = append(*last, getActiveAnsiCodes(segment.buf).getResetBytes()...)
+	}
+	var buf bytes.Buffer
+	buf.WriteString("\0337")
+	for i, line := range lines {
+		fmt.Fprintf(&buf, "\033[%d;1H\033[2K", height-w.statusBarLines+1+i)
+		line = trimStringEllipsis(line, maxWidth)
+		buf.Write(line)
+		buf.Write(getActiveAnsiCodes(line).getResetBytes())
+	}
+	buf.WriteString("\0338")
+	w.write(buf.Bytes())
+}
@@ -0,0 +1,33 @@
+package alog
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestStatusBar(t *testing.T) {
+	assert := assert.New(t)
+	var buf bytes.Buffer
+	writer := New(&buf, "", 0)
+	writer.SetTerminalWidth(80)
+	writer.SetTerminalHeight(10)
+	writer.ShowPartialLines()
+	defer writer.Close()
+	writer.EnableStatusBar(2)
+	assert.Equal("\033[?25l\n\n\033[2A\0337\033[1;8r\0338\0337\033[9;1H\033[2K\033[10;1H\033[2K\0338\033[?25h", buf.String())
+	buf.Reset()
+	writer.Segment("jobs").Set("3 jobs")
+	assert.Equal("\033[?25l\0337\033[9;1H\033[2K3 jobs\033[10;1H\033[2K\0338\033[?25h", buf.String())
+	buf.Reset()
+	writer.Print("building")
+	assert.Equal("\033[?25l\0337\033[9;1H\033[2Kbuilding\033[10;1H\033[2K3 jobs\0338\033[?25h", buf.String())
+	buf.Reset()
+	writer.Print("... done\n")
+	assert.True(strings.HasPrefix(buf.String(), "building... done\n\033[?25l\0337"), "%q", buf.String())
+	buf.Reset()
+	writer.DisableStatusBar()
+	assert.True(strings.HasPrefix(buf.String(), "\0337\033[r\033[10;1H\033[2K\033[9;1H\033[2K\0338"), "%q", buf.String())
+}
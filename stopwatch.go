@@ -0,0 +1,60 @@
+package alog
+
+import (
+	"strings"
+	"time"
+)
+
+// A Stopwatch times a named activity, printing the time taken by each phase
+// of it as it is marked with Lap, and the total once it is stopped. Times are
+// read from the Logger's clock.
+type Stopwatch struct {
+	l     *Logger
+	name  string
+	start time.Time
+	lap   time.Time
+}
+
+// NewStopwatch starts a Stopwatch for the activity name, printing to l, or to
+// DefaultLogger if l is nil.
+func NewStopwatch(l *Logger, name string) *Stopwatch {
+	if l == nil {
+		l = DefaultLogger
+	}
+	now := l.currentTime()
+	return &Stopwatch{l: l, name: name, start: now, lap: now}
+}
+
+// currentTime returns the time according to the Logger's clock.
+func (l *Logger) currentTime() time.Time {
+	ws := getWriterState(l.out)
+	ws.lock()
+	defer ws.unlock()
+	return l.clockNow()
+}
+
+// Elapsed returns the time since the Stopwatch was started.
+func (sw *Stopwatch) Elapsed() time.Duration {
+	return sw.l.currentTime().Sub(sw.start)
+}
+
+// Lap prints the time since the previous Lap (or since the Stopwatch was
+// started) as the phase label, and returns it.
+func (sw *Stopwatch) Lap(label string) time.Duration {
+	now := sw.l.currentTime()
+	lap := now.Sub(sw.lap)
+	sw.lap = now
+	sw.print(label, lap)
+	return lap
+}
+
+// Stop prints the total time since the Stopwatch was started, and returns it.
+func (sw *Stopwatch) Stop() time.Duration {
+	total := sw.Elapsed()
+	sw.print("total", total)
+	return total
+}
+
+func (sw *Stopwatch) print(label string, duration time.Duration) {
+	sw.l.Println(sw.name + ": " + label + " " + styled("dim", strings.TrimSpace(FormatDuration(duration))))
+}
@@ -0,0 +1,26 @@
+package alog
+
+import (
+	"bytes"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestStopwatch(t *testing.T) {
+	assert := assert.New(t)
+	var buf bytes.Buffer
+	var writer = New(&buf, "", 0)
+	defer writer.Close()
+	writer.DisableColor()
+	now := time.Date(2020, 1, 2, 3, 4, 5, 0, time.UTC)
+	writer.SetClock(func() time.Time { return now })
+	sw := NewStopwatch(writer, "indexing")
+	now = now.Add(1500 * time.Millisecond)
+	assert.Equal(1500*time.Millisecond, sw.Lap("phase 1"))
+	now = now.Add(250 * time.Millisecond)
+	sw.Lap("phase 2")
+	assert.Equal(1750*time.Millisecond, sw.Stop())
+	assert.Equal("indexing: phase 1 1.50s\nindexing: phase 2 250ms\nindexing: total 1.75s\n", buf.String())
+}
@@ -0,0 +1,40 @@
+package alog
+
+import "bytes"
+
+// SetSuffix sets a template written at the end of completed lines, aligned
+// to the right edge of the terminal (or the width set with
+// SetLineLengthPolicy), e.g. "@(dim:{time})". Like the prefix, it may
+// contain color templates and the {time} and similar templates. If a
+// line is too long to fit the suffix beside it, the suffix follows it after
+// a space. An empty template removes the suffix.
+func (l *Logger) SetSuffix(template string) {
+	ws := getWriterState(l.out)
+	ws.lock()
+	defer ws.unlock()
+	l.customized = true
+	l.suffix = []byte(template)
+	l.reprocessPrefix()
+}
+
+func SetSuffix(template string) { DefaultLogger.SetSuffix(template) }
+
+// appendSuffix adds the suffix to buf, a formatted completed line. Must be
+// called with the writer lock held.
+func (l *Logger) appendSuffix(buf []byte) []byte {
+	if len(l.suffixFormatted) == 0 {
+		return buf
+	}
+	var suffix []byte
+	l.expandTemplate(&suffix, l.suffixFormatted)
+	if !l.isColorEnabled() {
+		suffix = uncolorize(suffix)
+	}
+	padding := l.getLineWidth() - stringLen(buf) - stringLen(suffix)
+	if padding < 1 {
+		padding = 1
+	}
+	buf = append(buf, getActiveAnsiCodes(buf).getResetBytes()...)
+	buf = append(buf, bytes.Repeat(bytesSpace, padding)...)
+	return append(buf, suffix...)
+}
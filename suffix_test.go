@@ -0,0 +1,27 @@
+package alog
+
+import (
+	"bytes"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSuffix(t *testing.T) {
+	assert := assert.New(t)
+	var buf bytes.Buffer
+	var writer = New(&buf, "", 0)
+	defer writer.Close()
+	writer.SetTerminalWidth(20)
+	writer.SetClock(func() time.Time { return time.Date(2020, 1, 2, 3, 4, 5, 0, time.UTC) })
+	writer.EnableColor()
+	writer.EnableColorTemplate()
+	writer.SetSuffix("@(dim:{time})")
+	writer.Print("\033[31mhello\n")
+	assert.Equal("\033[31mhello\033[39m       \033[1m\033[30m03:04:05\033[0m\n", buf.String())
+	buf.Reset()
+	writer.DisableColor()
+	writer.Print("a long line of text\n")
+	assert.Equal("a long line of text 03:04:05\n", buf.String())
+}
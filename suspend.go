@@ -0,0 +1,74 @@
+package alog
+
+import "os"
+
+// suspendAll gets every writer ready for the process to be stopped: partial
+// lines are cleared, colors reset and the whole terminal given back to normal
+// scrolling, and further output is held back as by Pause until continueAll.
+func suspendAll() {
+	for _, ws := range allWriterStates() {
+		ws.lock()
+		ws.suspend()
+		ws.unlock()
+	}
+}
+
+// continueAll undoes suspendAll, then redraws for the terminal's current size,
+// which may have changed while the process was stopped.
+func continueAll() {
+	for _, ws := range allWriterStates() {
+		ws.lock()
+		ws.continueAfterSuspend()
+		ws.unlock()
+	}
+	notifyResize()
+}
+
+func allWriterStates() []*WriterState {
+	mutexGlobal.RLock()
+	defer mutexGlobal.RUnlock()
+	return uniqueWriterStates()
+}
+
+// suspend must be called with the writer lock held.
+func (w *WriterState) suspend() {
+	if w.paused {
+		// Already cleared by Pause, and nothing will be drawn until Resume.
+		return
+	}
+	if w.mayHaveColors() {
+		w.write(ansiBytesResetAll)
+	}
+	if lines := w.statusBarLines; lines > 0 {
+		// Keep the status bar configured; with its height reset, the scroll
+		// region is set up again on the next redraw.
+		w.disableStatusBar()
+		w.statusBarLines = lines
+	}
+	w.pause()
+	w.suspended = true
+}
+
+// mayHaveColors reports whether colors may have been left on for the writer,
+// so that they need resetting: it's a terminal, or a Logger with a partial
+// line on it writes colors. Files and buffers are left alone otherwise. Must be
+// called with the writer lock held.
+func (w *WriterState) mayHaveColors() bool {
+	if file, ok := w.out.(*os.File); ok && isTerminal(file) {
+		return true
+	}
+	for _, logger := range w.tempLoggers {
+		if logger.isColorEnabled() {
+			return true
+		}
+	}
+	return false
+}
+
+// continueAfterSuspend must be called with the writer lock held.
+func (w *WriterState) continueAfterSuspend() {
+	if w.suspended {
+		w.suspended = false
+		w.resume()
+	}
+}
@@ -0,0 +1,6 @@
+//go:build !unix
+
+package alog
+
+// HandleSuspend does nothing on platforms without job control.
+func HandleSuspend() {}
@@ -0,0 +1,59 @@
+package alog
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSuspendAndContinue(t *testing.T) {
+	assert := assert.New(t)
+	var buf bytes.Buffer
+	var writer = New(&buf, "", 0)
+	writer.SetTerminalWidth(80)
+	writer.ShowPartialLines()
+	writer.EnableColor()
+	defer writer.Close()
+	writer.Print("working...")
+	buf.Reset()
+	ws := getWriterState(&buf)
+	ws.lock()
+	ws.suspend()
+	ws.unlock()
+	assert.Equal("\033[0m\r          \r", buf.String())
+	buf.Reset()
+	writer.Println("done")
+	assert.Equal("", buf.String())
+	ws.lock()
+	ws.continueAfterSuspend()
+	ws.redrawAfterResize()
+	ws.unlock()
+	assert.Equal("working...done\n", buf.String())
+
+	// A writer paused by the application stays paused.
+	writer.Pause()
+	ws.lock()
+	ws.suspend()
+	ws.continueAfterSuspend()
+	ws.unlock()
+	assert.True(writer.IsPaused())
+}
+
+func TestSuspendWithoutColors(t *testing.T) {
+	assert := assert.New(t)
+	var buf bytes.Buffer
+	var writer = New(&buf, "", 0)
+	writer.SetTerminalWidth(80)
+	writer.ShowPartialLines()
+	writer.DisableColor()
+	defer writer.Close()
+	writer.Print("working...")
+	buf.Reset()
+	ws := getWriterState(&buf)
+	ws.lock()
+	ws.suspend()
+	ws.continueAfterSuspend()
+	ws.unlock()
+	assert.Equal("\r          \r", buf.String(), "no escapes for writers without colors")
+}
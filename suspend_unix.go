@@ -0,0 +1,40 @@
+//go:build unix
+
+package alog
+
+import (
+	"os"
+	"os/signal"
+	"sync"
+	"syscall"
+)
+
+var suspendHandler sync.Once
+
+// HandleSuspend makes Ctrl-Z (SIGTSTP) leave the terminal tidy: before the
+// process stops, partial lines are cleared, colors are reset and the status
+// bar is removed, and once it's continued (SIGCONT), output is redrawn for the
+// terminal's current size. Without it, a suspended and resumed program can
+// leave the display garbled. This changes process-wide signal handling, so it
+// is opt-in.
+func HandleSuspend() {
+	suspendHandler.Do(func() {
+		stops := make(chan os.Signal, 1)
+		signal.Notify(stops, syscall.SIGTSTP)
+		go func() {
+			for range stops {
+				suspendAll()
+				stopProcess()
+				continueAll()
+			}
+		}()
+	})
+}
+
+// stopProcess stops the process in place of the SIGTSTP it caught, returning
+// once it has been continued. Once notified, SIGTSTP can't be given back its
+// default action of stopping the process, so SIGSTOP is raised instead; the
+// shell sees the same thing either way.
+func stopProcess() {
+	syscall.Kill(syscall.Getpid(), syscall.SIGSTOP)
+}
@@ -0,0 +1,77 @@
+package alog
+
+import (
+	"regexp"
+	"strings"
+)
+
+// levelSymbols are the markers that stand in for level colors in monochrome
+// symbols mode.
+var levelSymbols = map[Level]string{
+	LevelWarn:  "[WARN]",
+	LevelError: "[ERR]",
+}
+
+// SetMonochromeSymbols sets whether, when color is disabled, textual markers
+// stand in for the colors that would otherwise convey meaning: warnings and
+// errors start with "[WARN]" or "[ERR]", and text in "@(success:...)" or
+// "@(error:...)" templates gets a "✓" or "✗". This keeps severity visible in
+// output captured to plain files. It has no effect while color is enabled.
+// Unless set, a Logger uses DefaultLogger's setting, which is off by default.
+func (l *Logger) SetMonochromeSymbols(flag bool) {
+	ws := getWriterState(l.out)
+	ws.lock()
+	defer ws.unlock()
+	l.customized = true
+	l.monochromeSymbols.set(flag)
+}
+
+func (l *Logger) EnableMonochromeSymbols()  { l.SetMonochromeSymbols(true) }
+func (l *Logger) DisableMonochromeSymbols() { l.SetMonochromeSymbols(false) }
+
+func SetMonochromeSymbols(flag bool) { DefaultLogger.SetMonochromeSymbols(flag) }
+func EnableMonochromeSymbols()       { DefaultLogger.SetMonochromeSymbols(true) }
+func DisableMonochromeSymbols()      { DefaultLogger.SetMonochromeSymbols(false) }
+
+func (l *Logger) useMonochromeSymbols() bool {
+	return isTrueDefaulted(&l.monochromeSymbols, &DefaultLogger.monochromeSymbols) && !l.isColorEnabled()
+}
+
+// styleForLevel is like the function of the same name, adding the level's
+// icon, or its marker in monochrome symbols mode.
+func (l *Logger) styleForLevel(level Level, s []byte) []byte {
+	if icon, ok := l.levelIcons[level]; ok {
+		s = append([]byte(icon+" "), s...)
+	} else if symbol, ok := levelSymbols[level]; ok && l.useMonochromeSymbols() {
+		s = append([]byte(symbol+" "), s...)
+	}
+	return styleForLevel(level, s)
+}
+
+// templateSymbol returns the marker that stands in for the semantic color
+// template name in monochrome symbols mode, if there is one.
+func (g *glyphSet) templateSymbol(name string) (string, bool) {
+	switch name {
+	case "success":
+		return g.check, true
+	case "error":
+		return g.cross, true
+	}
+	return "", false
+}
+
+// insertTemplateSymbols adds the markers for semantic templates in s.
+func insertTemplateSymbols(glyphs *glyphSet, colorTemplateRegexp *regexp.Regexp, s string) string {
+	return colorTemplateRegexp.ReplaceAllStringFunc(s, func(template string) string {
+		match := colorTemplateRegexp.FindStringSubmatch(template)
+		if len(match) < 4 || match[2] == "" {
+			return template
+		}
+		for _, name := range strings.Split(match[1], ",") {
+			if symbol, ok := glyphs.templateSymbol(name); ok {
+				return "@(" + match[1] + ":" + symbol + " " + match[3] + ")"
+			}
+		}
+		return template
+	})
+}
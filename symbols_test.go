@@ -0,0 +1,42 @@
+package alog
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMonochromeSymbols(t *testing.T) {
+	assert := assert.New(t)
+	var buf bytes.Buffer
+	var writer = New(&buf, "", 0)
+	defer writer.Close()
+	writer.EnableUnicode()
+	writer.EnableColorTemplate()
+	writer.DisableColor()
+	writer.EnableMonochromeSymbols()
+	writer.Warnf("disk almost full\n")
+	writer.Errorf("disk full\n")
+	writer.Printf("@(success:saved), @(error:not synced)\n")
+	assert.Equal("[WARN] disk almost full\n[ERR] disk full\n✓ saved, ✗ not synced\n", buf.String())
+
+	buf.Reset()
+	writer.EnableColor()
+	writer.Errorf("disk full\n")
+	assert.Equal("\033[31mdisk full\033[39m\n", buf.String())
+}
+
+func TestMonochromeSymbolsDefaulted(t *testing.T) {
+	assert := assert.New(t)
+	var buf bytes.Buffer
+	var writer = New(&buf, "", 0)
+	defer writer.Close()
+	writer.DisableColor()
+	defer DisableMonochromeSymbols()
+	EnableMonochromeSymbols()
+	writer.Warnf("disk almost full\n")
+	writer.DisableMonochromeSymbols()
+	writer.Warnf("disk almost full\n")
+	assert.Equal("[WARN] disk almost full\ndisk almost full\n", buf.String())
+}
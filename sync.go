@@ -0,0 +1,99 @@
+package alog
+
+import (
+	"os"
+	"time"
+)
+
+// A SyncPolicy determines how often output written to a file is committed to
+// stable storage with fsync. It only applies to writers that are *os.Files
+// other than terminals.
+type SyncPolicy struct {
+	everyLine bool
+	interval  time.Duration
+}
+
+var (
+	// SyncNever leaves flushing to disk up to the operating system. This is
+	// the default.
+	SyncNever = SyncPolicy{}
+	// SyncEveryLine syncs after every write, so that the last lines logged
+	// before a crash are guaranteed to be on disk.
+	SyncEveryLine = SyncPolicy{everyLine: true}
+)
+
+// syncFile commits a file to stable storage; replaced in tests.
+var syncFile = (*os.File).Sync
+
+// SyncInterval syncs at most once per d, and always within d of a write.
+func SyncInterval(d time.Duration) SyncPolicy {
+	if d <= 0 {
+		return SyncEveryLine
+	}
+	return SyncPolicy{interval: d}
+}
+
+// fileSyncer applies a SyncPolicy to a file. Its methods must be called with
+// the owning writeQueue's mutex held.
+type fileSyncer struct {
+	file     *os.File
+	policy   SyncPolicy
+	lastSync time.Time
+	timer    *time.Timer
+}
+
+// afterWrite syncs the file if the policy calls for it. It returns the file
+// to sync, or nil; the sync itself should happen without holding any locks.
+func (s *fileSyncer) afterWrite(q *writeQueue) *os.File {
+	if s == nil {
+		return nil
+	}
+	if s.policy.everyLine {
+		return s.file
+	}
+	if s.policy.interval <= 0 {
+		return nil
+	}
+	now := time.Now()
+	if now.Sub(s.lastSync) >= s.policy.interval {
+		s.lastSync = now
+		return s.file
+	}
+	if s.timer == nil {
+		// Make sure trailing output gets synced even if nothing else is written.
+		s.timer = time.AfterFunc(s.policy.interval-now.Sub(s.lastSync), func() {
+			q.mutex.Lock()
+			s.timer = nil
+			s.lastSync = time.Now()
+			q.mutex.Unlock()
+			syncFile(s.file)
+		})
+	}
+	return nil
+}
+
+func (s *fileSyncer) stop() {
+	if s != nil && s.timer != nil {
+		s.timer.Stop()
+		s.timer = nil
+	}
+}
+
+// SetSyncPolicy sets how often output is synced to disk when the Logger's
+// writer is a regular file (or other non-terminal *os.File). It has no effect
+// for other writers. This applies to all Loggers sharing the writer.
+func (l *Logger) SetSyncPolicy(policy SyncPolicy) {
+	ws := getWriterState(l.out)
+	q := &ws.queue
+	q.mutex.Lock()
+	defer q.mutex.Unlock()
+	q.syncer.stop()
+	q.syncer = nil
+	file, ok := ws.out.(*os.File)
+	if !ok || isTerminal(file) || policy == SyncNever {
+		return
+	}
+	q.syncer = &fileSyncer{file: file, policy: policy, lastSync: time.Now()}
+}
+
+func SetSyncPolicy(policy SyncPolicy) { DefaultLogger.SetSyncPolicy(policy) }
@@ -0,0 +1,74 @@
+package alog
+
+import (
+	"os"
+	"path/filepath"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// countSyncs makes syncFile count its calls for the duration of the test.
+func countSyncs(t *testing.T) *atomic.Int32 {
+	var count atomic.Int32
+	prev := syncFile
+	syncFile = func(f *os.File) error {
+		count.Add(1)
+		return prev(f)
+	}
+	t.Cleanup(func() { syncFile = prev })
+	return &count
+}
+
+func openTempLog(t *testing.T) *os.File {
+	file, err := os.Create(filepath.Join(t.TempDir(), "log"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { file.Close() })
+	return file
+}
+
+func TestSyncEveryLine(t *testing.T) {
+	assert := assert.New(t)
+	syncs := countSyncs(t)
+	file := openTempLog(t)
+	writer := New(file, "", 0)
+	defer writer.Close()
+	writer.SetSyncPolicy(SyncEveryLine)
+	writer.Print("one\n")
+	writer.Print("two\n")
+	assert.Equal(int32(2), syncs.Load())
+	content, err := os.ReadFile(file.Name())
+	assert.NoError(err)
+	assert.Equal("one\ntwo\n", string(content))
+}
+
+func TestSyncInterval(t *testing.T) {
+	assert := assert.New(t)
+	syncs := countSyncs(t)
+	file := openTempLog(t)
+	writer := New(file, "", 0)
+	defer writer.Close()
+	writer.SetSyncPolicy(SyncInterval(50 * time.Millisecond))
+	writer.Print("one\n")
+	writer.Print("two\n")
+	assert.Equal(int32(0), syncs.Load(), "nothing is synced within the interval")
+	assert.Eventually(func() bool { return syncs.Load() == 1 }, 5*time.Second, time.Millisecond, "trailing output is synced once the interval is up")
+	assert.Equal(SyncEveryLine, SyncInterval(0))
+}
+
+func TestSyncNever(t *testing.T) {
+	assert := assert.New(t)
+	syncs := countSyncs(t)
+	file := openTempLog(t)
+	writer := New(file, "", 0)
+	defer writer.Close()
+	writer.SetSyncPolicy(SyncEveryLine)
+	writer.SetSyncPolicy(SyncNever)
+	writer.Print("one\n")
+	assert.Equal(int32(0), syncs.Load())
+	assert.Nil(getWriterState(file).queue.syncer)
+}
@@ -0,0 +1,55 @@
+package alog
+
+import "fmt"
+
+// A ProgressState is the state of the progress indicator shown by terminals
+// that support OSC 9;4 (Windows Terminal, ConEmu and some others) in the
+// taskbar or tab.
+type ProgressState int
+
+const (
+	ProgressNone ProgressState = iota
+	ProgressNormal
+	ProgressError
+	ProgressIndeterminate
+	ProgressPaused
+)
+
+// SetTaskbarProgress reports progress (0 to 100 percent) to the terminal that
+// this Logger writes to, for display in the taskbar or tab. Terminals that
+// don't support it ignore it, and nothing is written when the Logger doesn't
+// write to a terminal. Normal progress of 100 percent clears the
+// indicator, as does ProgressNone; it's also cleared by Fatal and friends.
+func (l *Logger) SetTaskbarProgress(state ProgressState, percent int) {
+	ws := getWriterState(l.out)
+	ws.lock()
+	defer ws.unlock()
+	if state == ProgressNormal && percent >= 100 {
+		state = ProgressNone
+	}
+	ws.setTaskbarProgress(state, percent)
+}
+
+func SetTaskbarProgress(state ProgressState, percent int) {
+	DefaultLogger.SetTaskbarProgress(state, percent)
+}
+
+// setTaskbarProgress must be called with the writer lock held.
+func (w *WriterState) setTaskbarProgress(state ProgressState, percent int) {
+	if !isTerminalWriter(w.out) {
+		return
+	}
+	if percent < 0 {
+		percent = 0
+	} else if percent > 100 {
+		percent = 100
+	}
+	if state == ProgressNone {
+		if !w.taskbarProgress {
+			return
+		}
+		percent = 0
+	}
+	w.taskbarProgress = state != ProgressNone
+	w.write([]byte(fmt.Sprintf("\033]9;4;%d;%d\a", state, percent)))
+}
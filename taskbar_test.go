@@ -0,0 +1,27 @@
+package alog
+
+import (
+	"bytes"
+	"io"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTaskbarProgress(t *testing.T) {
+	assert := assert.New(t)
+	var buf bytes.Buffer
+	writer := New(&buf, "", 0)
+	defer writer.Close()
+	writer.SetTaskbarProgress(ProgressNormal, 42)
+	assert.Equal("", buf.String(), "not a terminal")
+	defer func(prev func(io.Writer) bool) { isTerminalWriter = prev }(isTerminalWriter)
+	isTerminalWriter = func(out io.Writer) bool { return true }
+	writer.SetTaskbarProgress(ProgressNone, 0)
+	assert.Equal("", buf.String(), "nothing to clear")
+	writer.SetTaskbarProgress(ProgressNormal, 42)
+	assert.Equal("\033]9;4;1;42\a", buf.String())
+	buf.Reset()
+	writer.SetTaskbarProgress(ProgressNormal, 100)
+	assert.Equal("\033]9;4;0;0\a", buf.String())
+}
@@ -0,0 +1,51 @@
+package alog
+
+import "fmt"
+
+// An EvictionPolicy determines which partial lines are shown when there are
+// more than fit.
+type EvictionPolicy int
+
+const (
+	// EvictSummarizeOldest shows the most recent partial lines, preceded by one
+	// line summarizing the rest, e.g. "+12 more: task1... | task2...". This is
+	// the default.
+	EvictSummarizeOldest EvictionPolicy = iota
+	// EvictHideOldest shows only the most recent partial lines.
+	EvictHideOldest
+)
+
+// SetMaxTempLines limits the number of partial lines (lines, in multiline
+// mode, or segments otherwise) displayed at once for this Logger's writer to
+// n, so that programs with hundreds of concurrent tasks keep a readable
+// display. Zero means no limit, though in multiline mode partial lines are
+// always limited to the height of the terminal.
+func (l *Logger) SetMaxTempLines(n int, eviction EvictionPolicy) {
+	ws := getWriterState(l.out)
+	ws.lock()
+	defer ws.unlock()
+	ws.maxTempLines = n
+	ws.eviction = eviction
+	updateTempOutput(l.out)
+}
+
+func SetMaxTempLines(n int, eviction EvictionPolicy) { DefaultLogger.SetMaxTempLines(n, eviction) }
+
+// evictTempLines reduces segments, which are ordered from oldest to newest,
+// to limit lines according to the eviction policy.
+func (w *WriterState) evictTempLines(segments []tempSegment, limit int) []tempSegment {
+	if w.eviction == EvictHideOldest {
+		return segments[len(segments)-limit:]
+	}
+	kept := segments[len(segments)-(limit-1):]
+	hidden := segments[:len(segments)-(limit-1)]
+	summary := []byte(fmt.Sprintf("+%d more: ", len(hidden)))
+	for i, segment := range hidden {
+		if i > 0 {
+			summary = append(summary, w.getSegmentSeparator()...)
+		}
+		summary = append(summary, segment.buf...)
+		summary = append(summary, getActiveAnsiCodes(segment.buf).getResetBytes()...)
+	}
+	return append([]tempSegment{{buf: summary, weight: 1}}, kept...)
+}
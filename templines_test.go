@@ -0,0 +1,32 @@
+package alog
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSetMaxTempLines(t *testing.T) {
+	assert := assert.New(t)
+	var buf bytes.Buffer
+	var writers []*Logger
+	for i := 0; i < 4; i++ {
+		writers = append(writers, New(&buf, "", 0))
+	}
+	writers[0].SetTerminalWidth(80)
+	writers[0].ShowPartialLines()
+	writers[0].SetMaxTempLines(2, EvictHideOldest)
+	for i, writer := range writers {
+		writer.Print(fmt.Sprintf("task%d", i))
+	}
+	assert.True(strings.HasSuffix(buf.String(), "\rtask2 | task3"), "%q", buf.String())
+	buf.Reset()
+	writers[0].SetMaxTempLines(2, EvictSummarizeOldest)
+	assert.Equal("\r+3 more: task0 | task1 | task2 | task3", buf.String())
+	for _, writer := range writers {
+		writer.Close()
+	}
+}
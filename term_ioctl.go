@@ -0,0 +1,35 @@
+//go:build linux || darwin || freebsd || openbsd || netbsd || dragonfly
+
+package alog
+
+import (
+	"io"
+	"os"
+	"syscall"
+	"unsafe"
+)
+
+// getWinsize asks the terminal behind writer for its dimensions.
+func getWinsize(writer io.Writer) (rows, cols int, ok bool) {
+	var fd int
+	if writer == os.Stdout {
+		fd = syscall.Stdout
+	} else {
+		// For custom writers, just use the size we get for stderr. This might not be true in some
+		// cases (and for those cases, we should add an option to explicitly set width), but it will
+		// be true in most cases.
+		fd = syscall.Stderr
+	}
+	var dimensions [4]uint16
+	if _, _, err := syscall.Syscall6(syscall.SYS_IOCTL, uintptr(fd), uintptr(syscall.TIOCGWINSZ), uintptr(unsafe.Pointer(&dimensions)), 0, 0, 0); err != 0 {
+		return 0, 0, false
+	}
+	return int(dimensions[0]), int(dimensions[1]), true
+}
+
+// isTerminal reports whether f refers to a terminal.
+func isTerminal(f *os.File) bool {
+	var dimensions [4]uint16
+	_, _, err := syscall.Syscall6(syscall.SYS_IOCTL, f.Fd(), uintptr(syscall.TIOCGWINSZ), uintptr(unsafe.Pointer(&dimensions)), 0, 0, 0)
+	return err == 0
+}
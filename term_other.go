@@ -0,0 +1,25 @@
+//go:build !unix
+
+package alog
+
+import (
+	"io"
+	"os"
+)
+
+// Without a Unix terminal to query or move the cursor around in (e.g. on
+// js/wasm or Plan 9), output is laid out for a fixed width (80 columns unless
+// set), and partial lines are drawn using carriage returns only.
+const cursorMovementSupported = false
+
+func getWinsize(writer io.Writer) (rows, cols int, ok bool) {
+	return 0, 0, false
+}
+
+func isTerminal(f *os.File) bool {
+	return false
+}
+
+func resizeSignals() <-chan os.Signal {
+	return nil
+}
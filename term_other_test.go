@@ -0,0 +1,30 @@
+//go:build !unix
+
+package alog
+
+import (
+	"bytes"
+	"errors"
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNoTerminal(t *testing.T) {
+	assert := assert.New(t)
+	t.Setenv("COLUMNS", "")
+	t.Setenv("LINES", "")
+	assert.False(cursorMovementSupported)
+	assert.False(isTerminal(os.Stderr))
+	assert.Nil(resizeSignals())
+	var buf bytes.Buffer
+	assert.Equal(80, getTermWidth(&buf))
+	assert.Equal(24, getTermHeight(&buf))
+}
+
+func TestIsEPIPE(t *testing.T) {
+	assert := assert.New(t)
+	IgnoreSIGPIPE()
+	assert.False(isEPIPE(errors.New("broken pipe")))
+}
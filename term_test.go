@@ -0,0 +1,29 @@
+package alog
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFallbackWidth(t *testing.T) {
+	assert := assert.New(t)
+	if os.Getenv("ALOG_TEST_FALLBACK_WIDTH") == "1" {
+		var buf bytes.Buffer
+		fmt.Print(getTermWidth(&buf))
+		return
+	}
+	// Run without a terminal on stdout or stderr, whatever runs the tests.
+	cmd := exec.Command(os.Args[0], "-test.run=^TestFallbackWidth$")
+	cmd.Env = append(os.Environ(), "ALOG_TEST_FALLBACK_WIDTH=1", "COLUMNS=")
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	out, err := cmd.Output()
+	assert.NoError(err, stderr.String())
+	assert.True(strings.HasPrefix(string(out), "80"), "%q", out)
+}
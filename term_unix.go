@@ -0,0 +1,21 @@
+//go:build unix
+
+package alog
+
+import (
+	"os"
+	"os/signal"
+	"syscall"
+)
+
+// cursorMovementSupported is false where output can't be redrawn in place, so
+// multiline mode and the status bar are unavailable.
+const cursorMovementSupported = true
+
+// resizeSignals returns a channel that receives a value whenever the
+// terminal is resized.
+func resizeSignals() <-chan os.Signal {
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, syscall.SIGWINCH)
+	return signals
+}
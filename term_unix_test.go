@@ -0,0 +1,42 @@
+//go:build unix
+
+package alog
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestResizeSignals(t *testing.T) {
+	assert := assert.New(t)
+	assert.True(cursorMovementSupported)
+	signals := resizeSignals()
+	assert.NoError(syscall.Kill(os.Getpid(), syscall.SIGWINCH))
+	select {
+	case sig := <-signals:
+		assert.Equal(syscall.SIGWINCH, sig)
+	case <-time.After(5 * time.Second):
+		t.Fatal("no signal for a resize")
+	}
+}
+
+func TestIsTerminalFile(t *testing.T) {
+	assert := assert.New(t)
+	f, err := os.CreateTemp(t.TempDir(), "")
+	assert.NoError(err)
+	defer f.Close()
+	assert.False(isTerminal(f))
+}
+
+func TestIsEPIPE(t *testing.T) {
+	assert := assert.New(t)
+	assert.True(isEPIPE(syscall.EPIPE))
+	assert.True(isEPIPE(fmt.Errorf("write: %w", syscall.EPIPE)))
+	assert.False(isEPIPE(errors.New("broken")))
+}
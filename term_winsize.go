@@ -0,0 +1,34 @@
+//go:build solaris || aix
+
+package alog
+
+import (
+	"io"
+	"os"
+
+	"golang.org/x/sys/unix"
+)
+
+// The syscall package doesn't offer ioctl here, so use x/sys.
+func winsize(fd int) (rows, cols int, ok bool) {
+	size, err := unix.IoctlGetWinsize(fd, unix.TIOCGWINSZ)
+	if err != nil {
+		return 0, 0, false
+	}
+	return int(size.Row), int(size.Col), true
+}
+
+// getWinsize asks the terminal behind writer for its dimensions.
+func getWinsize(writer io.Writer) (rows, cols int, ok bool) {
+	if writer == os.Stdout {
+		return winsize(int(os.Stdout.Fd()))
+	}
+	// As elsewhere, assume custom writers end up on the same terminal as stderr.
+	return winsize(int(os.Stderr.Fd()))
+}
+
+// isTerminal reports whether f refers to a terminal.
+func isTerminal(f *os.File) bool {
+	_, _, ok := winsize(int(f.Fd()))
+	return ok
+}
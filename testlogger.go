@@ -0,0 +1,78 @@
+package alog
+
+import (
+	"bytes"
+	"strings"
+	"sync"
+)
+
+// TB is the subset of testing.TB used by NewTestLogger. It's declared here so
+// that this package doesn't need to import testing.
+type TB interface {
+	Helper()
+	Log(args ...interface{})
+	Error(args ...interface{})
+	Cleanup(func())
+}
+
+// A TestLogger is a Logger that writes through a test's Log method, for
+// exercising code that uses alog under `go test`. Output is stripped of ANSI
+// escapes and partial lines are not rendered; each completed line becomes one
+// t.Log call, so it is attributed to the right test and only shown on failure
+// or with -v.
+type TestLogger struct {
+	*Logger
+	t           TB
+	mutex       sync.Mutex
+	failOnError bool
+}
+
+type testWriter struct {
+	t       TB
+	partial []byte
+}
+
+func (w *testWriter) Write(p []byte) (int, error) {
+	w.partial = append(w.partial, p...)
+	for {
+		index := bytes.IndexByte(w.partial, '\n')
+		if index == -1 {
+			break
+		}
+		w.t.Log(strings.TrimRight(string(w.partial[:index]), "\r"))
+		w.partial = w.partial[index+1:]
+	}
+	return len(p), nil
+}
+
+// NewTestLogger returns a TestLogger writing to t. It is closed automatically
+// when the test finishes.
+func NewTestLogger(t TB) *TestLogger {
+	l := &TestLogger{t: t}
+	l.Logger = New(&testWriter{t: t}, "", 0)
+	l.DisableColor()
+	l.HidePartialLines()
+	l.AddSink(l)
+	t.Cleanup(func() { l.Close() })
+	return l
+}
+
+// FailOnError sets whether lines logged at LevelError (e.g. with Errorf) mark
+// the test as failed.
+func (l *TestLogger) FailOnError(flag bool) {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+	l.failOnError = flag
+}
+
+// WriteEntry implements EntrySink.
+func (l *TestLogger) WriteEntry(e *Entry) error {
+	l.mutex.Lock()
+	failOnError := l.failOnError
+	l.mutex.Unlock()
+	if failOnError && e.Level >= LevelError {
+		l.t.Helper()
+		l.t.Error("error logged: " + e.PlainMessage())
+	}
+	return nil
+}
@@ -0,0 +1,37 @@
+package alog
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type fakeTB struct {
+	logs     []string
+	errors   []string
+	cleanups []func()
+}
+
+func (t *fakeTB) Helper()                   {}
+func (t *fakeTB) Log(args ...interface{})   { t.logs = append(t.logs, fmt.Sprint(args...)) }
+func (t *fakeTB) Error(args ...interface{}) { t.errors = append(t.errors, fmt.Sprint(args...)) }
+func (t *fakeTB) Cleanup(fn func())         { t.cleanups = append(t.cleanups, fn) }
+
+func TestTestLogger(t *testing.T) {
+	assert := assert.New(t)
+	tb := &fakeTB{}
+	l := NewTestLogger(tb)
+	l.FailOnError(true)
+	l.Printf("\033[32mhello\033[39m\n")
+	l.Print("partial ")
+	assert.Equal([]string{"hello"}, tb.logs, "ANSI is stripped and partial lines are held back")
+	l.Errorf("boom\n")
+	assert.Equal([]string{"hello", "partial boom"}, tb.logs)
+	assert.Equal([]string{"error logged: partial boom"}, tb.errors)
+	l.Print("unfinished")
+	for _, fn := range tb.cleanups {
+		fn()
+	}
+	assert.Equal("unfinished", tb.logs[len(tb.logs)-1], "cleanup flushes the logger")
+}
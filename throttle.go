@@ -0,0 +1,90 @@
+package alog
+
+import (
+	"fmt"
+	"time"
+)
+
+// A Throttled writes to a Logger only as often as its key allows. See Once
+// and Every.
+type Throttled struct {
+	l        *Logger
+	key      string
+	interval time.Duration // 0 means only the first time
+}
+
+// Once returns a Throttled that writes to the Logger the first time it is
+// used with key, and ignores every later call with the same key, e.g.
+// l.Once("deprecated-flag").Warnf(...). Keys are per Logger.
+func (l *Logger) Once(key string) *Throttled {
+	return &Throttled{l: l, key: key}
+}
+
+// Every returns a Throttled that writes to the Logger at most once per
+// interval for key, e.g. l.Every("retry", 10*time.Second).Printf(...). Calls
+// in between are ignored. Time is read from the Logger's clock.
+func (l *Logger) Every(key string, interval time.Duration) *Throttled {
+	return &Throttled{l: l, key: key, interval: interval}
+}
+
+func Once(key string) *Throttled { return DefaultLogger.Once(key) }
+func Every(key string, interval time.Duration) *Throttled {
+	return DefaultLogger.Every(key, interval)
+}
+
+// allow reports whether output for the key is due, recording it if so. Must
+// be called with the writer lock held.
+func (t *Throttled) allow() bool {
+	l := t.l
+	now := l.clockNow()
+	if last, ok := l.throttled[t.key]; ok && (t.interval <= 0 || now.Sub(last) < t.interval) {
+		return false
+	}
+	if l.throttled == nil {
+		l.throttled = make(map[string]time.Time)
+	}
+	l.throttled[t.key] = now
+	return true
+}
+
+// output writes what format returns if the key allows. calldepth is relative
+// to the caller of output. format runs without the writer lock held, so that
+// String methods that log don't deadlock.
+func (t *Throttled) output(calldepth int, level Level, format func() []byte) {
+	ws := getWriterState(t.l.out)
+	ws.lock()
+	allowed := t.allow()
+	ws.unlock()
+	if !allowed {
+		return
+	}
+	s := format()
+	ws.lock()
+	defer ws.unlock()
+	t.l.levelOutput(level, calldepth+1, s)
+}
+
+// Print is like Logger.Print.
+func (t *Throttled) Print(v ...interface{}) {
+	t.output(2, LevelInfo, func() []byte { return []byte(fmt.Sprint(v...)) })
+}
+
+// Printf is like Logger.Printf.
+func (t *Throttled) Printf(format string, v ...interface{}) {
+	t.output(2, LevelInfo, func() []byte { return fmt.Appendf(nil, t.l.applyColorTemplates(format), v...) })
+}
+
+// Println is like Logger.Println.
+func (t *Throttled) Println(v ...interface{}) {
+	t.output(2, LevelInfo, func() []byte { return []byte(fmt.Sprintln(v...)) })
+}
+
+// Warnf is like Logger.Warnf.
+func (t *Throttled) Warnf(format string, v ...interface{}) {
+	t.output(2, LevelWarn, func() []byte { return fmt.Appendf(nil, t.l.applyColorTemplates(format), v...) })
+}
+
+// Errorf is like Logger.Errorf.
+func (t *Throttled) Errorf(format string, v ...interface{}) {
+	t.output(2, LevelError, func() []byte { return fmt.Appendf(nil, t.l.applyColorTemplates(format), v...) })
+}
@@ -0,0 +1,38 @@
+package alog
+
+import (
+	"bytes"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestOnceAndEvery(t *testing.T) {
+	assert := assert.New(t)
+	var buf bytes.Buffer
+	var writer = New(&buf, "", 0)
+	defer writer.Close()
+	now := time.Date(2020, 1, 2, 3, 4, 5, 0, time.UTC)
+	writer.SetClock(func() time.Time { return now })
+	for i := 0; i < 3; i++ {
+		writer.Once("deprecated").Println("--old is deprecated")
+	}
+	assert.Equal("--old is deprecated\n", buf.String())
+	buf.Reset()
+	for i := 0; i < 5; i++ {
+		writer.Every("retry", 2*time.Second).Printf("retrying (%d)\n", i)
+		now = now.Add(time.Second)
+	}
+	assert.Equal("retrying (0)\nretrying (2)\nretrying (4)\n", buf.String())
+}
+
+func TestOnceLoggingArgument(t *testing.T) {
+	assert := assert.New(t)
+	var buf bytes.Buffer
+	var writer = New(&buf, "", 0)
+	defer writer.Close()
+	writer.Once("key").Printf("%v\n", loggingStringer{writer})
+	writer.Once("key").Printf("%v\n", loggingStringer{writer})
+	assert.Equal("formatting\nvalue\n", buf.String(), "arguments are formatted only when allowed, and without the lock")
+}
@@ -0,0 +1,158 @@
+package alog
+
+import (
+	"encoding/json"
+	"os"
+	"sync"
+	"time"
+)
+
+// A timeline records completed lines as events in the Chrome trace event
+// format (as understood by chrome://tracing, Perfetto and speedscope). Lines
+// that were displayed as partial lines before completing become duration
+// events spanning from when they were first displayed to when they completed,
+// so a build tool that uses a temp line per task gets a flame chart of its
+// tasks for free.
+//
+// Events are written in the background as they happen using the JSON Array
+// Format, which tolerates a missing closing bracket, so the file is usable
+// even if the program crashes.
+type timeline struct {
+	mutex   sync.Mutex
+	cond    *sync.Cond // signaled when pending grows or the timeline closes
+	file    *os.File
+	start   time.Time
+	nextTid int
+	pending []byte // events waiting to be written
+	closing bool
+	done    chan struct{} // closed once the writer goroutine exits
+}
+
+type timelineSink struct {
+	timeline *timeline
+	tid      int
+}
+
+type traceEvent struct {
+	Name  string            `json:"name"`
+	Phase string            `json:"ph"`
+	Ts    int64             `json:"ts"`
+	Dur   int64             `json:"dur,omitempty"`
+	Pid   int               `json:"pid"`
+	Tid   int               `json:"tid"`
+	Scope string            `json:"s,omitempty"`
+	Args  map[string]string `json:"args,omitempty"`
+}
+
+var timelines = struct {
+	sync.Mutex
+	byPath map[string]*timeline
+}{byPath: make(map[string]*timeline)}
+
+// EnableTimeline records every line this Logger completes from now on into a
+// trace event file at path. Loggers enabling the same path share the file,
+// each getting its own row (thread) in the trace viewer. Call CloseTimelines
+// before exiting to terminate the file cleanly.
+func (l *Logger) EnableTimeline(path string) error {
+	ws := getWriterState(l.out)
+	ws.lock()
+	now := l.clockNow()
+	ws.unlock()
+	timelines.Lock()
+	t, ok := timelines.byPath[path]
+	if !ok {
+		file, err := os.Create(path)
+		if err != nil {
+			timelines.Unlock()
+			return err
+		}
+		t = &timeline{file: file, start: now, pending: []byte("[\n"), done: make(chan struct{})}
+		t.cond = sync.NewCond(&t.mutex)
+		go t.run()
+		timelines.byPath[path] = t
+	}
+	timelines.Unlock()
+	t.mutex.Lock()
+	t.nextTid++
+	sink := &timelineSink{timeline: t, tid: t.nextTid}
+	t.mutex.Unlock()
+	l.AddSink(sink)
+	return nil
+}
+
+func EnableTimeline(path string) error { return DefaultLogger.EnableTimeline(path) }
+
+// CloseTimelines terminates and closes all timeline files. Lines completed
+// afterwards are no longer recorded.
+func CloseTimelines() error {
+	timelines.Lock()
+	defer timelines.Unlock()
+	var firstErr error
+	for path, t := range timelines.byPath {
+		t.mutex.Lock()
+		// An empty object keeps the trailing comma legal.
+		t.pending = append(t.pending, "{}]\n"...)
+		t.closing = true
+		t.cond.Signal()
+		t.mutex.Unlock()
+		<-t.done
+		if err := t.file.Close(); err != nil && firstErr == nil {
+			firstErr = err
+		}
+		delete(timelines.byPath, path)
+	}
+	return firstErr
+}
+
+// run writes pending events to the file until the timeline is closed.
+func (t *timeline) run() {
+	defer close(t.done)
+	var buf []byte
+	t.mutex.Lock()
+	for {
+		for len(t.pending) == 0 && !t.closing {
+			t.cond.Wait()
+		}
+		if len(t.pending) == 0 {
+			t.mutex.Unlock()
+			return
+		}
+		buf, t.pending = t.pending, buf[:0]
+		t.mutex.Unlock()
+		t.file.Write(buf)
+		t.mutex.Lock()
+	}
+}
+
+func (s *timelineSink) WriteEntry(e *Entry) error {
+	t := s.timeline
+	event := traceEvent{
+		Name:  e.PlainMessage(),
+		Phase: "X",
+		Ts:    e.Start.Sub(t.start).Microseconds(),
+		Dur:   e.Time.Sub(e.Start).Microseconds(),
+		Pid:   1,
+		Tid:   s.tid,
+		Args:  map[string]string{"level": e.Level.String()},
+	}
+	if prefix := e.PlainPrefix(); prefix != "" {
+		event.Args["prefix"] = prefix
+	}
+	if !e.Time.After(e.Start) {
+		event.Phase = "i"
+		event.Dur = 0
+		event.Scope = "t"
+	}
+	buf, err := json.Marshal(event)
+	if err != nil {
+		return err
+	}
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+	if t.closing {
+		return nil
+	}
+	t.pending = append(append(t.pending, buf...), ",\n"...)
+	t.cond.Signal()
+	return nil
+}
@@ -0,0 +1,58 @@
+package alog
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTimeline(t *testing.T) {
+	assert := assert.New(t)
+	path := filepath.Join(t.TempDir(), "trace.json")
+	var buf bytes.Buffer
+	writer := New(&buf, "", 0)
+	defer writer.Close()
+	assert.NoError(writer.EnableTimeline(path))
+	writer.Print("compiling...")
+	time.Sleep(2 * time.Millisecond)
+	writer.Print(" done\n")
+	writer.Print("instant\n")
+	assert.NoError(CloseTimelines())
+	contents, err := os.ReadFile(path)
+	assert.NoError(err)
+	var events []traceEvent
+	assert.NoError(json.Unmarshal(contents, &events), string(contents))
+	assert.Len(events, 3, "two lines plus the terminating empty object")
+	assert.Equal("compiling... done", events[0].Name)
+	assert.Equal("X", events[0].Phase)
+	assert.True(events[0].Dur >= 2000, "duration spans the partial line's lifetime")
+	assert.Equal("i", events[1].Phase)
+}
+
+func TestTimelineClock(t *testing.T) {
+	assert := assert.New(t)
+	path := filepath.Join(t.TempDir(), "trace.json")
+	var buf bytes.Buffer
+	writer := New(&buf, "", 0)
+	defer writer.Close()
+	now := time.Date(2020, 1, 2, 3, 4, 5, 0, time.UTC)
+	writer.SetClock(func() time.Time { return now })
+	assert.NoError(writer.EnableTimeline(path))
+	now = now.Add(time.Second)
+	writer.Print("compiling...")
+	now = now.Add(3 * time.Second)
+	writer.Print(" done\n")
+	assert.NoError(CloseTimelines())
+	contents, err := os.ReadFile(path)
+	assert.NoError(err)
+	var events []traceEvent
+	assert.NoError(json.Unmarshal(contents, &events), string(contents))
+	assert.Len(events, 2)
+	assert.Equal(int64(1000000), events[0].Ts, "offsets come from the Logger's clock")
+	assert.Equal(int64(3000000), events[0].Dur)
+}
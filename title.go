@@ -0,0 +1,70 @@
+package alog
+
+import (
+	"fmt"
+	"strings"
+)
+
+const maxTitleLength = 200
+
+// SetTerminalTitle sets the title of the terminal window or tab that this
+// Logger writes to, formatted as by fmt.Sprintf. Nothing is written when the
+// Logger doesn't write to a terminal.
+func (l *Logger) SetTerminalTitle(format string, v ...interface{}) {
+	ws := getWriterState(l.out)
+	ws.lock()
+	defer ws.unlock()
+	ws.title = fmt.Sprintf(format, v...)
+	ws.setTitle(ws.title)
+}
+
+// SetTitleMirroring makes the terminal title follow the first partial line
+// (in singleline order; see SetSegmentOrder) of this Logger's writer, which is
+// handy for keeping an eye on builds running in background tabs. When there
+// are no partial lines, the title set by SetTerminalTitle is shown.
+func (l *Logger) SetTitleMirroring(flag bool) {
+	ws := getWriterState(l.out)
+	ws.lock()
+	defer ws.unlock()
+	ws.mirrorTitle = flag
+	if !flag {
+		ws.setTitle(ws.title)
+	}
+	updateTempOutput(l.out)
+}
+
+func SetTerminalTitle(format string, v ...interface{}) {
+	DefaultLogger.SetTerminalTitle(format, v...)
+}
+func SetTitleMirroring(flag bool) { DefaultLogger.SetTitleMirroring(flag) }
+
+// setTitle writes an OSC 0 sequence (setting both the window and icon title)
+// if title isn't already shown and the writer is a terminal. Must be called
+// with the writer lock held.
+func (w *WriterState) setTitle(title string) {
+	if !isTerminalWriter(w.out) {
+		return
+	}
+	title = string(trimString(sanitize(uncolorize([]byte(title))), maxTitleLength))
+	// Sanitizing leaves tabs and newlines, which don't belong in a title either.
+	title = strings.NewReplacer("\t", " ", "\n", " ", "\r", " ").Replace(title)
+	if title == w.shownTitle {
+		return
+	}
+	w.shownTitle = title
+	w.write([]byte("\033]0;" + title + "\a"))
+}
+
+func (w *WriterState) mirrorTitleFrom(segments []tempSegment) {
+	if len(segments) == 0 {
+		w.setTitle(w.title)
+		return
+	}
+	top := segments[0]
+	for _, segment := range segments[1:] {
+		if segment.order < top.order {
+			top = segment
+		}
+	}
+	w.setTitle(string(top.buf))
+}
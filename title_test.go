@@ -0,0 +1,31 @@
+package alog
+
+import (
+	"bytes"
+	"io"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTerminalTitle(t *testing.T) {
+	assert := assert.New(t)
+	var buf bytes.Buffer
+	writer := New(&buf, "", 0)
+	writer.SetTerminalWidth(80)
+	writer.ShowPartialLines()
+	defer writer.Close()
+	writer.SetTerminalTitle("build %d", 7)
+	assert.Equal("", buf.String(), "not a terminal")
+	defer func(prev func(io.Writer) bool) { isTerminalWriter = prev }(isTerminalWriter)
+	isTerminalWriter = func(out io.Writer) bool { return true }
+	writer.SetTerminalTitle("build %d", 7)
+	assert.Equal("\033]0;build 7\a", buf.String())
+	writer.SetTitleMirroring(true)
+	buf.Reset()
+	writer.Print("\033[32mcompiling\033[39m")
+	assert.Equal("\033]0;compiling\a\033[32mcompiling\033[39m", buf.String())
+	buf.Reset()
+	writer.Print("\n")
+	assert.Equal("\n\033]0;build 7\a", buf.String())
+}
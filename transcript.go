@@ -0,0 +1,142 @@
+package alog
+
+import (
+	"bufio"
+	"os"
+	"sync"
+	"time"
+)
+
+// transcriptFlushInterval is how often a transcript file is flushed.
+var transcriptFlushInterval = time.Second
+
+// TranscriptOptions configures a transcript. The zero value is usable.
+type TranscriptOptions struct {
+	// SnapshotInterval, if positive, is how often to also record the state
+	// of a partial line while it is being updated, e.g. to see how a long
+	// download progressed. Otherwise partial lines are recorded only in
+	// their final state, once completed.
+	SnapshotInterval time.Duration
+}
+
+// A transcriptSink appends completed lines to a file, for AlsoLogToFile.
+type transcriptSink struct {
+	mutex        sync.Mutex
+	file         *os.File
+	w            *bufio.Writer
+	buf          []byte
+	done         chan struct{}
+	opts         TranscriptOptions
+	lastSnapshot time.Time
+}
+
+// AlsoLogToFile appends every line completed by the Logger from now on to
+// the file at path, creating it if necessary, as a persistent transcript of
+// what was shown: ANSI escapes are stripped, each line starts with an
+// ISO 8601 timestamp and its level, and partial lines are recorded only in
+// their final state, however often they were updated. Writes are buffered
+// and flushed every second, when the Logger is closed, and before exiting
+// through Fatal and the like. stop flushes and closes the file and stops the
+// transcript.
+func (l *Logger) AlsoLogToFile(path string) (stop func() error, err error) {
+	return l.StartTranscript(path, nil)
+}
+
+// StartTranscript is like AlsoLogToFile, with options; opts may be nil.
+func (l *Logger) StartTranscript(path string, opts *TranscriptOptions) (stop func() error, err error) {
+	file, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_APPEND, 0644)
+	if err != nil {
+		return nil, err
+	}
+	sink := &transcriptSink{file: file, w: bufio.NewWriter(file), done: make(chan struct{})}
+	if opts != nil {
+		sink.opts = *opts
+	}
+	go sink.flushPeriodically()
+	l.AddSink(sink)
+	var once sync.Once
+	return func() error {
+		once.Do(func() {
+			l.RemoveSink(sink)
+			close(sink.done)
+			err = sink.close()
+		})
+		return err
+	}, nil
+}
+
+func AlsoLogToFile(path string) (stop func() error, err error) {
+	return DefaultLogger.AlsoLogToFile(path)
+}
+
+func StartTranscript(path string, opts *TranscriptOptions) (stop func() error, err error) {
+	return DefaultLogger.StartTranscript(path, opts)
+}
+
+func (s *transcriptSink) WriteEntry(e *Entry) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.lastSnapshot = time.Time{}
+	return s.write(e, "")
+}
+
+// WritePartialEntry records a snapshot of a partial line, if one is due.
+func (s *transcriptSink) WritePartialEntry(e *Entry) error {
+	if s.opts.SnapshotInterval <= 0 {
+		return nil
+	}
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	since := s.lastSnapshot
+	if since.Before(e.Start) {
+		since = e.Start
+	}
+	if e.Time.Sub(since) < s.opts.SnapshotInterval {
+		return nil
+	}
+	s.lastSnapshot = e.Time
+	return s.write(e, "(partial) ")
+}
+
+// write appends a line for e to the file. Must be called with s.mutex held.
+func (s *transcriptSink) write(e *Entry, note string) error {
+	s.buf = e.Time.AppendFormat(s.buf[:0], "2006-01-02T15:04:05.000Z07:00")
+	s.buf = append(s.buf, ' ')
+	s.buf = append(s.buf, e.Level.String()...)
+	s.buf = append(s.buf, ' ')
+	s.buf = append(s.buf, note...)
+	s.buf = append(s.buf, e.PlainMessage()...)
+	s.buf = append(s.buf, '\n')
+	_, err := s.w.Write(s.buf)
+	return err
+}
+
+// Flush writes out buffered lines. It implements FlushingSink.
+func (s *transcriptSink) Flush() error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	return s.w.Flush()
+}
+
+func (s *transcriptSink) flushPeriodically() {
+	ticker := time.NewTicker(transcriptFlushInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			s.Flush()
+		case <-s.done:
+			return
+		}
+	}
+}
+
+func (s *transcriptSink) close() error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	err := s.w.Flush()
+	if closeErr := s.file.Close(); err == nil {
+		err = closeErr
+	}
+	return err
+}
@@ -0,0 +1,103 @@
+package alog
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAlsoLogToFile(t *testing.T) {
+	assert := assert.New(t)
+	var buf bytes.Buffer
+	var writer = New(&buf, "@(dim:{time}) ", 0)
+	defer writer.Close()
+	writer.EnableColor()
+	writer.EnableColorTemplate()
+	now := time.Date(2020, 1, 2, 3, 4, 5, 0, time.UTC)
+	writer.SetClock(func() time.Time { return now })
+	path := filepath.Join(t.TempDir(), "transcript.log")
+	stop, err := writer.AlsoLogToFile(path)
+	if !assert.NoError(err) {
+		return
+	}
+	writer.Print("working...")
+	writer.Printf("@(success:done)\n")
+	writer.Warnf("careful\n")
+	assert.NoError(stop())
+	writer.Printf("not logged\n")
+	contents, err := os.ReadFile(path)
+	assert.NoError(err)
+	assert.Equal("2020-01-02T03:04:05.000Z info working...done\n2020-01-02T03:04:05.000Z warn careful\n", string(contents))
+}
+
+func TestTranscriptFlushedOnClose(t *testing.T) {
+	assert := assert.New(t)
+	var buf bytes.Buffer
+	var writer = New(&buf, "", 0)
+	now := time.Date(2020, 1, 2, 3, 4, 5, 0, time.UTC)
+	writer.SetClock(func() time.Time { return now })
+	path := filepath.Join(t.TempDir(), "transcript.log")
+	stop, err := writer.AlsoLogToFile(path)
+	if !assert.NoError(err) {
+		return
+	}
+	defer stop()
+	writer.Errorf("broken\n")
+	assert.NoError(writer.Close())
+	contents, err := os.ReadFile(path)
+	assert.NoError(err)
+	assert.Equal("2020-01-02T03:04:05.000Z error broken\n", string(contents), "closing the Logger flushes the transcript")
+}
+
+func TestTranscriptSnapshots(t *testing.T) {
+	assert := assert.New(t)
+	var buf bytes.Buffer
+	var writer = New(&buf, "", 0)
+	defer writer.Close()
+	now := time.Date(2020, 1, 2, 3, 4, 5, 0, time.UTC)
+	writer.SetClock(func() time.Time { return now })
+	path := filepath.Join(t.TempDir(), "transcript.log")
+	stop, err := writer.StartTranscript(path, &TranscriptOptions{SnapshotInterval: time.Second})
+	if !assert.NoError(err) {
+		return
+	}
+	for i := 0; i <= 100; i += 10 {
+		writer.Replacef("downloading %d%%", i)
+		now = now.Add(300 * time.Millisecond)
+	}
+	writer.Replacef("downloaded\n")
+	assert.NoError(stop())
+	contents, err := os.ReadFile(path)
+	assert.NoError(err)
+	assert.Equal("2020-01-02T03:04:06.200Z info (partial) downloading 40%\n"+
+		"2020-01-02T03:04:07.400Z info (partial) downloading 80%\n"+
+		"2020-01-02T03:04:08.300Z info downloaded\n", string(contents))
+}
+
+func TestTranscriptSnapshotsRedacted(t *testing.T) {
+	assert := assert.New(t)
+	var buf bytes.Buffer
+	var writer = New(&buf, "", 0)
+	defer writer.Close()
+	now := time.Date(2020, 1, 2, 3, 4, 5, 0, time.UTC)
+	writer.SetClock(func() time.Time { return now })
+	writer.AddRedactor(regexp.MustCompile(`hunter2`), "[REDACTED]")
+	path := filepath.Join(t.TempDir(), "transcript.log")
+	stop, err := writer.StartTranscript(path, &TranscriptOptions{SnapshotInterval: time.Second})
+	if !assert.NoError(err) {
+		return
+	}
+	writer.Print("password hunter2")
+	now = now.Add(2 * time.Second)
+	writer.Print(" more")
+	assert.NoError(stop())
+	contents, err := os.ReadFile(path)
+	assert.NoError(err)
+	assert.NotContains(string(contents), "hunter2")
+	assert.Contains(string(contents), "(partial) password [REDACTED] more\n")
+}
@@ -0,0 +1,219 @@
+package alog
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+)
+
+// A TreeStatus is the state of a task in a Tree.
+type TreeStatus int
+
+const (
+	TreePending TreeStatus = iota
+	TreeRunning
+	TreeDone
+	TreeFailed
+)
+
+// A Tree displays a hierarchy of tasks, one per line, with box-drawing
+// connectors between parents and their children and the live status of each
+// task, e.g. for the dependency graph of a build. It is drawn along with the
+// partial lines of the Logger's writer and is meant for multiline mode, where
+// it is updated in place. All methods are safe for concurrent use.
+type Tree struct {
+	l             *Logger
+	roots         []*TreeNode
+	segments      []*StatusSegment
+	animateOnce   sync.Once
+	stopAnimating func()
+}
+
+// A TreeNode is a task in a Tree.
+type TreeNode struct {
+	tree      *Tree
+	name      string
+	status    TreeStatus
+	detail    string
+	err       error
+	start     time.Time
+	end       time.Time
+	children  []*TreeNode
+	connector string // drawn before the node, set by layout
+}
+
+// NewTree creates an empty Tree, drawn on the Logger's writer.
+func (l *Logger) NewTree() *Tree {
+	return &Tree{l: l}
+}
+
+func NewTree() *Tree { return DefaultLogger.NewTree() }
+
+// update applies fn with the writer lock held, lays out the tree again and
+// redraws.
+func (t *Tree) update(fn func()) {
+	ws := getWriterState(t.l.out)
+	ws.lock()
+	defer ws.unlock()
+	fn()
+	t.layout(ws)
+	updateTempOutput(t.l.out)
+}
+
+// Add adds a top-level task to the Tree.
+func (t *Tree) Add(name string) *TreeNode {
+	node := &TreeNode{tree: t, name: name}
+	t.update(func() { t.roots = append(t.roots, node) })
+	return node
+}
+
+// Add adds a child task to the node.
+func (n *TreeNode) Add(name string) *TreeNode {
+	node := &TreeNode{tree: n.tree, name: name}
+	n.tree.update(func() { n.children = append(n.children, node) })
+	return node
+}
+
+// Start marks the task as running, showing a spinner and the time elapsed.
+func (n *TreeNode) Start() {
+	n.tree.animateOnce.Do(func() { n.tree.stopAnimating = n.tree.l.animate() })
+	n.tree.update(func() {
+		n.status = TreeRunning
+		n.start = n.tree.l.clockNow()
+	})
+}
+
+// SetDetail sets text shown after the task's name, formatted as by
+// fmt.Sprintf, e.g. the step it is on.
+func (n *TreeNode) SetDetail(format string, v ...interface{}) {
+	detail := fmt.Sprintf(format, v...)
+	n.tree.update(func() { n.detail = detail })
+}
+
+// Done marks the task as finished successfully.
+func (n *TreeNode) Done() {
+	n.tree.update(func() {
+		n.status = TreeDone
+		n.end = n.tree.l.clockNow()
+	})
+}
+
+// Fail marks the task as failed with err.
+func (n *TreeNode) Fail(err error) {
+	n.tree.update(func() {
+		n.status = TreeFailed
+		n.err = err
+		n.end = n.tree.l.clockNow()
+	})
+}
+
+// Close stops displaying the Tree and writes it out once more in its final
+// state, as completed lines.
+func (t *Tree) Close() {
+	ws := getWriterState(t.l.out)
+	ws.lock()
+	t.removeSegments(ws)
+	updateTempOutput(t.l.out)
+	var write func(nodes []*TreeNode)
+	write = func(nodes []*TreeNode) {
+		for _, node := range nodes {
+			writeLine(t.l.out, node.line(t.l.isColorEnabled()))
+			write(node.children)
+		}
+	}
+	write(t.roots)
+	ws.unlock()
+	t.animateOnce.Do(func() {})
+	if t.stopAnimating != nil {
+		t.stopAnimating()
+	}
+}
+
+func (t *Tree) removeSegments(ws *WriterState) {
+	var kept []*StatusSegment
+	for _, segment := range ws.namedSegments {
+		if !t.owns(segment) {
+			kept = append(kept, segment)
+		}
+	}
+	ws.namedSegments = kept
+	t.segments = nil
+}
+
+func (t *Tree) owns(segment *StatusSegment) bool {
+	for _, own := range t.segments {
+		if own == segment {
+			return true
+		}
+	}
+	return false
+}
+
+// layout sets the connectors of all nodes and replaces the Tree's segments
+// with one per node, in order. Must be called with the writer lock held.
+func (t *Tree) layout(ws *WriterState) {
+	t.removeSegments(ws)
+	var walk func(nodes []*TreeNode, indent string, top bool)
+	glyphs := t.l.glyphs()
+	walk = func(nodes []*TreeNode, indent string, top bool) {
+		for i, node := range nodes {
+			last := i == len(nodes)-1
+			childIndent := indent
+			switch {
+			case top:
+				node.connector = ""
+			case last:
+				node.connector = indent + glyphs.lastBranch
+				childIndent += "   "
+			default:
+				node.connector = indent + glyphs.branch
+				childIndent += glyphs.vertical
+			}
+			node := node
+			segment := &StatusSegment{l: t.l, name: node.name, render: func() []byte { return node.line(true) }}
+			t.segments = append(t.segments, segment)
+			walk(node.children, childIndent, false)
+		}
+	}
+	walk(t.roots, "", true)
+	ws.namedSegments = append(ws.namedSegments, t.segments...)
+}
+
+// line renders the node's line. Must be called with the writer lock held.
+func (n *TreeNode) line(color bool) []byte {
+	l := n.tree.l
+	glyphs := l.glyphs()
+	var line strings.Builder
+	line.WriteString(n.connector)
+	now := l.clockNow()
+	switch n.status {
+	case TreePending:
+		line.WriteString(styled("dim", glyphs.pending))
+	case TreeRunning:
+		line.WriteString(styled("cyan", l.spinnerFrame(now.Sub(n.start))))
+	case TreeDone:
+		line.WriteString(styled("success", glyphs.check))
+	case TreeFailed:
+		line.WriteString(styled("error", glyphs.cross))
+	}
+	line.WriteString(" " + n.name)
+	switch n.status {
+	case TreeRunning:
+		line.WriteString(" " + styled("dim", strings.TrimSpace(FormatDuration(now.Sub(n.start)))))
+	case TreeDone, TreeFailed:
+		if !n.start.IsZero() {
+			line.WriteString(" " + styled("dim", strings.TrimSpace(FormatDuration(n.end.Sub(n.start)))))
+		}
+	}
+	if n.err != nil {
+		line.WriteString(" " + styled("error", n.err.Error()))
+	} else if n.detail != "" {
+		line.WriteString(" " + n.detail)
+	}
+	buf := []byte(line.String())
+	if !color {
+		buf = uncolorize(buf)
+	}
+	return buf
+}
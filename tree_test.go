@@ -0,0 +1,60 @@
+package alog
+
+import (
+	"bytes"
+	"errors"
+	"regexp"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTree(t *testing.T) {
+	assert := assert.New(t)
+	var buf bytes.Buffer
+	var writer = New(&buf, "", 0)
+	defer writer.Close()
+	writer.EnableUnicode()
+	writer.DisableColor()
+	writer.SetSegmentSeparator("\n")
+	tree := writer.NewTree()
+	app := tree.Add("app")
+	lib := app.Add("lib")
+	util := lib.Add("util")
+	test := app.Add("test")
+	app.Start()
+	lib.Start()
+	util.Start()
+	util.Done()
+	lib.Fail(errors.New("compile error"))
+	test.SetDetail("waiting")
+	buf.Reset()
+	tree.Close()
+	output := regexp.MustCompile(`\d+(\.\d+)?(ms|s)`).ReplaceAllString(buf.String(), "<dur>")
+	assert.Regexp("\r[⠋⠙⠹⠸⠼⠴⠦⠧⠇⠏] app <dur>\n├─ ✗ lib <dur> compile error\n│  └─ ✓ util <dur>\n└─ · test waiting\n", output)
+}
+
+func TestTreeClock(t *testing.T) {
+	assert := assert.New(t)
+	var buf bytes.Buffer
+	var writer = New(&buf, "", 0)
+	defer writer.Close()
+	now := time.Date(2020, 1, 2, 3, 4, 5, 0, time.UTC)
+	writer.SetClock(func() time.Time { return now })
+	writer.EnableUnicode()
+	writer.DisableColor()
+	writer.SetSegmentSeparator("\n")
+	tree := writer.NewTree()
+	build := tree.Add("build")
+	deploy := tree.Add("deploy")
+	build.Start()
+	deploy.Start()
+	now = now.Add(2 * time.Second)
+	build.Done()
+	now = now.Add(90 * time.Second)
+	buf.Reset()
+	tree.Close()
+	assert.True(strings.HasSuffix(buf.String(), "\r✓ build 2.00s\n⠋ deploy 92.0s\n"), "elapsed times come from the Logger's clock: %q", buf.String())
+}
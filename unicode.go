@@ -0,0 +1,92 @@
+package alog
+
+import (
+	"os"
+	"runtime"
+	"strings"
+)
+
+// A glyphSet holds the symbols drawn by the built-in widgets.
+type glyphSet struct {
+	spinner    []string
+	pending    string
+	check      string
+	cross      string
+	branch     string
+	lastBranch string
+	vertical   string
+	gutter     string
+}
+
+var unicodeGlyphs = &glyphSet{
+	spinner:    []string{"⠋", "⠙", "⠹", "⠸", "⠼", "⠴", "⠦", "⠧", "⠇", "⠏"},
+	pending:    "·",
+	check:      "✓",
+	cross:      "✗",
+	branch:     "├─ ",
+	lastBranch: "└─ ",
+	vertical:   "│  ",
+	gutter:     "│ ",
+}
+
+var asciiGlyphs = &glyphSet{
+	spinner:    []string{"|", "/", "-", "\\"},
+	pending:    ".",
+	check:      "+",
+	cross:      "x",
+	branch:     "|- ",
+	lastBranch: "`- ",
+	vertical:   "|  ",
+	gutter:     "| ",
+}
+
+// SetUnicodeEnabled sets whether the built-in widgets (spinners, trees,
+// block gutters, check marks and the like) draw Unicode symbols, or ASCII
+// fallbacks for dumb terminals and legacy consoles. The default is detected
+// from the environment: Unicode unless TERM is "dumb", the locale isn't
+// UTF-8, or on Windows, the console isn't Windows Terminal.
+func (l *Logger) SetUnicodeEnabled(flag bool) {
+	ws := getWriterState(l.out)
+	ws.lock()
+	defer ws.unlock()
+	l.customized = true
+	l.unicodeEnabled.set(flag)
+}
+
+func (l *Logger) EnableUnicode()  { l.SetUnicodeEnabled(true) }
+func (l *Logger) DisableUnicode() { l.SetUnicodeEnabled(false) }
+
+func SetUnicodeEnabled(flag bool) { DefaultLogger.SetUnicodeEnabled(flag) }
+func EnableUnicode()              { DefaultLogger.SetUnicodeEnabled(true) }
+func DisableUnicode()             { DefaultLogger.SetUnicodeEnabled(false) }
+
+func (l *Logger) isUnicodeEnabled() bool {
+	return isTrueDefaulted(&l.unicodeEnabled, &DefaultLogger.unicodeEnabled)
+}
+
+// glyphs returns the symbols for the built-in widgets to draw.
+func (l *Logger) glyphs() *glyphSet {
+	if l.isUnicodeEnabled() {
+		return unicodeGlyphs
+	}
+	return asciiGlyphs
+}
+
+// detectUnicode guesses whether the terminal can display Unicode symbols.
+func detectUnicode() bool {
+	if os.Getenv("TERM") == "dumb" {
+		return false
+	}
+	if runtime.GOOS == "windows" {
+		// The legacy console's default code page can't; Windows Terminal can.
+		return os.Getenv("WT_SESSION") != "" || os.Getenv("TERM_PROGRAM") != ""
+	}
+	for _, name := range []string{"LC_ALL", "LC_CTYPE", "LANG"} {
+		if locale := os.Getenv(name); locale != "" {
+			locale = strings.ToLower(locale)
+			return strings.Contains(locale, "utf-8") || strings.Contains(locale, "utf8")
+		}
+	}
+	// No locale at all, as is common in containers: most terminals cope.
+	return true
+}
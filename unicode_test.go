@@ -0,0 +1,26 @@
+package alog
+
+import (
+	"runtime"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDetectUnicode(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("detection on Windows doesn't depend on the locale")
+	}
+	assert := assert.New(t)
+	for _, name := range []string{"TERM", "LC_ALL", "LC_CTYPE", "LANG"} {
+		t.Setenv(name, "")
+	}
+	assert.True(detectUnicode())
+	t.Setenv("LANG", "en_US.UTF-8")
+	assert.True(detectUnicode())
+	t.Setenv("LC_ALL", "C")
+	assert.False(detectUnicode())
+	t.Setenv("LC_ALL", "")
+	t.Setenv("TERM", "dumb")
+	assert.False(detectUnicode())
+}
@@ -0,0 +1,69 @@
+package alog
+
+import (
+	"flag"
+	"strconv"
+)
+
+// SetVerbosity sets how much optional output the Logger writes: lines logged
+// through V(n) are written only if n is at most the verbosity. Unless set,
+// a Logger has the verbosity of DefaultLogger, which is zero by default.
+func (l *Logger) SetVerbosity(n int) { l.verbosity.set(n) }
+
+// Verbosity returns the Logger's verbosity; see SetVerbosity.
+func (l *Logger) Verbosity() int { return intDefaulted(&l.verbosity, &DefaultLogger.verbosity) }
+
+// V returns the Logger if its verbosity is at least n, and a disabled Logger
+// otherwise (see If), in the manner of glog: alog.V(2).Printf(...) only prints
+// when running with -vv. The result is an ordinary Logger, so Warnf and the
+// rest work too.
+func (l *Logger) V(n int) *Logger { return l.If(l.Verbosity() >= n) }
+
+func SetVerbosity(n int) { DefaultLogger.SetVerbosity(n) }
+func Verbosity() int     { return DefaultLogger.Verbosity() }
+func V(n int) *Logger    { return DefaultLogger.V(n) }
+
+// RegisterVerbosityFlags defines -v and -vv on fs (flag.CommandLine if nil),
+// which raise the Logger's verbosity by one and two each time they're given,
+// so that -v -v and -vv are the same. -v=n sets the verbosity to n.
+func (l *Logger) RegisterVerbosityFlags(fs *flag.FlagSet) {
+	if fs == nil {
+		fs = flag.CommandLine
+	}
+	fs.Var(&verbosityFlag{l, 1}, "v", "log more verbosely; repeat for more")
+	fs.Var(&verbosityFlag{l, 2}, "vv", "log even more verbosely, like -v -v")
+}
+
+func RegisterVerbosityFlags(fs *flag.FlagSet) { DefaultLogger.RegisterVerbosityFlags(fs) }
+
+// A verbosityFlag is a boolean flag that adds step to a Logger's verbosity
+// each time it's given.
+type verbosityFlag struct {
+	l    *Logger
+	step int
+}
+
+func (f *verbosityFlag) IsBoolFlag() bool { return true }
+
+func (f *verbosityFlag) String() string {
+	if f == nil || f.l == nil {
+		return "0"
+	}
+	return strconv.Itoa(f.l.Verbosity())
+}
+
+func (f *verbosityFlag) Set(s string) error {
+	switch s {
+	case "true":
+		f.l.SetVerbosity(f.l.Verbosity() + f.step)
+		return nil
+	case "false":
+		return nil
+	}
+	n, err := strconv.Atoi(s)
+	if err != nil {
+		return err
+	}
+	f.l.SetVerbosity(n)
+	return nil
+}
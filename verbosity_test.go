@@ -0,0 +1,42 @@
+package alog
+
+import (
+	"bytes"
+	"flag"
+	"io"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestVerbosity(t *testing.T) {
+	assert := assert.New(t)
+	var buf bytes.Buffer
+	writer := New(&buf, "", 0)
+	defer writer.Close()
+	writer.V(0).Printf("always\n")
+	writer.V(1).Printf("verbose\n")
+	assert.Equal("always\n", buf.String())
+	buf.Reset()
+	writer.SetVerbosity(2)
+	writer.V(1).Printf("verbose\n")
+	writer.V(2).Warnf("more verbose\n")
+	writer.V(3).Printf("too verbose\n")
+	assert.Equal("verbose\nmore verbose\n", string(uncolorize(buf.Bytes())))
+}
+
+func TestRegisterVerbosityFlags(t *testing.T) {
+	assert := assert.New(t)
+	var buf bytes.Buffer
+	writer := New(&buf, "", 0)
+	defer writer.Close()
+	fs := flag.NewFlagSet("test", flag.ContinueOnError)
+	fs.SetOutput(io.Discard)
+	writer.RegisterVerbosityFlags(fs)
+	assert.NoError(fs.Parse([]string{"-v", "-vv", "arg"}))
+	assert.Equal(3, writer.Verbosity())
+	assert.Equal([]string{"arg"}, fs.Args())
+	assert.NoError(fs.Parse([]string{"-v=1"}))
+	assert.Equal(1, writer.Verbosity())
+	assert.Error(fs.Parse([]string{"-v=lots"}))
+}
@@ -0,0 +1,62 @@
+package alog
+
+import "fmt"
+
+// A PrefixOverride writes to a Logger with a different prefix, for a single
+// call at a time. See WithPrefix.
+type PrefixOverride struct {
+	l      *Logger
+	prefix string
+}
+
+// WithPrefix returns a PrefixOverride for writing with prefix (a template, as
+// for SetPrefix) in place of the Logger's own, e.g.
+// l.WithPrefix("[worker 3] ").Printf(...). Unlike SetPrefix, it leaves the
+// Logger untouched, so goroutines sharing a Logger can each use their own
+// prefix without racing. The prefix applies to lines completed by the call;
+// a partial line left behind is shown, and later completed, with the
+// Logger's own prefix.
+func (l *Logger) WithPrefix(prefix string) *PrefixOverride {
+	return &PrefixOverride{l: l, prefix: prefix}
+}
+
+func WithPrefix(prefix string) *PrefixOverride { return DefaultLogger.WithPrefix(prefix) }
+
+// output writes what format returns with the override prefix. calldepth is
+// relative to the caller of output. format runs before the writer lock is
+// taken, so that String methods that log don't deadlock.
+func (p *PrefixOverride) output(calldepth int, level Level, format func() []byte) {
+	l := p.l
+	s := format()
+	ws := getWriterState(l.out)
+	ws.lock()
+	defer ws.unlock()
+	l.callPrefix = l.processTemplates([]byte(p.prefix))
+	l.levelOutput(level, calldepth+1, s)
+	l.callPrefix = nil
+}
+
+// Print is like Logger.Print.
+func (p *PrefixOverride) Print(v ...interface{}) {
+	p.output(2, LevelInfo, func() []byte { return []byte(fmt.Sprint(v...)) })
+}
+
+// Printf is like Logger.Printf.
+func (p *PrefixOverride) Printf(format string, v ...interface{}) {
+	p.output(2, LevelInfo, func() []byte { return fmt.Appendf(nil, p.l.applyColorTemplates(format), v...) })
+}
+
+// Println is like Logger.Println.
+func (p *PrefixOverride) Println(v ...interface{}) {
+	p.output(2, LevelInfo, func() []byte { return []byte(fmt.Sprintln(v...)) })
+}
+
+// Warnf is like Logger.Warnf.
+func (p *PrefixOverride) Warnf(format string, v ...interface{}) {
+	p.output(2, LevelWarn, func() []byte { return fmt.Appendf(nil, p.l.applyColorTemplates(format), v...) })
+}
+
+// Errorf is like Logger.Errorf.
+func (p *PrefixOverride) Errorf(format string, v ...interface{}) {
+	p.output(2, LevelError, func() []byte { return fmt.Appendf(nil, p.l.applyColorTemplates(format), v...) })
+}
@@ -0,0 +1,30 @@
+package alog
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWithPrefix(t *testing.T) {
+	assert := assert.New(t)
+	var buf bytes.Buffer
+	var writer = New(&buf, "main: ", 0)
+	defer writer.Close()
+	writer.DisableColor()
+	writer.WithPrefix("worker: ").Printf("%d\n", 1)
+	writer.Println("done")
+	assert.Equal("worker: 1\nmain: done\n", buf.String())
+	assert.Equal("main: ", writer.Prefix())
+}
+
+func TestWithPrefixLoggingArgument(t *testing.T) {
+	assert := assert.New(t)
+	var buf bytes.Buffer
+	var writer = New(&buf, "main: ", 0)
+	defer writer.Close()
+	writer.DisableColor()
+	writer.WithPrefix("worker: ").Printf("%v\n", loggingStringer{writer})
+	assert.Equal("main: formatting\nworker: value\n", buf.String(), "arguments are formatted before taking the lock")
+}
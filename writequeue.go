@@ -0,0 +1,320 @@
+package alog
+
+import (
+	"errors"
+	"io"
+	"sync"
+)
+
+// Don't recycle buffers that grew unusually large.
+const maxRecycledChunkSize = 64 << 10
+const maxRecycledChunks = 16
+
+// DefaultMaxQueuedBytes is the default limit on output waiting to be written
+// to a single writer before its OverflowPolicy kicks in.
+const DefaultMaxQueuedBytes = 1 << 20
+
+// An OverflowPolicy determines what happens to new output when the output
+// already queued for a writer exceeds its limit, which happens when the
+// writer stops accepting data (e.g. a stopped pager on the other end of
+// stdout).
+type OverflowPolicy int
+
+const (
+	// OverflowBlock makes logging calls wait until the writer catches up.
+	OverflowBlock OverflowPolicy = iota
+	// OverflowDropOldest discards the oldest queued output to make room.
+	OverflowDropOldest
+	// OverflowDropNewest discards the new output.
+	OverflowDropNewest
+)
+
+// A writeQueue serializes writes to a single io.Writer. Chunks are written in
+// the order they were pushed. Whichever goroutine finds the queue idle when it
+// calls drain does the writing, including for any chunks pushed by others in
+// the meantime; everyone else returns immediately. That keeps the common,
+// uncontended case synchronous while preventing a slow Writer from blocking
+// more than one caller. Callers that need the output written before they go
+// on, such as Flush, use flush or wait instead.
+type writeQueue struct {
+	mutex    sync.Mutex
+	cond     *sync.Cond // signalled when queued output has been written
+	chunks   [][]byte
+	spare    [][]byte // recycled chunk list
+	free     [][]byte // recycled chunk buffers
+	draining bool
+	queued   int // bytes in chunks
+	limit    int // 0 means DefaultMaxQueuedBytes
+	policy   OverflowPolicy
+	dropped  int         // chunks dropped since last reported
+	err      error       // most recent error returned by the writer
+	onError  func(error) // called with each error returned by the writer
+	// brokenPipe is set once the writer has reported that its reader went
+	// away, and hideTempOnBrokenPipe says whether to stop drawing temp lines
+	// from then on.
+	brokenPipe           bool
+	hideTempOnBrokenPipe bool
+	syncer               *fileSyncer // nil unless a SyncPolicy applies
+	maxWrite             int         // largest single write, if nonzero (see ChunkedWrites)
+}
+
+// push queues a copy of p, applying the overflow policy if the queue is full.
+func (q *writeQueue) push(p []byte) {
+	q.mutex.Lock()
+	defer q.mutex.Unlock()
+	for q.isFull(len(p)) {
+		switch q.policy {
+		case OverflowDropNewest:
+			q.dropped++
+			return
+		case OverflowDropOldest:
+			q.queued -= len(q.chunks[0])
+			q.recycleChunk(q.chunks[0])
+			q.chunks = q.chunks[1:]
+			q.dropped++
+		default:
+			q.getCond().Wait()
+		}
+	}
+	var chunk []byte
+	if n := len(q.free); n > 0 {
+		chunk = q.free[n-1]
+		q.free = q.free[:n-1]
+	}
+	q.chunks = append(q.chunks, append(chunk[:0], p...))
+	q.queued += len(p)
+}
+
+// isFull reports whether adding n bytes would overflow the queue. A chunk is
+// always accepted into an empty queue, however large.
+func (q *writeQueue) isFull(n int) bool {
+	limit := q.limit
+	if limit == 0 {
+		limit = DefaultMaxQueuedBytes
+	}
+	return len(q.chunks) > 0 && q.queued+n > limit
+}
+
+func (q *writeQueue) drain(out io.Writer) {
+	q.mutex.Lock()
+	if q.draining {
+		q.mutex.Unlock()
+		return
+	}
+	q.draining = true
+	var errs []error
+	for len(q.chunks) > 0 {
+		chunks := q.chunks
+		q.chunks = q.spare[:0]
+		q.spare = nil
+		q.queued = 0
+		if q.brokenPipe {
+			// Nobody is listening anymore; don't flood the writer with
+			// writes that will only fail with EPIPE again.
+			q.recycle(chunks)
+			continue
+		}
+		maxWrite := q.maxWrite
+		q.mutex.Unlock()
+		errs = append(errs, q.writeChunks(out, chunks, maxWrite)...)
+		q.mutex.Lock()
+		if file := q.syncer.afterWrite(q); file != nil {
+			q.mutex.Unlock()
+			syncFile(file)
+			q.mutex.Lock()
+		}
+		q.recycle(chunks)
+		if q.cond != nil {
+			q.cond.Broadcast()
+		}
+	}
+	q.stopDraining()
+	onError := q.onError
+	q.mutex.Unlock()
+	// Only report errors once done, so that onError may log to this writer
+	// even while someone holding its lock waits in flush.
+	if onError != nil {
+		for _, err := range errs {
+			onError(err)
+		}
+	}
+}
+
+// flush writes out everything queued so far, waiting for whichever goroutine
+// is already writing, if any, to finish.
+func (q *writeQueue) flush(out io.Writer) {
+	q.drain(out)
+	q.wait()
+}
+
+// wait blocks until no goroutine is writing queued output, at which point
+// everything queued before the call has been written.
+func (q *writeQueue) wait() {
+	q.mutex.Lock()
+	defer q.mutex.Unlock()
+	for q.draining {
+		q.getCond().Wait()
+	}
+}
+
+// stopDraining must be called with q.mutex held.
+func (q *writeQueue) stopDraining() {
+	q.draining = false
+	if q.cond != nil {
+		q.cond.Broadcast()
+	}
+}
+
+// getCond must be called with q.mutex held.
+func (q *writeQueue) getCond() *sync.Cond {
+	if q.cond == nil {
+		q.cond = sync.NewCond(&q.mutex)
+	}
+	return q.cond
+}
+
+func (q *writeQueue) writeChunks(out io.Writer, chunks [][]byte, maxWrite int) (errs []error) {
+	defer func() {
+		if r := recover(); r != nil {
+			// Don't leave the queue wedged in the draining state if out panics.
+			q.mutex.Lock()
+			q.stopDraining()
+			q.mutex.Unlock()
+			panic(r)
+		}
+	}()
+	for _, chunk := range chunks {
+		for len(chunk) > 0 {
+			piece := splitWrite(chunk, maxWrite)
+			chunk = chunk[len(piece):]
+			if _, err := out.Write(piece); err != nil {
+				errs = append(errs, err)
+				if q.recordError(err) {
+					return errs
+				}
+				break
+			}
+		}
+	}
+	return errs
+}
+
+// recordError records an error returned by the writer and reports whether it
+// means the pipe is broken.
+func (q *writeQueue) recordError(err error) bool {
+	q.mutex.Lock()
+	q.err = err
+	brokenPipe := isBrokenPipe(err)
+	if brokenPipe {
+		q.brokenPipe = true
+	}
+	q.mutex.Unlock()
+	return brokenPipe
+}
+
+func isBrokenPipe(err error) bool {
+	return isEPIPE(err) || errors.Is(err, io.ErrClosedPipe)
+}
+
+func (q *writeQueue) getErr() error {
+	q.mutex.Lock()
+	defer q.mutex.Unlock()
+	return q.err
+}
+
+// tempLinesSuspended reports whether temp lines should no longer be drawn
+// because the reader on the other end of the writer is gone.
+func (q *writeQueue) tempLinesSuspended() bool {
+	q.mutex.Lock()
+	defer q.mutex.Unlock()
+	return q.brokenPipe && q.hideTempOnBrokenPipe
+}
+
+func (q *writeQueue) recycle(chunks [][]byte) {
+	for i, chunk := range chunks {
+		q.recycleChunk(chunk)
+		chunks[i] = nil
+	}
+	q.spare = chunks[:0]
+}
+
+func (q *writeQueue) recycleChunk(chunk []byte) {
+	if cap(chunk) <= maxRecycledChunkSize && len(q.free) < maxRecycledChunks {
+		q.free = append(q.free, chunk)
+	}
+}
+
+// takeDropped returns the number of chunks dropped since the last call, once
+// the writer has caught up again; until then it returns 0.
+func (q *writeQueue) takeDropped() int {
+	q.mutex.Lock()
+	defer q.mutex.Unlock()
+	if q.draining || len(q.chunks) > 0 {
+		return 0
+	}
+	dropped := q.dropped
+	q.dropped = 0
+	return dropped
+}
+
+func (q *writeQueue) setPolicy(policy OverflowPolicy, limit int) {
+	q.mutex.Lock()
+	defer q.mutex.Unlock()
+	q.policy = policy
+	q.limit = limit
+	if q.cond != nil {
+		// Blocked pushers need to re-evaluate under the new policy.
+		q.cond.Broadcast()
+	}
+}
+
+// SetOverflowPolicy sets what happens when more than maxQueuedBytes of output
+// is waiting to be written to the Logger's writer, e.g. because it is a pipe
+// nobody is reading from. This applies to all Loggers sharing the writer. A
+// maxQueuedBytes of 0 means DefaultMaxQueuedBytes. When output has been
+// dropped, a notice with the number of dropped writes is printed once the
+// writer catches up.
+func (l *Logger) SetOverflowPolicy(policy OverflowPolicy, maxQueuedBytes int) {
+	getWriterState(l.out).queue.setPolicy(policy, maxQueuedBytes)
+}
+
+func SetOverflowPolicy(policy OverflowPolicy, maxQueuedBytes int) {
+	DefaultLogger.SetOverflowPolicy(policy, maxQueuedBytes)
+}
+
+// Err returns the most recent error returned by the Logger's writer, or nil if
+// every write so far has succeeded. Errors are otherwise not reported by the
+// logging functions, as the output is written asynchronously with respect to
+// some callers.
+func (l *Logger) Err() error {
+	return getWriterState(l.out).queue.getErr()
+}
+
+// OnWriteError sets a function to be called with every error returned by the
+// Logger's writer, replacing any previous one. This applies to all Loggers
+// sharing the writer. The function is called without any alog locks held, from
+// whichever goroutine happened to be writing, so it may log (though output to
+// the failing writer will likely fail again).
+func (l *Logger) OnWriteError(fn func(err error)) {
+	q := &getWriterState(l.out).queue
+	q.mutex.Lock()
+	defer q.mutex.Unlock()
+	q.onError = fn
+}
+
+// SetHidePartialLinesOnBrokenPipe sets whether to stop rendering partial lines
+// once the Logger's writer has returned EPIPE, i.e. the reader went away. This
+// is the default. Either way, once the pipe is broken, nothing more is written
+// to the writer: completed lines are silently discarded rather than producing
+// a flood of EPIPE errors (OnWriteError is called once, for the first one).
+// This applies to all Loggers sharing the writer.
+func (l *Logger) SetHidePartialLinesOnBrokenPipe(flag bool) {
+	q := &getWriterState(l.out).queue
+	q.mutex.Lock()
+	defer q.mutex.Unlock()
+	q.hideTempOnBrokenPipe = flag
+}
+
+func Err() error                                { return DefaultLogger.Err() }
+func OnWriteError(fn func(err error))           { DefaultLogger.OnWriteError(fn) }
+func SetHidePartialLinesOnBrokenPipe(flag bool) { DefaultLogger.SetHidePartialLinesOnBrokenPipe(flag) }
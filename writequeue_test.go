@@ -0,0 +1,150 @@
+package alog
+
+import (
+	"bytes"
+	"io"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// gatedWriter blocks every Write until the gate is opened.
+type gatedWriter struct {
+	mutex   sync.Mutex
+	buf     bytes.Buffer
+	gate    chan struct{}
+	started chan struct{}
+}
+
+func (w *gatedWriter) Write(p []byte) (int, error) {
+	select {
+	case w.started <- struct{}{}:
+	default:
+	}
+	<-w.gate
+	w.mutex.Lock()
+	defer w.mutex.Unlock()
+	return w.buf.Write(p)
+}
+
+func TestSlowWriterDoesNotBlockOtherCallers(t *testing.T) {
+	assert := assert.New(t)
+	out := &gatedWriter{gate: make(chan struct{}), started: make(chan struct{}, 1)}
+	writer1 := New(out, "", 0)
+	writer2 := New(out, "", 0)
+	writer1.HidePartialLines()
+	writer2.HidePartialLines()
+	done := make(chan struct{})
+	go func() {
+		writer1.Print("first\n")
+		close(done)
+	}()
+	<-out.started
+	returned := make(chan struct{})
+	go func() {
+		writer2.Print("second\n")
+		writer2.Print("third\n")
+		close(returned)
+	}()
+	select {
+	case <-returned:
+	case <-time.After(5 * time.Second):
+		t.Fatal("Print blocked behind a slow Write on another goroutine")
+	}
+	close(out.gate)
+	<-done
+	assert.Equal("first\nsecond\nthird\n", out.buf.String(), "queued output is written in order")
+}
+
+func TestFlushWaitsForOtherWriter(t *testing.T) {
+	assert := assert.New(t)
+	out := &gatedWriter{gate: make(chan struct{}), started: make(chan struct{}, 1)}
+	writer1 := New(out, "", 0)
+	writer2 := New(out, "", 0)
+	writer1.HidePartialLines()
+	writer2.HidePartialLines()
+	go writer1.Print("first\n")
+	<-out.started
+	flushed := make(chan struct{})
+	go func() {
+		writer2.Print("second\n")
+		writer2.Flush()
+		close(flushed)
+	}()
+	select {
+	case <-flushed:
+		t.Fatal("Flush returned before the output was written")
+	case <-time.After(50 * time.Millisecond):
+	}
+	close(out.gate)
+	<-flushed
+	out.mutex.Lock()
+	defer out.mutex.Unlock()
+	assert.Equal("first\nsecond\n", out.buf.String())
+}
+
+func TestOverflowPolicyDropNewest(t *testing.T) {
+	assert := assert.New(t)
+	out := &gatedWriter{gate: make(chan struct{}), started: make(chan struct{}, 1)}
+	writer1 := New(out, "", 0)
+	writer2 := New(out, "", 0)
+	writer1.HidePartialLines()
+	writer2.HidePartialLines()
+	writer1.SetOverflowPolicy(OverflowDropNewest, 10)
+	done := make(chan struct{})
+	go func() {
+		writer1.Print("stuck\n")
+		close(done)
+	}()
+	<-out.started
+	writer2.Print("kept\n")
+	writer2.Print("dropped\n")
+	writer2.Print("dropped\n")
+	close(out.gate)
+	<-done
+	writer2.Print("recovered\n")
+	assert.Equal("stuck\nkept\n[alog: dropped 2 writes while output was blocked]\nrecovered\n", out.buf.String())
+}
+
+// failingWriter records what it is given but always returns err.
+type failingWriter struct {
+	bytes.Buffer
+	err error
+}
+
+func (w *failingWriter) Write(p []byte) (int, error) {
+	w.Buffer.Write(p)
+	return 0, w.err
+}
+
+func TestWriteErrors(t *testing.T) {
+	assert := assert.New(t)
+	out := &failingWriter{err: io.ErrClosedPipe}
+	writer := New(out, "", 0)
+	defer writer.Close()
+	var reported []error
+	writer.OnWriteError(func(err error) { reported = append(reported, err) })
+	assert.Nil(writer.Err())
+	writer.Print("first\n")
+	assert.Equal(io.ErrClosedPipe, writer.Err())
+	assert.Equal([]error{io.ErrClosedPipe}, reported)
+	out.Reset()
+	writer.Print("partial")
+	writer.Print(" line\n")
+	assert.Equal("", out.String(), "nothing more is written once the pipe is broken")
+	assert.Equal([]error{io.ErrClosedPipe}, reported, "the error is only reported once")
+}
+
+func TestWriteErrorsNotBrokenPipe(t *testing.T) {
+	assert := assert.New(t)
+	out := &failingWriter{err: io.ErrShortWrite}
+	writer := New(out, "", 0)
+	defer writer.Close()
+	writer.Print("first\n")
+	assert.Equal(io.ErrShortWrite, writer.Err())
+	out.Reset()
+	writer.Print("partial")
+	assert.Equal("partial", out.String(), "other errors don't stop output")
+}